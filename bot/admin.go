@@ -0,0 +1,117 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// role is what a user may do in a club.
+type role int
+
+const (
+	roleMember role = iota // any subscriber of the club
+	roleAdmin              // a club organizer
+)
+
+// commandRoles is the permission table: the role a command requires. Commands
+// not listed are open to every subscriber.
+var commandRoles = map[string]role{
+	"start_vote":  roleAdmin,
+	"cancel_vote": roleAdmin,
+	"extend":      roleAdmin,
+}
+
+// adminSyncInterval is how long a club's synced admin list is trusted before
+// it is fetched from Telegram again.
+const adminSyncInterval = time.Hour
+
+// authorize checks the command in msg against the permission table. A user who
+// may not run it is told so; a user whose club is ambiguous is asked to pick one
+// (see clubFor). It reports whether the command may proceed.
+func (b *Bot) authorize(msg *tgbotapi.Message) (bool, error) {
+	if commandRoles[msg.Command()] != roleAdmin {
+		return true, nil
+	}
+
+	uid := msg.From.ID
+	chatID, err := b.clubFor(uid, nil)
+	if err != nil || chatID == 0 {
+		return false, err
+	}
+	admin, err := b.isClubAdmin(uid, chatID)
+	if err != nil {
+		return false, err
+	}
+	if !admin {
+		b.sendMessage(uid, b.messages.AdminOnlyCommand)
+		log.Printf("user %d is not an admin of club %d, refused /%s", uid, chatID, msg.Command())
+	}
+	return admin, nil
+}
+
+// isClubAdmin reports whether uid organizes the club: either listed in the
+// config's admins (organizers of every club) or one of the group's chat
+// administrators when syncing them is enabled.
+func (b *Bot) isClubAdmin(uid, chatID int64) (bool, error) {
+	if slices.Contains(b.cfg.Admins, uid) {
+		return true, nil
+	}
+	if !b.cfg.SyncChatAdmins {
+		return false, nil
+	}
+
+	club, err := b.clubRepository.GetClubById(context.Background(), chatID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load club %d: %w", chatID, err)
+	}
+	if club == nil {
+		return false, nil
+	}
+	admins := club.AdminIDs
+	if club.AdminsSyncedAt == nil || time.Since(*club.AdminsSyncedAt) > adminSyncInterval {
+		// On a failed sync the last known list is still the best answer.
+		if synced, err := b.syncClubAdmins(chatID); err != nil {
+			b.logSyncFailure(chatID, admins, err)
+		} else {
+			admins = synced
+		}
+	}
+	return slices.Contains(admins, uid), nil
+}
+
+// logSyncFailure logs a failed sync of a club's admins, as a warning when the
+// club is left with no admin at all: no one can then start a round in it.
+func (b *Bot) logSyncFailure(chatID int64, known []int64, err error) {
+	if len(b.cfg.Admins) == 0 && len(known) == 0 {
+		log.Printf("WARNING: cannot sync admins of club %d and no admins are listed, so no one can start a round in it: %v", chatID, err)
+		return
+	}
+	log.Printf("cannot sync admins of club %d: %v", chatID, err)
+}
+
+// syncClubAdmins fetches the group's human chat administrators and stores them
+// as the club's admins.
+func (b *Bot) syncClubAdmins(chatID int64) ([]int64, error) {
+	members, err := b.tgBot.GetChatAdministrators(tgbotapi.ChatAdministratorsConfig{
+		ChatConfig: tgbotapi.ChatConfig{ChatID: chatID},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int64
+	for _, m := range members {
+		if m.User != nil && !m.User.IsBot {
+			ids = append(ids, m.User.ID)
+		}
+	}
+	if err := b.clubRepository.SetClubAdmins(context.Background(), chatID, ids, time.Now().UTC()); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
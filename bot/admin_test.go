@@ -0,0 +1,111 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartVoteRequiresAdmin(t *testing.T) {
+	t.Run("admin from config", func(t *testing.T) {
+		b, fake, sessions := clubWithMembers(t, &config.AppConfig{Admins: []int64{1}}, 1, 2)
+
+		b.serve(fake.inject(dm(1, "/start_vote")))
+
+		assert.NotNil(t, sessions.latest(testClubID))
+		assert.Zero(t, fake.adminRequests, "chat admins are not synced unless enabled")
+	})
+
+	t.Run("non-admin is refused", func(t *testing.T) {
+		b, fake, sessions := clubWithMembers(t, &config.AppConfig{Admins: []int64{1}}, 1, 2)
+		b.messages.AdminOnlyCommand = "admins only"
+
+		b.serve(fake.inject(dm(2, "/start_vote")))
+
+		assert.Nil(t, sessions.latest(testClubID), "no round is started")
+		assert.Equal(t, []string{"admins only"}, fake.textsTo(2)[1:])
+	})
+
+	t.Run("group chat administrators are synced", func(t *testing.T) {
+		cfg := &config.AppConfig{SyncChatAdmins: true}
+		b, fake, sessions := roundBot(cfg)
+		fake.chatAdmins = map[int64][]int64{testClubID: {2}}
+		b.serve(fake.inject(
+			botAdded(testClubID, "Readers", testSelfID),
+			dm(1, "/subscribe"),
+			dm(2, "/subscribe"),
+			dm(1, "/start_vote"),
+		))
+		assert.Nil(t, sessions.latest(testClubID), "member 1 is not a chat administrator")
+
+		b.serve(fake.inject(dm(2, "/start_vote")))
+		assert.NotNil(t, sessions.latest(testClubID))
+
+		club, err := b.clubRepository.GetClubById(context.Background(), testClubID)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{2}, club.AdminIDs, "the bot itself is not an admin")
+		assert.Equal(t, 1, fake.adminRequests, "the list synced on joining is reused")
+	})
+
+	t.Run("a stale admin list is refreshed", func(t *testing.T) {
+		b, fake, _ := clubWithMembers(t, &config.AppConfig{SyncChatAdmins: true}, 1)
+		stale := time.Now().UTC().Add(-2 * adminSyncInterval)
+		require.NoError(t, b.clubRepository.SetClubAdmins(context.Background(), testClubID, nil, stale))
+		fake.chatAdmins = map[int64][]int64{testClubID: {1}}
+
+		admin, err := b.isClubAdmin(1, testClubID)
+		require.NoError(t, err)
+		assert.True(t, admin)
+	})
+
+	t.Run("a failed sync keeps the last known admins", func(t *testing.T) {
+		b, fake, _ := clubWithMembers(t, &config.AppConfig{SyncChatAdmins: true}, 1)
+		stale := time.Now().UTC().Add(-2 * adminSyncInterval)
+		require.NoError(t, b.clubRepository.SetClubAdmins(context.Background(), testClubID, []int64{1}, stale))
+		fake.adminsErr = errors.New("telegram is down")
+
+		admin, err := b.isClubAdmin(1, testClubID)
+		require.NoError(t, err)
+		assert.True(t, admin)
+	})
+}
+
+func TestOpenCommandsNeedNoAdmin(t *testing.T) {
+	b, fake, _ := clubWithMembers(t, &config.AppConfig{}, 1)
+
+	ok, err := b.authorize(dm(1, "/skip").Message)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Zero(t, fake.adminRequests)
+}
+
+func TestSubscribeNeedsMembership(t *testing.T) {
+	b, fake, _ := roundBot(&config.AppConfig{})
+	b.messages.NotInAnyClub = "not in any club"
+	b.messages.ChooseClubToSubscribe = "choose:"
+	fake.outsiders = map[int64][]int64{testClubID: {2}, testClubID - 1: {2, 3}}
+	b.serve(fake.inject(
+		botAdded(testClubID, "Readers", testSelfID),
+		botAdded(testClubID-1, "Poets", testSelfID),
+	))
+
+	// Member 1 is in both groups and is asked which; member 2 is in neither.
+	b.serve(fake.inject(dm(1, "/subscribe"), dm(2, "/subscribe"), dm(3, "/subscribe"), dm(3, "/subscribe 2")))
+
+	assert.Equal(t, "choose:\n1. Readers\n2. Poets", lastText(fake, 1))
+	assert.Equal(t, "not in any club", lastText(fake, 2))
+	sub, err := b.subRepository.GetSubscriberById(context.Background(), 2)
+	require.NoError(t, err)
+	assert.Nil(t, sub)
+
+	// Member 3 sees only the group they are in, so there is no second club.
+	sub, err = b.subRepository.GetSubscriberById(context.Background(), 3)
+	require.NoError(t, err)
+	require.NotNil(t, sub)
+	assert.Equal(t, []int64{testClubID}, sub.ClubIDs)
+}
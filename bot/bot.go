@@ -2,6 +2,7 @@ package bot
 
 import (
 	"BookClubBot/config"
+	"BookClubBot/internal/metadata"
 	"BookClubBot/internal/models"
 	"BookClubBot/internal/repository"
 	"BookClubBot/message"
@@ -9,6 +10,8 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"math"
+	"slices"
 	"strings"
 	"sync"
 	"time"
@@ -22,51 +25,74 @@ import (
 // of silently cancelling the round.
 var errNotEnoughBooks = errors.New("cannot run a poll as there is less than 2 books")
 
+// errRoundCancelled signals that the round was cancelled (by /cancel_vote, the
+// bot leaving the group or the recovery loop) while its poll was being sent.
+// The poll has been stopped and the session is left cancelled.
+var errRoundCancelled = errors.New("the round was cancelled while its poll was sent")
+
 type Bot struct {
-	// mu serializes the phase transitions (gathering → voting → completed) so
-	// that a deadline goroutine and the main update loop cannot both drive the
-	// same transition. The session in MongoDB is the source of truth.
+	// mu serializes the phase transitions (gathering → voting → reading →
+	// completed) so that a deadline goroutine and the main update loop cannot
+	// both drive the same transition. The session in MongoDB is the source of
+	// truth.
 	mu                 sync.Mutex
 	cfg                *config.AppConfig
-	tgBot              *tgbotapi.BotAPI
+	tgBot              messenger
+	selfID             int64 // the bot's own Telegram user ID
 	messages           *message.LocalizedMessages
 	subRepository      subscriberRepo
+	clubRepository     clubRepo
 	settingsRepository settingsRepo
 	sessionRepository  sessionRepo
+	bookRepository     bookRepo              // the shared book catalog; nil keeps none
+	metadata           metadata.Provider     // nil when no catalog is configured
+	links              metadata.LinkResolver // reads books from Goodreads and LiveLib links
+
+	// Book lookups run off the update loop: lookups counts the running ones.
+	// Guarded by lookupMu, inLookup holds the members they are for and
+	// lookedUp the answer last looked up for each member.
+	lookups  sync.WaitGroup
+	lookupMu sync.Mutex
+	inLookup map[int64]bool
+	lookedUp map[int64]string
 }
 
-func NewBot(cfg *config.AppConfig, messages *message.LocalizedMessages, subRepository subscriberRepo, settingsRepository settingsRepo, sessionRepository sessionRepo) *Bot {
+func NewBot(cfg *config.AppConfig, messages *message.LocalizedMessages, subRepository subscriberRepo, clubRepository clubRepo, settingsRepository settingsRepo, sessionRepository sessionRepo, bookRepository bookRepo) *Bot {
 	return &Bot{
 		cfg:                cfg,
 		messages:           messages,
 		subRepository:      subRepository,
+		clubRepository:     clubRepository,
 		settingsRepository: settingsRepository,
 		sessionRepository:  sessionRepository,
+		bookRepository:     bookRepository,
+		metadata:           newMetadataProvider(cfg),
+		links:              metadata.NewPageReader(),
 	}
 }
 
+// newMetadataProvider returns the catalog books are looked up in, or nil when
+// metadata_url is not set.
+func newMetadataProvider(cfg *config.AppConfig) metadata.Provider {
+	if cfg.MetadataURL == "" {
+		return nil
+	}
+	return metadata.NewOpenLibrary(cfg.MetadataURL, cfg.CoversURL)
+}
+
 // Run stars telegram bot on using provided API key from a config
 func (b *Bot) Run() {
-	var err error
-	b.tgBot, err = tgbotapi.NewBotAPI(b.cfg.TKey)
+	api, err := tgbotapi.NewBotAPI(b.cfg.TKey)
 
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	b.tgBot.Debug = b.cfg.DebugMode
-	groupId, err := b.settingsRepository.GetGroupId(context.Background())
-	if err != nil {
-		if errors.Is(err, repository.ErrNotFound) {
-			if err := b.settingsRepository.SaveGroupID(context.Background(), 0); err != nil {
-				log.Fatalf("error during setting a group id: '%v'", err)
-			}
-		} else {
-			log.Fatalf("unexpected error getting group id: '%v'", err)
-		}
-	}
-
-	b.cfg.GroupId = int64(groupId)
+	api.Debug = b.cfg.DebugMode
+	b.tgBot = api
+	b.selfID = api.Self.ID
+	b.migrateLegacyGroup()
+	b.backfillBooks()
 
 	// Drive deadlines and resume any in-flight round from persisted state.
 	b.startRecoveryLoop()
@@ -74,91 +100,140 @@ func (b *Bot) Run() {
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = b.cfg.LongPollingTimeout
 
-	updates := b.tgBot.GetUpdatesChan(u)
+	b.serve(b.tgBot.GetUpdatesChan(u))
+}
 
+// serve handles updates one at a time until the channel is closed, then waits
+// for the book lookups they started.
+func (b *Bot) serve(updates tgbotapi.UpdatesChannel) {
 	for update := range updates {
-		if update.Message != nil {
-			if update.Message.NewChatMembers != nil {
-				b.handleBotAdded(update)
-				continue
-			}
-			if update.Message.LeftChatMember != nil && update.Message.LeftChatMember.ID == b.tgBot.Self.ID {
-				b.handleBotRemoved()
-				continue
-			}
+		b.handleUpdate(update)
+	}
+	b.lookups.Wait()
+}
 
-			if update.Message.Chat.ID == b.cfg.GroupId {
-				continue // ignore all messages from group
-			}
+// handleUpdate dispatches a single update: the bot joining or leaving a group,
+// a DM from a user, or a poll answer.
+func (b *Bot) handleUpdate(update tgbotapi.Update) {
+	if update.Message != nil {
+		if update.Message.NewChatMembers != nil {
+			b.handleBotAdded(update)
+			return
+		}
+		if update.Message.LeftChatMember != nil && update.Message.LeftChatMember.ID == b.selfID {
+			b.handleBotRemoved(update.Message.Chat.ID)
+			return
+		}
 
-			s, err := b.subRepository.GetSubscriberById(context.Background(), update.Message.Chat.ID)
+		if !update.Message.Chat.IsPrivate() {
+			return // ignore all messages from the clubs' groups
+		}
 
-			if err != nil {
-				log.Printf("cannot execute 'FindById' from subRepository: %s", err)
-				msg := tgbotapi.NewMessage(update.Message.From.ID, b.messages.SomethingWrong)
-				b.tgBot.Send(msg)
-				continue
-			}
+		s, err := b.subRepository.GetSubscriberById(context.Background(), update.Message.Chat.ID)
 
-			// handle unsubscribed user's msg
-			if update.Message.Text != "/subscribe" && (s == nil || s.Archived == true) {
-				msg := tgbotapi.NewMessage(update.Message.From.ID, b.messages.NotSubscriber)
-				b.tgBot.Send(msg)
-				continue
-			}
+		if err != nil {
+			log.Printf("cannot execute 'FindById' from subRepository: %s", err)
+			msg := tgbotapi.NewMessage(update.Message.From.ID, b.messages.SomethingWrong)
+			b.tgBot.Send(msg)
+			return
+		}
 
-			// handle msgs from users
-			switch update.Message.Text {
-			case "/subscribe":
-				b.processCommand(&update, b.handleSubsribe)
-			case "/unsubscribe":
-				b.processCommand(&update, b.handleUnsubscribe)
-			case "/start_vote":
-				b.processCommand(&update, b.handleStartVote)
-			case "/skip":
-				b.handleSkip(&update)
-			case "/help":
-				b.handleHelp(&update)
-			default:
-				b.handleUserMsg(&update)
-			}
-			continue
+		// handle unsubscribed user's msg
+		if update.Message.Command() != "subscribe" && (s == nil || s.Archived == true) {
+			msg := tgbotapi.NewMessage(update.Message.From.ID, b.messages.NotSubscriber)
+			b.tgBot.Send(msg)
+			return
 		}
 
-		if update.PollAnswer != nil {
-			b.handlePollAnswer(update.PollAnswer)
+		// Organizer commands are refused to everyone but the club's admins.
+		if ok, err := b.authorize(update.Message); err != nil {
+			log.Printf("ERROR: %s", err)
+			b.sendMessage(update.Message.From.ID, b.messages.SomethingWrong)
+			return
+		} else if !ok {
+			return
 		}
-	}
-}
 
-func (b *Bot) handleBotRemoved() {
-	err := b.settingsRepository.SaveGroupID(context.Background(), 0)
-	if err != nil {
-		log.Printf("cannot handle bot removing: %v", err)
+		// handle msgs from users. Commands are matched without their
+		// arguments (and without a trailing @botname).
+		switch update.Message.Command() {
+		case "subscribe":
+			b.processCommand(&update, b.handleSubsribe)
+		case "unsubscribe":
+			b.processCommand(&update, b.handleUnsubscribe)
+		case "club":
+			b.processCommand(&update, b.handleClub)
+		case "start_vote":
+			b.processCommand(&update, b.handleStartVote)
+		case "cancel_vote":
+			b.processCommand(&update, b.handleCancelVote)
+		case "extend":
+			b.processCommand(&update, b.handleExtend)
+		case "skip":
+			b.handleSkip(&update)
+		case "back":
+			b.processCommand(&update, b.handleBack)
+		case "my_book":
+			b.processCommand(&update, b.handleMyBook)
+		case "edit":
+			b.processCommand(&update, b.handleEdit)
+		case "withdraw":
+			b.processCommand(&update, b.handleWithdraw)
+		case "finished":
+			b.processCommand(&update, b.handleFinished)
+		case "rate":
+			b.processCommand(&update, b.handleRate)
+		case "review":
+			b.processCommand(&update, b.handleReview)
+		case "abandon":
+			b.processCommand(&update, b.handleAbandon)
+		case "progress":
+			b.processCommand(&update, b.handleProgress)
+		case "help":
+			b.handleHelp(&update)
+		default:
+			b.handleUserMsg(&update)
+		}
 		return
 	}
-	b.cfg.GroupId = 0
-}
 
-func (b *Bot) handleBotAdded(update tgbotapi.Update) {
-	for _, member := range update.Message.NewChatMembers {
-		if member.IsBot && member.ID == b.tgBot.Self.ID {
-			groupId := update.Message.Chat.ID
-			err := b.settingsRepository.SaveGroupID(context.Background(), groupId)
-			if err != nil {
-				log.Printf("cannot handle bot adding: %v", err)
-				return
-			}
-			b.cfg.GroupId = groupId
-			b.sendMessage(groupId, b.messages.GreetingMessage)
-		}
+	if update.PollAnswer != nil {
+		b.handlePollAnswer(update.PollAnswer)
+	}
+
+	if update.CallbackQuery != nil {
+		b.handleCallback(update.CallbackQuery)
 	}
 }
 
-// handleSubsribe handles /subscribe command from a user that adds them to subs
-// if they are not subscribed yet
+// handleSubsribe handles /subscribe command from a user that adds them to a
+// club if they are not its member yet. When the bot serves several clubs the
+// user picks one with /subscribe <n>.
 func (b *Bot) handleSubsribe(update *tgbotapi.Update) error {
 	uid := update.Message.From.ID
+	all, err := b.clubRepository.GetAllClubs(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to load clubs: %w", err)
+	}
+	if len(all) == 0 {
+		b.sendMessage(uid, b.messages.NoClubsYet)
+		return nil
+	}
+	clubs := b.memberClubs(all, uid)
+	if len(clubs) == 0 {
+		b.sendMessage(uid, b.messages.NotInAnyClub)
+		return nil
+	}
+	club := clubs[0]
+	if len(clubs) > 1 {
+		n, ok := parseClubNumber(update.Message.CommandArguments(), len(clubs))
+		if !ok {
+			b.sendMessage(uid, b.messages.ChooseClubToSubscribe+clubList(clubs, 0))
+			return nil
+		}
+		club = clubs[n-1]
+	}
+
 	s, err := b.subRepository.GetSubscriberById(context.Background(), uid)
 	if err != nil {
 		return fmt.Errorf("failed to find subscriber with id %d: %w", uid, err)
@@ -172,51 +247,80 @@ func (b *Bot) handleSubsribe(update *tgbotapi.Update) error {
 			FirstName: update.Message.From.FirstName,
 			LastName:  update.Message.From.LastName,
 			JoinedAt:  time.Now(),
+			ClubIDs:   []int64{club.ChatID},
 		}
 		err = b.subRepository.SaveSubscriber(context.Background(), &newSub)
 		if err != nil {
 			return fmt.Errorf("failed to add a new subscriber: %w", err)
 		}
 		b.sendMessage(uid, b.messages.WelcomeBookClubNextVoting)
-		log.Printf("user %s %s subsribed\n", newSub.FirstName, newSub.LastName)
+		log.Printf("user %s %s subsribed to club %d\n", newSub.FirstName, newSub.LastName, club.ChatID)
 		return nil
 	}
 
-	//case2: Already subscribed and active
-	if !s.Archived {
+	//case2: Already an active member of the club
+	if !s.Archived && slices.Contains(s.ClubIDs, club.ChatID) {
 		msg := tgbotapi.NewMessage(update.Message.From.ID, b.messages.AlreadySubscribedWaitForVoting)
 		b.tgBot.Send(msg)
 		return nil
 	}
 
-	// case3: Reactivating archived subscriber
-	if err := b.subRepository.SetArchiveSubscriber(context.Background(), uid, false); err != nil {
-		return fmt.Errorf("failed to reactivate a subscriber with id %d : %w", uid, err)
+	// case3: Joining another club, or reactivating an archived subscriber
+	if err := b.subRepository.AddClub(context.Background(), uid, club.ChatID); err != nil {
+		return fmt.Errorf("failed to add a subscriber with id %d to club %d: %w", uid, club.ChatID, err)
+	}
+	if s.Archived {
+		b.sendMessage(uid, b.messages.WelcomeBack)
+		log.Printf("user %s %s reactivated\n", s.FirstName, s.LastName)
+		return nil
 	}
-	b.sendMessage(uid, b.messages.WelcomeBack)
-	log.Printf("user %s %s reactivated\n", s.FirstName, s.LastName)
+	b.sendMessage(uid, b.messages.WelcomeBookClubNextVoting)
+	log.Printf("user %s %s joined club %d\n", s.FirstName, s.LastName, club.ChatID)
 	return nil
 }
 
+// handleUnsubscribe removes the user from their club. A user left without any
+// club is archived.
 func (b *Bot) handleUnsubscribe(update *tgbotapi.Update) error {
 	uid := update.Message.From.ID
-	if err := b.subRepository.SetArchiveSubscriber(context.Background(), uid, true); err != nil {
-		return fmt.Errorf("failed to unsubsride a user with id %d : %w", uid, err)
+	chatID, err := b.clubFor(uid, nil)
+	if err != nil || chatID == 0 {
+		return err
+	}
+	if err := b.subRepository.RemoveClub(context.Background(), uid, chatID); err != nil {
+		return fmt.Errorf("failed to remove a user with id %d from club %d: %w", uid, chatID, err)
+	}
+
+	sub, clubs, err := b.subscriberClubs(uid)
+	if err != nil {
+		return err
+	}
+	if sub != nil && len(clubs) == 0 {
+		if err := b.subRepository.SetArchiveSubscriber(context.Background(), uid, true); err != nil {
+			return fmt.Errorf("failed to unsubsride a user with id %d : %w", uid, err)
+		}
 	}
-	log.Printf("user with user id: %d unsubsribed", uid)
+	log.Printf("user with user id: %d unsubsribed from club %d", uid, chatID)
 	b.sendMessage(uid, b.messages.Unsubsribed)
 	return nil
 }
 
-// handleStartVote opens a new book gathering session and DMs every active
-// subscriber to suggest a book.
+// handleStartVote opens a new book gathering session in the user's club and DMs
+// every active member of the club to suggest a book. Arguments override the
+// round's durations, name and theme (see parseRoundOptions).
 func (b *Bot) handleStartVote(update *tgbotapi.Update) error {
-	if b.cfg.GroupId == 0 {
-		b.sendMessage(update.Message.From.ID, b.messages.CannotStartGatheringGroupIdMissing)
+	opts, err := parseRoundOptions(update.Message.CommandArguments())
+	if err != nil {
+		log.Printf("bad /start_vote arguments: %v", err)
+		b.sendMessage(update.Message.From.ID, b.messages.StartVoteUsage)
 		return nil
 	}
+	chatID, err := b.clubFor(update.Message.From.ID, nil)
+	if err != nil || chatID == 0 {
+		return err
+	}
 
-	subs, err := b.subRepository.GetAllSubscribers(context.Background())
+	subs, err := b.subRepository.GetAllSubscribers(context.Background(), chatID)
 	if err != nil {
 		return fmt.Errorf("failed to load subscribers: %w", err)
 	}
@@ -234,13 +338,21 @@ func (b *Bot) handleStartVote(update *tgbotapi.Update) error {
 		})
 	}
 
+	name := opts.name
+	if name == "" {
+		name = now.Format("January 2006")
+	}
+	settings := opts.settings(b.cfg)
 	session := &models.BookClubSession{
-		Name:      now.Format("January 2006"),
+		ChatID:    chatID,
+		Name:      name,
+		Theme:     opts.theme,
+		Settings:  settings,
 		Status:    models.StatusGathering,
 		CreatedBy: update.Message.From.ID,
 		Gathering: models.Gathering{
-			Deadline:     now.Add(time.Duration(b.cfg.TimeToGatherBooks) * time.Second),
-			NotifyAt:     now.Add(time.Duration(b.cfg.TimeToGatherBooks-b.cfg.NotifyBeforeGathering) * time.Second),
+			Deadline:     now.Add(time.Duration(settings.GatherSeconds) * time.Second),
+			NotifyAt:     reminderAt(now, now.Add(time.Duration(settings.GatherSeconds)*time.Second), b.cfg.NotifyBeforeGathering),
 			Participants: participants,
 		},
 	}
@@ -254,6 +366,9 @@ func (b *Bot) handleStartVote(update *tgbotapi.Update) error {
 	}
 
 	for _, p := range participants {
+		if session.Theme != "" {
+			b.sendMessage(p.SubscriberID, fmt.Sprintf(b.messages.RoundTheme, session.Theme))
+		}
 		b.sendMessage(p.SubscriberID, b.messages.PleaseSuggestBookTitle)
 	}
 
@@ -262,16 +377,24 @@ func (b *Bot) handleStartVote(update *tgbotapi.Update) error {
 	return nil
 }
 
-// handleUserMsg handles any free-text message from a user during book gathering.
+// handleUserMsg handles any free-text message from a user: a book submission
+// answer during gathering, or a rating/review answer during reading.
 func (b *Bot) handleUserMsg(update *tgbotapi.Update) {
 	uid := update.Message.From.ID
 
-	session, err := b.sessionRepository.GetActiveSession(context.Background())
+	chatID, session, err := b.activeSessionFor(uid, awaitsAnswer(uid))
 	if err != nil {
 		log.Printf("cannot get active session: %v", err)
 		b.sendMessage(uid, b.messages.SomethingWrong)
 		return
 	}
+	if chatID == 0 {
+		return
+	}
+	if session != nil && session.Status == models.StatusReading {
+		b.handleReadingAnswer(session, update)
+		return
+	}
 	if session == nil || session.Status != models.StatusGathering {
 		b.sendMessage(uid, b.messages.VotingNotStartedOrEnded)
 		return
@@ -289,59 +412,52 @@ func (b *Bot) handleUserMsg(update *tgbotapi.Update) {
 	// the step just applied — no need to reload. If everyone has finished or
 	// skipped, move straight to the poll.
 	if allBooksChosen(session) {
-		b.runTelegramPollFlow()
+		b.runTelegramPollFlow(session.ChatID)
 	}
 }
 
-// handleParticipantAnswer advances one participant's submission flow and
-// persists each step.
+// handleParticipantAnswer advances one participant's submission flow (the
+// questionnaire, title first) and persists each step. A link to the book's
+// page is read, and when a catalog is configured the title (or an ISBN) is
+// looked up first; a confirmed match skips the questions it answers. A title
+// close to another participant's book is held until the participant says
+// whether theirs is a different one.
 func (b *Bot) handleParticipantAnswer(session *models.BookClubSession, p *models.Participant, update *tgbotapi.Update) {
 	uid := update.Message.From.ID
 
 	switch p.Step {
 	case models.StepBook:
 		title := strings.TrimSpace(update.Message.Text)
-		if isBookAlreadyProposed(session, title) {
-			b.sendMessage(uid, b.messages.BookAlreadyProposed)
+		if b.needsLookup(title) {
+			b.startLookup(session, p, title)
 			return
 		}
-		p.Book = &models.Book{Title: title}
-		p.Step = models.StepAuthor
-		b.persistParticipant(session.ID, p)
-		b.sendMessage(uid, b.messages.WhoIsAuthor)
-
-	case models.StepAuthor:
-		p.Book.Author = update.Message.Text
-		p.Step = models.StepDescription
-		b.persistParticipant(session.ID, p)
-		b.sendMessage(uid, b.messages.WriteBookDescription)
-
-	case models.StepDescription:
-		p.Book.Description = update.Message.Text
-		p.Step = models.StepImage
-		b.persistParticipant(session.ID, p)
-		b.sendMessage(uid, b.messages.AttachCoverPhoto)
-
-	case models.StepImage:
-		hasPhoto := update.Message.Photo != nil
-		if hasPhoto {
-			photo := (update.Message.Photo)[len(update.Message.Photo)-1]
-			p.Book.PhotoID = photo.FileID
-		}
-		now := time.Now().UTC()
-		p.Step = models.StepDone
-		p.SubmittedAt = &now
-		b.persistParticipant(session.ID, p)
-
-		if hasPhoto {
-			b.sendMessage(uid, b.messages.BookAddedToNextVoting)
-		} else {
-			b.sendMessage(uid, b.messages.ImageMissingBookAdded)
-		}
-		log.Printf("user: %s %s suggested a book.\n", p.FirstName, p.LastName)
+		q, _ := b.question(models.BookTitle)
+		if _, ok := b.readAnswer(uid, q, title, nil); !ok {
+			return
+		}
+		b.takeBook(session, p, &models.Book{Title: title})
+
+	case models.StepLookup:
+		// The lookup is still running, or was cut short by a restart.
+		b.sendMessage(uid, b.messages.BookLookupPending)
+		b.resumeLookup(session.ChatID, uid, p.Lookup)
+
+	case models.StepConfirm:
+		b.sendBookProposal(session, p)
+
+	case models.StepDuplicate:
+		b.sendDuplicateNotice(session, p)
 
 	case models.StepDone:
+		if p.Editing != "" {
+			b.handleEditAnswer(session, p, update)
+			return
+		}
 		b.sendMessage(uid, b.messages.VotingAlreadyCompleted)
+
+	default:
+		b.handleQuestionAnswer(session, p, update.Message)
 	}
 }
 
@@ -349,12 +465,15 @@ func (b *Bot) handleParticipantAnswer(session *models.BookClubSession, p *models
 func (b *Bot) handleSkip(update *tgbotapi.Update) {
 	uid := update.Message.From.ID
 
-	session, err := b.sessionRepository.GetActiveSession(context.Background())
+	chatID, session, err := b.activeSessionFor(uid, gatheringParticipant(uid))
 	if err != nil {
 		log.Printf("cannot get active session: %v", err)
 		b.sendMessage(uid, b.messages.SomethingWrong)
 		return
 	}
+	if chatID == 0 {
+		return
+	}
 	if session == nil || session.Status != models.StatusGathering {
 		b.sendMessage(uid, b.messages.VotingNotStartedOrEnded)
 		return
@@ -375,7 +494,7 @@ func (b *Bot) handleSkip(update *tgbotapi.Update) {
 	// session already reflects the skip (p points into it). The last pending
 	// user skipping should end the gathering too.
 	if allBooksChosen(session) {
-		b.runTelegramPollFlow()
+		b.runTelegramPollFlow(session.ChatID)
 	}
 }
 
@@ -384,66 +503,157 @@ func (b *Bot) handleHelp(update *tgbotapi.Update) {
 	b.sendMessage(update.Message.From.ID, b.messages.HelpInfo)
 }
 
-// handlePollAnswer records a vote and closes the poll once everyone has voted.
+// handlePollAnswer records a vote, or its retraction, and closes the poll once
+// everyone has voted. The answer names only the poll, so the club is found by
+// its voting session.
 func (b *Bot) handlePollAnswer(answer *tgbotapi.PollAnswer) {
-	session, err := b.sessionRepository.GetActiveSession(context.Background())
+	sessions, err := b.sessionRepository.GetActiveSessions(context.Background())
 	if err != nil {
-		log.Printf("cannot get active session for poll answer: %v", err)
+		log.Printf("cannot get active sessions for poll answer: %v", err)
 		return
 	}
-	if session == nil || session.Status != models.StatusVoting || session.Voting == nil {
+	session, heat := votingSessionForPoll(sessions, answer.PollID)
+	if session == nil {
 		return
 	}
 
-	if err := b.sessionRepository.AddVoter(context.Background(), session.ID, answer.User.ID); err != nil {
+	// Every answer is kept, even one that does not count (from an outsider, or
+	// picking too many books), so the tally can leave it out.
+	uid := answer.User.ID
+	record := &models.Answer{PollID: answer.PollID, VoterID: uid, OptionIDs: answer.OptionIDs, AnsweredAt: time.Now().UTC()}
+	if err := b.sessionRepository.SetAnswer(context.Background(), session.ID, record); err != nil {
+		log.Printf("cannot record poll answer: %v", err)
+	}
+	if !eligible(session.Voting, uid) {
+		if err := b.sessionRepository.AddOutsider(context.Background(), session.ID, uid); err != nil {
+			log.Printf("cannot record an outside voter: %v", err)
+		}
+		return
+	}
+	if len(answer.OptionIDs) == 0 {
+		// A retracted vote: the voter is undecided again until they revote.
+		b.removeVoter(session, heat, uid)
+		return
+	}
+	if limit := b.maxChoices(); len(answer.OptionIDs) > limit {
+		// The answer replaces a valid one the voter may have given before, so
+		// they have not voted until they revote within the limit.
+		b.removeVoter(session, heat, uid)
+		b.sendMessage(uid, fmt.Sprintf(b.messages.TooManyChoices, len(answer.OptionIDs), limit))
+		return
+	}
+
+	if heat >= 0 {
+		err = b.sessionRepository.AddHeatVoter(context.Background(), session.ID, heat, uid)
+	} else {
+		err = b.sessionRepository.AddVoter(context.Background(), session.ID, uid)
+	}
+	if err != nil {
 		log.Printf("cannot record voter: %v", err)
 		return
 	}
 
-	updated, err := b.sessionRepository.GetActiveSession(context.Background())
+	updated, err := b.sessionRepository.GetActiveSession(context.Background(), session.ChatID)
 	if err != nil || updated == nil || updated.Voting == nil {
 		return
 	}
-	if updated.Voting.TotalParticipants > 0 && len(updated.Voting.VoterIDs) >= updated.Voting.TotalParticipants {
-		b.closeTelegramPoll()
+	if allVoted(updated.Voting) {
+		b.closeTelegramPoll(session.ChatID)
 	}
 }
 
-// announceWinner defines a winner and sends a message to the group
-func (b *Bot) announceWinner(poll *tgbotapi.Poll) {
-	if b.cfg.GroupId == 0 {
-		log.Println("cannot announce winner as GroupId is not innit")
-		return
+// removeVoter takes uid out of the voters of the final poll, or of the given
+// heat (-1 for the final poll).
+func (b *Bot) removeVoter(session *models.BookClubSession, heat int, uid int64) {
+	var err error
+	if heat >= 0 {
+		err = b.sessionRepository.RemoveHeatVoter(context.Background(), session.ID, heat, uid)
+	} else {
+		err = b.sessionRepository.RemoveVoter(context.Background(), session.ID, uid)
+	}
+	if err != nil {
+		log.Printf("cannot take back a vote: %v", err)
+	}
+}
+
+// maxChoices is how many books a voter may pick in a poll.
+func (b *Bot) maxChoices() int {
+	if b.cfg.MaxChoices > 0 {
+		return b.cfg.MaxChoices
+	}
+	return 2
+}
+
+// votingSessionForPoll returns the voting session running the given Telegram
+// poll, and the index of the preliminary heat it is (-1 for the final poll). A
+// poll started before poll IDs were stored is matched only when it is the
+// single such session.
+func votingSessionForPoll(sessions []*models.BookClubSession, pollID string) (*models.BookClubSession, int) {
+	var legacy []*models.BookClubSession
+	for _, s := range sessions {
+		if s.Status != models.StatusVoting || s.Voting == nil {
+			continue
+		}
+		if s.Voting.PollID == pollID {
+			return s, -1
+		}
+		for i, h := range s.Voting.Heats {
+			if h.PollID == pollID {
+				return s, i
+			}
+		}
+		if s.Voting.PollID == "" && len(s.Voting.Heats) == 0 && s.Voting.Mode != models.VotingRanked {
+			legacy = append(legacy, s)
+		}
+	}
+	if len(legacy) == 1 {
+		return legacy[0], -1
+	}
+	return nil, -1
+}
+
+// announceWinner tells the group which book won, and how a tie was broken.
+func (b *Bot) announceWinner(chatID int64, winners []models.Winner, tieBreak *models.TieBreak) {
+	options := make([]string, len(winners))
+	for i, w := range winners {
+		options[i] = strings.TrimSpace(b.pollOptionFor(&models.Book{Title: w.Title, Author: w.Author}))
 	}
-	winners := defineWinners(poll)
+
 	var txt string
 	switch len(winners) {
 	case 0:
 		txt = b.messages.ErrorDeterminingWinner
 	case 1:
-		txt = fmt.Sprintf("%s - '%s'\n", b.messages.WeHaveAWinner, winners[0])
+		txt = fmt.Sprintf("%s - '%s'\n", b.messages.WeHaveAWinner, options[0])
 	default:
-		txt = fmt.Sprintf("%s: %s\n", b.messages.NoClearWinnerManualVoting, strings.Join(winners, ","))
+		txt = fmt.Sprintf("%s: %s\n", b.messages.NoClearWinnerManualVoting, strings.Join(options, ","))
+	}
+	if tieBreak != nil {
+		titles := make([]string, len(tieBreak.Tied))
+		for i, w := range tieBreak.Tied {
+			titles[i] = w.Title
+		}
+		how := b.messages.TieBrokenByDraw
+		if tieBreak.Method == models.TieBreakLongestWaiting {
+			how = b.messages.TieBrokenByWaiting
+		}
+		txt = fmt.Sprintf(how, strings.Join(titles, ", ")) + "\n" + txt
 	}
 
-	msg := tgbotapi.NewMessage(b.cfg.GroupId, txt)
+	msg := tgbotapi.NewMessage(chatID, txt)
 	b.tgBot.Send(msg)
 }
 
 // msgAboutGatheringBooks sends a media group of the gathered books to the group.
 func (b *Bot) msgAboutGatheringBooks(session *models.BookClubSession) {
-	if b.cfg.GroupId == 0 {
-		log.Println("cannot send a msg about gathering books as GroupId is not innit")
-		return
-	}
-
-	groupId := b.cfg.GroupId
+	groupId := session.ChatID
 	var mediaItems []interface{}
 	for _, p := range session.Gathering.Participants {
 		if p.Step != models.StepDone || p.Book == nil {
 			continue
 		}
 		vp := viewParticipant(p)
+		vp.book.attributes = b.bookAttributes(p.Book)
 		img := vp.bookImage()
 		img.Caption = truncateString(vp.bookCaption(), 1024)
 		img.ParseMode = "Markdown"
@@ -466,18 +676,18 @@ func (b *Bot) msgAboutGatheringBooks(session *models.BookClubSession) {
 			return
 		}
 		msg := tgbotapi.NewMediaGroup(groupId, batch)
-		if _, err := b.tgBot.Send(msg); err != nil {
+		if _, err := b.tgBot.SendMediaGroup(msg); err != nil {
 			log.Printf("ERROR: %s\n", err)
 		}
 	}
 }
 
-// runTelegramPollFlow ends the active book gathering and starts a telegram poll.
-// It claims the transition under b.mu by flipping the session to the voting
-// status; only the first caller that sees a gathering session proceeds.
-func (b *Bot) runTelegramPollFlow() {
+// runTelegramPollFlow ends the club's book gathering and starts a telegram
+// poll. It claims the transition under b.mu by flipping the session to the
+// voting status; only the first caller that sees a gathering session proceeds.
+func (b *Bot) runTelegramPollFlow(chatID int64) {
 	b.mu.Lock()
-	session, err := b.sessionRepository.GetActiveSession(context.Background())
+	session, err := b.sessionRepository.GetActiveSession(context.Background(), chatID)
 	if err != nil {
 		b.mu.Unlock()
 		log.Printf("cannot get active session: %v", err)
@@ -494,15 +704,20 @@ func (b *Bot) runTelegramPollFlow() {
 	}
 	b.mu.Unlock()
 
+	b.catalogProposals(session)
 	b.msgAboutGatheringBooks(session)
 
 	if err := b.runTelegramPoll(session); err != nil {
+		if errors.Is(err, errRoundCancelled) {
+			log.Printf("session %s was cancelled while its poll was sent", session.ID.Hex())
+			return
+		}
 		log.Printf("ERROR: cannot run poll: %v\n", err)
 		// Could not start a poll. End the round so a new one can be started, and
 		// tell the group why when the cause is too few books (rather than
 		// silently cancelling).
-		if errors.Is(err, errNotEnoughBooks) && b.cfg.GroupId != 0 {
-			b.sendMessage(b.cfg.GroupId, b.messages.NotEnoughBooksVotingCancelled)
+		if errors.Is(err, errNotEnoughBooks) {
+			b.sendMessage(chatID, b.messages.NotEnoughBooksVotingCancelled)
 		}
 		if err := b.sessionRepository.SetStatus(context.Background(), session.ID, models.StatusCancelled); err != nil {
 			log.Printf("cannot cancel session: %v", err)
@@ -515,10 +730,12 @@ func (b *Bot) runTelegramPollFlow() {
 }
 
 // runTelegramPoll creates and starts a poll for choosing a book in the group,
-// then persists the voting sub-document.
+// then persists the voting sub-document. When more books were gathered than fit
+// in one poll, preliminary heats are run first (see startVotingStage). In the
+// ranked voting mode members rank the books on DM ballots instead.
 func (b *Bot) runTelegramPoll(session *models.BookClubSession) error {
-	if b.cfg.GroupId == 0 {
-		return fmt.Errorf("cannot run telegram poll as groupId is not innit")
+	if session.ChatID == 0 {
+		return fmt.Errorf("cannot run telegram poll as the session has no club")
 	}
 
 	books := b.extractBooks(session)
@@ -526,51 +743,53 @@ func (b *Bot) runTelegramPoll(session *models.BookClubSession) error {
 		return errNotEnoughBooks
 	}
 
-	if len(books) > 10 {
-		log.Println("cannot use more than ten books in the poll... keeping the first ten")
-		books = books[0:10]
-	}
-
-	votingEnds := fmt.Sprintf(b.messages.VotingEndsInHours, (time.Duration(b.cfg.TimeForTelegramPoll) * time.Second).Hours())
-	txt := fmt.Sprintf("%s.%s", b.messages.ChooseUpToTwoBooks, votingEnds)
-	poll := tgbotapi.NewPoll(b.cfg.GroupId, txt, books...)
-	poll.IsAnonymous = false
-	poll.AllowsMultipleAnswers = true
-	msg, err := b.tgBot.Send(poll)
+	subs, err := b.subRepository.GetAllSubscribers(context.Background(), session.ChatID)
 	if err != nil {
 		return err
 	}
-	subs, err := b.subRepository.GetAllSubscribers(context.Background())
-	if err != nil {
+	voting := &models.Voting{}
+	b.eligibleVoters(session, voting, subs)
+	if b.cfg.VotingMode == models.VotingRanked {
+		b.startRankedVoting(session, voting, subs)
+	} else if err := b.startVotingStage(session, voting, books); err != nil {
 		return err
 	}
+	// b.mu is not held while the poll is sent, so the round may have been
+	// cancelled meanwhile; StartVoting then refuses to revive it.
+	err = b.sessionRepository.StartVoting(context.Background(), session.ID, voting)
+	if errors.Is(err, repository.ErrNotFound) {
+		b.stopPolls(session.ChatID, voting)
+		return errRoundCancelled
+	}
+	if err == nil && voting.Mode == models.VotingRanked {
+		b.sendBallots(session, voting)
+	}
+	return err
+}
 
-	now := time.Now().UTC()
-	voting := &models.Voting{
-		TelegramPollID:    msg.MessageID,
-		Deadline:          now.Add(time.Duration(b.cfg.TimeForTelegramPoll) * time.Second),
-		NotifyAt:          now.Add(time.Duration(b.cfg.TimeForTelegramPoll-b.cfg.NotifyBeforePoll) * time.Second),
-		TotalParticipants: len(subs),
-		StartedAt:         now,
-	}
-	return b.sessionRepository.StartVoting(context.Background(), session.ID, voting)
-}
-
-// closeTelegramPoll stops the poll, records the winner(s) and completes the
-// session. The state-changing section runs under b.mu so a deadline tick and an
-// all-voted close cannot both drive it. The session is completed only AFTER
-// StopPoll succeeds: a failed StopPoll leaves it in voting so the close can be
-// retried (by a later vote, or the recovery loop) instead of stranding an open
-// poll with no winner and a held active lock. announceWinner runs after the
-// lock is released — it is a plain group message and need not hold the lock.
-func (b *Bot) closeTelegramPoll() {
-	b.mu.Lock()
-	if b.cfg.GroupId == 0 {
-		b.mu.Unlock()
-		log.Println("cannot close a telegram poll as GroupId is not innit")
-		return
+// stopPolls stops the voting's open polls, so they collect no votes for a
+// round that no longer exists. A poll that cannot be stopped is logged.
+func (b *Bot) stopPolls(chatID int64, voting *models.Voting) {
+	for _, id := range openPolls(voting) {
+		stop := tgbotapi.StopPollConfig{BaseEdit: tgbotapi.BaseEdit{ChatID: chatID, MessageID: id}}
+		if _, err := b.tgBot.StopPoll(stop); err != nil {
+			log.Printf("cannot stop the poll of a cancelled round: %v", err)
+		}
 	}
-	session, err := b.sessionRepository.GetActiveSession(context.Background())
+}
+
+// closeTelegramPoll stops the poll (or tallies the ranked ballots), records the
+// winner(s) and either starts the reading phase (single winner) or completes
+// the session. The state-changing
+// section runs under b.mu so a deadline tick and an all-voted close cannot both
+// drive it. The session leaves voting only AFTER StopPoll succeeds: a failed
+// StopPoll leaves it in voting so the close can be retried (by a later vote, or
+// the recovery loop) instead of stranding an open poll with no winner and a
+// held active lock. The announcements run after the lock is released — they
+// are plain group messages and need not hold the lock.
+func (b *Bot) closeTelegramPoll(chatID int64) {
+	b.mu.Lock()
+	session, err := b.sessionRepository.GetActiveSession(context.Background(), chatID)
 	if err != nil {
 		b.mu.Unlock()
 		log.Printf("cannot get active session: %v", err)
@@ -581,28 +800,155 @@ func (b *Bot) closeTelegramPoll() {
 		log.Print("there is not an active poll, cannot close it")
 		return
 	}
-
-	finishPoll := tgbotapi.StopPollConfig{
-		BaseEdit: tgbotapi.BaseEdit{
-			ChatID:    b.cfg.GroupId,
-			MessageID: session.Voting.TelegramPollID,
-		},
-	}
-	res, err := b.tgBot.StopPoll(finishPoll)
-	if err != nil {
+	if inHeats(session.Voting) {
+		err := b.closeHeats(session)
 		b.mu.Unlock()
-		log.Printf("ERROR: %s", err)
+		if err != nil {
+			log.Printf("ERROR: cannot close preliminary polls: %v", err)
+		}
 		return
 	}
 
-	if winners := b.winnersFromPoll(session, &res); len(winners) > 0 {
+	now := time.Now().UTC()
+	ranked := session.Voting.Mode == models.VotingRanked
+	var winners []models.Winner
+	if ranked {
+		winners = tallyRanked(session.Voting)
+	} else {
+		finishPoll := tgbotapi.StopPollConfig{
+			BaseEdit: tgbotapi.BaseEdit{
+				ChatID:    chatID,
+				MessageID: session.Voting.TelegramPollID,
+			},
+		}
+		res, err := b.tgBot.StopPoll(finishPoll)
+		if err != nil {
+			b.mu.Unlock()
+			log.Printf("ERROR: %s", err)
+			return
+		}
+		counted := countedPoll(res, session.Voting, b.maxChoices())
+		winners = b.winnersFromPoll(session, &counted)
+		result := b.pollResult(session, session.Voting.Options, &res, &counted, now)
+		result.TelegramPollID, result.Runoff = session.Voting.TelegramPollID, session.Voting.Runoff
+		b.recordPollResult(session, result)
+	}
+
+	linkWinners(session, winners)
+
+	var tieBreak *models.TieBreak
+	if len(winners) > 1 {
+		// A tied runoff is not run off again; neither is a tie whose runoff
+		// poll could not be sent, as the tied poll is already stopped. A ranked
+		// vote's tie has survived every elimination, so it is broken directly.
+		if b.cfg.TieBreak != models.TieBreakRandom && b.cfg.TieBreak != models.TieBreakLongestWaiting && !session.Voting.Runoff && !ranked {
+			err := b.startRunoff(session, winners)
+			if err == nil {
+				b.mu.Unlock()
+				return
+			}
+			log.Printf("cannot start a runoff poll, drawing lots instead: %v", err)
+		}
+		var winner models.Winner
+		winner, tieBreak = b.breakTie(session, winners)
+		winners = []models.Winner{winner}
+		if err := b.sessionRepository.SetTieBreak(context.Background(), session.ID, tieBreak); err != nil {
+			log.Printf("cannot record the tie break: %v", err)
+		}
+	}
+	if len(winners) > 0 {
+		b.catalogWinners(session, winners)
 		if err := b.sessionRepository.SetWinners(context.Background(), session.ID, winners); err != nil {
 			log.Printf("cannot save winners: %v", err)
 		}
 	}
-	if err := b.sessionRepository.SetVotingClosed(context.Background(), session.ID, time.Now().UTC()); err != nil {
+	if err := b.sessionRepository.SetVotingClosed(context.Background(), session.ID, now); err != nil {
 		log.Printf("cannot stamp poll close time: %v", err)
 	}
+
+	// A single winner moves the club into reading it; a poll nobody voted in
+	// ends the round here.
+	var reading *models.Reading
+	if len(winners) == 1 {
+		reading = b.newReading(session, winners[0], now)
+		if err := b.sessionRepository.StartReading(context.Background(), session.ID, reading); err != nil {
+			b.mu.Unlock()
+			log.Printf("cannot start reading: %v", err)
+			return
+		}
+	} else if err := b.sessionRepository.SetStatus(context.Background(), session.ID, models.StatusCompleted); err != nil {
+		b.mu.Unlock()
+		log.Printf("cannot complete session: %v", err)
+		return
+	}
+	b.mu.Unlock()
+
+	b.announceWinner(chatID, winners, tieBreak)
+	if reading != nil {
+		b.announceReading(chatID, reading)
+	}
+}
+
+// newReading builds the reading sub-document for the winning book. Every
+// active member of the club becomes a reading member; if subscribers cannot be loaded,
+// the round's participants are used instead so the phase still starts.
+func (b *Bot) newReading(session *models.BookClubSession, winner models.Winner, now time.Time) *models.Reading {
+	reading := &models.Reading{
+		Book:         models.Book{Title: winner.Title, Author: winner.Author},
+		SubscriberID: winner.SubscriberID,
+		Deadline:     now.Add(time.Duration(b.cfg.TimeForReading) * time.Second),
+		StartedAt:    now,
+	}
+	if p := findParticipant(session, winner.SubscriberID); p != nil && p.Book != nil {
+		reading.Book = *p.Book
+	}
+
+	var ids []int64
+	subs, err := b.subRepository.GetAllSubscribers(context.Background(), session.ChatID)
+	if err != nil {
+		log.Printf("cannot load subscribers for reading, using participants: %v", err)
+		for _, p := range session.Gathering.Participants {
+			ids = append(ids, p.SubscriberID)
+		}
+	} else {
+		for _, sub := range subs {
+			ids = append(ids, sub.ID)
+		}
+	}
+
+	reading.Members = make([]*models.ReadingMember, 0, len(ids))
+	for _, id := range ids {
+		reading.Members = append(reading.Members, &models.ReadingMember{
+			SubscriberID: id,
+			Status:       models.ReadingInProgress,
+			StartedAt:    now,
+		})
+	}
+	return reading
+}
+
+// announceReading tells the group which book the club is reading and until when.
+func (b *Bot) announceReading(chatID int64, reading *models.Reading) {
+	txt := fmt.Sprintf(b.messages.ReadingStarted, reading.Book.Title, reading.Deadline.Format("02.01.2006"))
+	b.sendMessage(chatID, txt)
+}
+
+// finishReading ends the club's reading phase, completes the session and posts the
+// review digest to the group. Like the other transitions the status change runs
+// under b.mu and re-checks the status, so a second caller is a no-op; the
+// digest is sent after the lock is released.
+func (b *Bot) finishReading(chatID int64) {
+	b.mu.Lock()
+	session, err := b.sessionRepository.GetActiveSession(context.Background(), chatID)
+	if err != nil {
+		b.mu.Unlock()
+		log.Printf("cannot get active session: %v", err)
+		return
+	}
+	if session == nil || session.Status != models.StatusReading || session.Reading == nil {
+		b.mu.Unlock()
+		return
+	}
 	if err := b.sessionRepository.SetStatus(context.Background(), session.ID, models.StatusCompleted); err != nil {
 		b.mu.Unlock()
 		log.Printf("cannot complete session: %v", err)
@@ -610,22 +956,29 @@ func (b *Bot) closeTelegramPoll() {
 	}
 	b.mu.Unlock()
 
-	b.announceWinner(&res)
+	b.publishReadingDigest(session)
 }
 
-// extractBooks builds the shuffled poll options from the finished submissions.
-func (b *Bot) extractBooks(session *models.BookClubSession) []string {
-	books := make([]string, 0, len(session.Gathering.Participants))
+// extractBooks builds the shuffled poll options from the finished submissions,
+// each mapped to its book.
+func (b *Bot) extractBooks(session *models.BookClubSession) []models.PollOption {
+	books := make([]models.PollOption, 0, len(session.Gathering.Participants))
 	for _, p := range session.Gathering.Participants {
 		if p.Step == models.StepDone && p.Book != nil {
-			books = append(books, b.pollOptionFor(p.Book))
+			books = append(books, models.PollOption{
+				Text:         b.pollOptionFor(p.Book),
+				SubscriberID: p.SubscriberID,
+				Title:        p.Book.Title,
+				Author:       p.Book.Author,
+			})
 		}
 	}
 	return shuffleSlice(books)
 }
 
-// winnersFromPoll maps the winning poll option texts back to the books that
-// produced them.
+// winnersFromPoll maps the winning poll options back to the books behind them.
+// Options are resolved by index through the stored Voting.Options; a poll
+// started before those were stored falls back to matching the option texts.
 func (b *Bot) winnersFromPoll(session *models.BookClubSession, poll *tgbotapi.Poll) []models.Winner {
 	if poll == nil {
 		return nil
@@ -643,6 +996,15 @@ func (b *Bot) winnersFromPoll(session *models.BookClubSession, poll *tgbotapi.Po
 		return nil
 	}
 
+	if session.Voting != nil && len(session.Voting.Options) > 0 && len(session.Voting.Options) == len(poll.Options) {
+		var winners []models.Winner
+		for _, i := range leadingOptions(poll) {
+			o := session.Voting.Options[i]
+			winners = append(winners, models.Winner{SubscriberID: o.SubscriberID, Title: o.Title, Author: o.Author})
+		}
+		return winners
+	}
+
 	texts := defineWinners(poll)
 	if len(texts) == 0 {
 		return nil
@@ -669,17 +1031,48 @@ func (b *Bot) winnersFromPoll(session *models.BookClubSession, poll *tgbotapi.Po
 	return winners
 }
 
-// pollOptionFor renders the poll option text for a book. The same rendering is
-// used to build the poll and to match winners back to books, so they must stay
-// in sync.
+// leadingOptions returns the indexes of the options with the most votes.
+func leadingOptions(poll *tgbotapi.Poll) []int {
+	most := 0
+	for _, o := range poll.Options {
+		most = max(most, o.VoterCount)
+	}
+	var idx []int
+	for i, o := range poll.Options {
+		if most > 0 && o.VoterCount == most {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// pollOptionFor renders the poll option text for a book, cut to Telegram's
+// limit. Winners are resolved by option index; the text is matched back to a
+// book only for polls started before the options were stored.
 func (b *Bot) pollOptionFor(bk *models.Book) string {
-	return fmt.Sprintf("%s: %s. %s: %s\n", b.messages.BookLabel, bk.Title, b.messages.AuthorLabel, bk.Author)
+	return truncateOption(fmt.Sprintf("%s: %s. %s: %s\n", b.messages.BookLabel, bk.Title, b.messages.AuthorLabel, bk.Author))
+}
+
+// reminderAt is when to remind about a deadline: lead seconds before it, but
+// no earlier than halfway from start, so that a round shorter than the lead is
+// not reminded about the moment it starts.
+func reminderAt(start, deadline time.Time, lead int) time.Time {
+	before := time.Duration(lead) * time.Second
+	if half := deadline.Sub(start) / 2; before > half {
+		before = half
+	}
+	return deadline.Add(-before)
+}
+
+// hoursLeft is the time from now to deadline in whole hours, rounded up.
+func hoursLeft(now, deadline time.Time) float64 {
+	return math.Ceil(max(0, deadline.Sub(now).Hours()))
 }
 
 // notifyGatheringDeadline messages participants who have not finished before
 // the gathering deadline.
-func (b *Bot) notifyGatheringDeadline(session *models.BookClubSession) {
-	txt := fmt.Sprintf(b.messages.BookSubmissionDeadline, (time.Duration(b.cfg.NotifyBeforeGathering) * time.Second).Hours())
+func (b *Bot) notifyGatheringDeadline(session *models.BookClubSession, now time.Time) {
+	txt := fmt.Sprintf(b.messages.BookSubmissionDeadline, hoursLeft(now, session.Gathering.Deadline))
 	for _, p := range session.Gathering.Participants {
 		if p.Step != models.StepDone && p.Step != models.StepSkipped {
 			b.sendMessage(p.SubscriberID, txt)
@@ -687,14 +1080,10 @@ func (b *Bot) notifyGatheringDeadline(session *models.BookClubSession) {
 	}
 }
 
-// notifyPollDeadline messages the group before the poll deadline.
-func (b *Bot) notifyPollDeadline() {
-	if b.cfg.GroupId == 0 {
-		log.Println("cannot announce the deadline as GroupId is not innit")
-		return
-	}
-	txt := fmt.Sprintf(b.messages.VotingEndsInHours, (time.Duration(b.cfg.NotifyBeforePoll) * time.Second).Hours())
-	b.sendMessage(b.cfg.GroupId, txt)
+// notifyPollDeadline messages the club's group before the poll deadline.
+func (b *Bot) notifyPollDeadline(chatID int64, deadline, now time.Time) {
+	txt := fmt.Sprintf(b.messages.VotingEndsInHours, hoursLeft(now, deadline))
+	b.sendMessage(chatID, txt)
 }
 
 // findParticipant returns the participant with the given id, or nil.
@@ -707,17 +1096,6 @@ func findParticipant(session *models.BookClubSession, id int64) *models.Particip
 	return nil
 }
 
-// isBookAlreadyProposed reports whether a book with the given title was already
-// proposed by any participant.
-func isBookAlreadyProposed(session *models.BookClubSession, title string) bool {
-	for _, p := range session.Gathering.Participants {
-		if p.Book != nil && p.Book.Title == title {
-			return true
-		}
-	}
-	return false
-}
-
 // allBooksChosen reports whether every participant has finished or skipped.
 func allBooksChosen(session *models.BookClubSession) bool {
 	for _, p := range session.Gathering.Participants {
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"BookClubBot/internal/repository"
+	"context"
+	"errors"
+	"log"
+	"slices"
+	"strings"
+)
+
+// bookKeys returns the keys a book is found by in the book catalog: its title
+// normalized as for duplicates, and its author's normalized words in order, so
+// "Herbert, Frank" and "Frank Herbert" are one author.
+func bookKeys(title, author string) (string, string) {
+	titleKey := normalizeTitle(title)
+	if titleKey == "" {
+		titleKey = strings.ToLower(strings.TrimSpace(title))
+	}
+	words := strings.Fields(normalizeTitle(author))
+	slices.Sort(words)
+	return titleKey, strings.Join(words, " ")
+}
+
+// catalogProposals records the round's submitted books in the book catalog
+// and references each from its participant. It runs as the gathering closes,
+// so a withdrawn or unfinished book is not counted. The catalog is a record
+// of the rounds, not part of one: a failure is logged and the round goes on.
+func (b *Bot) catalogProposals(session *models.BookClubSession) {
+	if b.bookRepository == nil {
+		return
+	}
+	ctx := context.Background()
+	for _, p := range session.Gathering.Participants {
+		if p.Step != models.StepDone || p.Book == nil || !p.BookID.IsZero() {
+			continue
+		}
+		titleKey, authorKey := bookKeys(p.Book.Title, p.Book.Author)
+		proposal := models.BookProposal{
+			SessionID:    session.ID,
+			ChatID:       session.ChatID,
+			SubscriberID: p.SubscriberID,
+			ProposedAt:   session.CreatedAt,
+		}
+		if p.SubmittedAt != nil {
+			proposal.ProposedAt = *p.SubmittedAt
+		}
+		book := &models.CatalogBook{Title: p.Book.Title, Author: p.Book.Author, TitleKey: titleKey, AuthorKey: authorKey}
+		id, err := b.bookRepository.RecordProposal(ctx, book, proposal)
+		if err != nil {
+			log.Printf("cannot record %q in the book catalog: %v", p.Book.Title, err)
+			continue
+		}
+		p.BookID = id
+		if err := b.sessionRepository.UpdateParticipant(ctx, session.ID, p); err != nil {
+			log.Printf("cannot reference the catalog book of %d: %v", p.SubscriberID, err)
+		}
+	}
+}
+
+// linkWinners references the catalog books of the poll's winners (tied ones
+// too) from the participants that proposed them.
+func linkWinners(session *models.BookClubSession, winners []models.Winner) {
+	for i, w := range winners {
+		if p := findParticipant(session, w.SubscriberID); p != nil && p.Book != nil && p.Book.Title == w.Title {
+			winners[i].BookID = p.BookID
+		}
+	}
+}
+
+// catalogWinners marks the round's winning books won in the book catalog.
+func (b *Bot) catalogWinners(session *models.BookClubSession, winners []models.Winner) {
+	if b.bookRepository == nil {
+		return
+	}
+	for _, w := range winners {
+		if w.BookID.IsZero() {
+			continue
+		}
+		err := b.bookRepository.MarkWon(context.Background(), w.BookID, session.ID, w.SubscriberID)
+		if err != nil && !errors.Is(err, repository.ErrNotFound) {
+			log.Printf("cannot mark %q won in the book catalog: %v", w.Title, err)
+		}
+	}
+}
+
+// backfillBooks records the books of the rounds that closed their gathering
+// before the book catalog existed, and references them from those sessions.
+// Recording is idempotent, so an interrupted backfill resumes on the next
+// start.
+func (b *Bot) backfillBooks() {
+	if b.bookRepository == nil {
+		return
+	}
+	ctx := context.Background()
+	sessions, err := b.sessionRepository.ListUncataloguedSessions(ctx)
+	if err != nil {
+		log.Printf("cannot list the sessions to record in the book catalog: %v", err)
+		return
+	}
+	for _, s := range sessions {
+		b.catalogProposals(s)
+		if len(s.Winners) == 0 {
+			continue
+		}
+		linkWinners(s, s.Winners)
+		b.catalogWinners(s, s.Winners)
+		if err := b.sessionRepository.SetWinners(ctx, s.ID, s.Winners); err != nil {
+			log.Printf("cannot reference the catalog books of the winners of %s: %v", s.ID.Hex(), err)
+		}
+	}
+	if len(sessions) > 0 {
+		log.Printf("recorded the books of %d sessions in the book catalog", len(sessions))
+	}
+}
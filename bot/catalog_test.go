@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookKeys(t *testing.T) {
+	title, author := bookKeys("  Мастер и Маргарита!", "Булгаков, Михаил")
+	assert.Equal(t, "master margarita", title)
+	assert.Equal(t, "bulgakov mikhail", author)
+
+	title2, author2 := bookKeys("The Master and Margarita", "Mikhail Bulgakov")
+	assert.Equal(t, title, title2)
+	assert.Equal(t, author, author2)
+}
+
+func TestBookCatalog(t *testing.T) {
+	t.Run("every round's books and winner are recorded", func(t *testing.T) {
+		cfg := &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, TimeForTelegramPoll: 3600}
+		b, fake, sessions := clubWithMembers(t, cfg, 1, 2, 3)
+		catalog := b.bookRepository.(*memBookRepo)
+
+		// round plays a round in which Solaris loses to the other book.
+		round := func(other, proposer int64) *models.BookClubSession {
+			updates := []tgbotapi.Update{dm(1, "/start_vote")}
+			updates = append(updates, submit(other, "Dune", "Herbert")...)
+			updates = append(updates, submit(proposer, "solaris", "Lem")...)
+			for _, id := range []int64{1, 2, 3} {
+				if id != other && id != proposer {
+					updates = append(updates, dm(id, "/skip"))
+				}
+			}
+			b.serve(fake.inject(updates...))
+			poll := fake.lastPoll()
+			b.serve(fake.inject(fake.vote(1, poll.ID, "Dune"), fake.vote(2, poll.ID, "Dune"), fake.vote(3, poll.ID, "Dune")))
+			b.recoverTick() // the reading deadline has passed
+			session := sessions.latest(testClubID)
+			require.Equal(t, models.StatusCompleted, session.Status)
+			return session
+		}
+		first := round(1, 2)
+		cfg.PastWinnerPolicy = models.PastWinnerAllow
+		second := round(3, 2)
+
+		solaris := catalog.book("solaris")
+		require.NotNil(t, solaris)
+		assert.Equal(t, 2, solaris.ProposedCount)
+		assert.Zero(t, solaris.WonCount)
+		assert.Equal(t, first.ID, solaris.Proposals[0].SessionID)
+		assert.Equal(t, second.ID, solaris.Proposals[1].SessionID)
+		assert.Equal(t, solaris.ID, findParticipant(second, 2).BookID)
+
+		dune := catalog.book("dune")
+		require.NotNil(t, dune)
+		assert.Equal(t, 2, dune.WonCount)
+		assert.Equal(t, []int64{1, 3}, []int64{dune.Proposals[0].SubscriberID, dune.Proposals[1].SubscriberID})
+		assert.True(t, dune.Proposals[1].Won)
+		assert.Equal(t, dune.ID, second.Winners[0].BookID)
+	})
+
+	t.Run("past sessions are backfilled once", func(t *testing.T) {
+		b, _, sessions := roundBot(&config.AppConfig{})
+		catalog := b.bookRepository.(*memBookRepo)
+		submitted := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+		past := &models.BookClubSession{
+			ChatID: testClubID,
+			Status: models.StatusCompleted,
+			Gathering: models.Gathering{Participants: []*models.Participant{
+				{SubscriberID: 1, Step: models.StepDone, Book: &models.Book{Title: "Dune", Author: "Herbert"}, SubmittedAt: &submitted},
+				{SubscriberID: 2, Step: models.StepDone, Book: &models.Book{Title: "Solaris", Author: "Lem"}},
+				{SubscriberID: 3, Step: models.StepSkipped},
+			}},
+			Winners: []models.Winner{{SubscriberID: 2, Title: "Solaris", Author: "Lem"}},
+		}
+		require.NoError(t, sessions.CreateSession(context.Background(), past))
+
+		b.backfillBooks()
+		b.backfillBooks()
+
+		session := sessions.latest(testClubID)
+		solaris := catalog.book("solaris")
+		require.NotNil(t, solaris)
+		assert.Equal(t, 1, solaris.ProposedCount)
+		assert.Equal(t, 1, solaris.WonCount)
+		assert.Equal(t, solaris.ID, session.Winners[0].BookID)
+		assert.Equal(t, solaris.ID, findParticipant(session, 2).BookID)
+		assert.Equal(t, submitted, catalog.book("dune").Proposals[0].ProposedAt)
+		assert.Len(t, catalog.books, 2)
+
+		left, err := sessions.ListUncataloguedSessions(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, left)
+	})
+}
@@ -0,0 +1,336 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"BookClubBot/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// migrateLegacyGroup moves a single-club deployment to the clubs collection.
+// Before multi-club support the one group chat was stored in the settings
+// document; if it is still set, that group becomes a club, every subscriber and
+// session without a club is assigned to it, and the setting is cleared so the
+// migration runs only once.
+func (b *Bot) migrateLegacyGroup() {
+	ctx := context.Background()
+	groupId, err := b.settingsRepository.GetGroupId(ctx)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return
+		}
+		log.Fatalf("unexpected error getting group id: '%v'", err)
+	}
+	if groupId == 0 {
+		return
+	}
+
+	club, err := b.clubRepository.GetClubById(ctx, groupId)
+	if err != nil {
+		log.Fatalf("cannot load the legacy club: '%v'", err)
+	}
+	if club == nil {
+		club = &models.Club{ChatID: groupId, AddedAt: time.Now().UTC()}
+		chat, err := b.tgBot.GetChat(tgbotapi.ChatInfoConfig{ChatConfig: tgbotapi.ChatConfig{ChatID: groupId}})
+		if err != nil {
+			log.Printf("cannot load the legacy group title: %v", err)
+		} else {
+			club.Title = chat.Title
+		}
+		if err := b.clubRepository.SaveClub(ctx, club); err != nil {
+			log.Fatalf("cannot save the legacy club: '%v'", err)
+		}
+	}
+
+	subs, err := b.subRepository.AssignLegacyClub(ctx, groupId)
+	if err != nil {
+		log.Fatalf("cannot assign subscribers to the legacy club: '%v'", err)
+	}
+	sessions, err := b.sessionRepository.AssignLegacyChat(ctx, groupId)
+	if err != nil {
+		log.Fatalf("cannot assign sessions to the legacy club: '%v'", err)
+	}
+	if err := b.settingsRepository.SaveGroupID(ctx, 0); err != nil {
+		log.Fatalf("cannot clear the legacy group id: '%v'", err)
+	}
+	log.Printf("migrated group %d to a club: %d subscribers, %d sessions", groupId, subs, sessions)
+}
+
+func (b *Bot) handleBotAdded(update tgbotapi.Update) {
+	for _, member := range update.Message.NewChatMembers {
+		if member.IsBot && member.ID == b.selfID {
+			club := &models.Club{
+				ChatID:  update.Message.Chat.ID,
+				Title:   update.Message.Chat.Title,
+				AddedAt: time.Now().UTC(),
+			}
+			if err := b.clubRepository.SaveClub(context.Background(), club); err != nil {
+				log.Printf("cannot handle bot adding: %v", err)
+				return
+			}
+			if b.cfg.SyncChatAdmins {
+				if _, err := b.syncClubAdmins(club.ChatID); err != nil {
+					b.logSyncFailure(club.ChatID, nil, err)
+				}
+			}
+			b.sendMessage(club.ChatID, b.messages.GreetingMessage)
+		}
+	}
+}
+
+// handleBotRemoved forgets the club and cancels its round, if any. Members keep
+// the club in their list, so re-adding the bot to the group restores them.
+func (b *Bot) handleBotRemoved(chatID int64) {
+	if err := b.clubRepository.DeleteClub(context.Background(), chatID); err != nil && !errors.Is(err, repository.ErrNotFound) {
+		log.Printf("cannot handle bot removing: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	session, err := b.sessionRepository.GetActiveSession(context.Background(), chatID)
+	if err != nil {
+		log.Printf("cannot get active session of removed club %d: %v", chatID, err)
+		return
+	}
+	if session == nil {
+		return
+	}
+	if err := b.sessionRepository.SetStatus(context.Background(), session.ID, models.StatusCancelled); err != nil {
+		log.Printf("cannot cancel session of removed club %d: %v", chatID, err)
+	}
+}
+
+// handleClub handles /club: without arguments it lists the user's clubs, and
+// /club <n> picks the club their messages are about.
+func (b *Bot) handleClub(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	sub, clubs, err := b.subscriberClubs(uid)
+	if err != nil {
+		return err
+	}
+	if len(clubs) == 0 {
+		b.sendMessage(uid, b.messages.NotClubMember)
+		return nil
+	}
+
+	arg := update.Message.CommandArguments()
+	if strings.TrimSpace(arg) == "" {
+		b.sendMessage(uid, b.messages.YourClubs+clubList(clubs, sub.CurrentClubID))
+		return nil
+	}
+	n, ok := parseClubNumber(arg, len(clubs))
+	if !ok {
+		b.sendMessage(uid, b.messages.YourClubs+clubList(clubs, sub.CurrentClubID))
+		return nil
+	}
+
+	club := clubs[n-1]
+	if err := b.subRepository.SetCurrentClub(context.Background(), uid, club.ChatID); err != nil {
+		return fmt.Errorf("failed to set current club of %d: %w", uid, err)
+	}
+	b.sendMessage(uid, fmt.Sprintf(b.messages.ClubSelected, clubTitle(club)))
+	return nil
+}
+
+// clubFor resolves which club a DM from uid is about. A member of one club
+// needs no routing. For a member of several, involved narrows the choice to the
+// clubs whose active round involves the user (nil skips this); a single match
+// wins, otherwise the club picked with /club is used if it is still a
+// candidate. When the club cannot be told, the user is asked to pick one and 0
+// is returned.
+func (b *Bot) clubFor(uid int64, involved func(*models.BookClubSession) bool) (int64, error) {
+	sub, clubs, err := b.subscriberClubs(uid)
+	if err != nil {
+		return 0, err
+	}
+	if len(clubs) == 0 {
+		b.sendMessage(uid, b.messages.NotClubMember)
+		return 0, nil
+	}
+	ids := make([]int64, 0, len(clubs))
+	for _, c := range clubs {
+		ids = append(ids, c.ChatID)
+	}
+
+	var matched []int64
+	if involved != nil && len(ids) > 1 {
+		sessions, err := b.sessionRepository.GetActiveSessions(context.Background())
+		if err != nil {
+			return 0, fmt.Errorf("cannot get active sessions: %w", err)
+		}
+		for _, s := range sessions {
+			if slices.Contains(ids, s.ChatID) && involved(s) {
+				matched = append(matched, s.ChatID)
+			}
+		}
+	}
+
+	if chatID := pickClub(ids, matched, sub.CurrentClubID); chatID != 0 {
+		return chatID, nil
+	}
+	b.sendMessage(uid, b.messages.ChooseClub+clubList(clubs, sub.CurrentClubID))
+	return 0, nil
+}
+
+// activeSessionFor resolves the user's club with clubFor and returns its active
+// session. A zero chatID means the user has already been asked to pick a club.
+func (b *Bot) activeSessionFor(uid int64, involved func(*models.BookClubSession) bool) (int64, *models.BookClubSession, error) {
+	chatID, err := b.clubFor(uid, involved)
+	if err != nil || chatID == 0 {
+		return 0, nil, err
+	}
+	session, err := b.sessionRepository.GetActiveSession(context.Background(), chatID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("cannot get active session: %w", err)
+	}
+	return chatID, session, nil
+}
+
+// subscriberClubs loads a subscriber and the clubs they belong to, in the order
+// the clubs were added. Clubs the bot has since left are skipped.
+func (b *Bot) subscriberClubs(uid int64) (*models.Subscriber, []*models.Club, error) {
+	sub, err := b.subRepository.GetSubscriberById(context.Background(), uid)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find subscriber with id %d: %w", uid, err)
+	}
+	if sub == nil {
+		return nil, nil, nil
+	}
+	all, err := b.clubRepository.GetAllClubs(context.Background())
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load clubs: %w", err)
+	}
+	var clubs []*models.Club
+	for _, c := range all {
+		if slices.Contains(sub.ClubIDs, c.ChatID) {
+			clubs = append(clubs, c)
+		}
+	}
+	return sub, clubs, nil
+}
+
+// memberClubs returns the clubs whose group chat uid is in, so that /subscribe
+// lists and joins only those. A club whose chat cannot be checked is left out.
+func (b *Bot) memberClubs(clubs []*models.Club, uid int64) []*models.Club {
+	var in []*models.Club
+	for _, c := range clubs {
+		m, err := b.tgBot.GetChatMember(tgbotapi.GetChatMemberConfig{
+			ChatConfigWithUser: tgbotapi.ChatConfigWithUser{ChatID: c.ChatID, UserID: uid},
+		})
+		if err != nil {
+			log.Printf("cannot check whether user %d is in club %d: %v", uid, c.ChatID, err)
+			continue
+		}
+		if isChatMember(m) {
+			in = append(in, c)
+		}
+	}
+	return in
+}
+
+// isChatMember reports whether a chat member is in the chat: its creator, an
+// administrator, a member, or restricted but still in it.
+func isChatMember(m tgbotapi.ChatMember) bool {
+	switch m.Status {
+	case "creator", "administrator", "member":
+		return true
+	case "restricted":
+		return m.IsMember
+	}
+	return false
+}
+
+// pickClub chooses among a user's clubs: the only one, else the only club whose
+// round involves them, else their current club if it is among the candidates
+// (the matched clubs, or all of them when none matched). 0 means ambiguous.
+func pickClub(ids, matched []int64, current int64) int64 {
+	if len(ids) == 1 {
+		return ids[0]
+	}
+	if len(matched) == 1 {
+		return matched[0]
+	}
+	candidates := ids
+	if len(matched) > 1 {
+		candidates = matched
+	}
+	if current != 0 && slices.Contains(candidates, current) {
+		return current
+	}
+	return 0
+}
+
+// clubList renders clubs as a numbered list for /club and /subscribe, marking
+// the current one.
+func clubList(clubs []*models.Club, current int64) string {
+	var sb strings.Builder
+	for i, c := range clubs {
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, clubTitle(c))
+		if c.ChatID == current {
+			sb.WriteString(" ✓")
+		}
+	}
+	return sb.String()
+}
+
+// clubTitle is the club's group title, or its chat ID for clubs migrated from
+// a single-group deployment whose title could not be loaded.
+func clubTitle(c *models.Club) string {
+	if c.Title != "" {
+		return c.Title
+	}
+	return strconv.FormatInt(c.ChatID, 10)
+}
+
+// parseClubNumber parses a 1-based position in a club list of size n.
+func parseClubNumber(arg string, n int) (int, bool) {
+	i, err := strconv.Atoi(strings.TrimSpace(arg))
+	if err != nil || i < 1 || i > n {
+		return 0, false
+	}
+	return i, true
+}
+
+// awaitsAnswer reports whether the round is waiting for a free-text answer from
+// uid: a book submission in progress or a rating/review question.
+func awaitsAnswer(uid int64) func(*models.BookClubSession) bool {
+	return func(s *models.BookClubSession) bool {
+		switch s.Status {
+		case models.StatusGathering:
+			p := findParticipant(s, uid)
+			return p != nil && (p.Editing != "" || p.Step != models.StepDone && p.Step != models.StepSkipped)
+		case models.StatusReading:
+			m := findReadingMember(s, uid)
+			return m != nil && (m.Step == models.ReviewStepRating || m.Step == models.ReviewStepReview)
+		}
+		return false
+	}
+}
+
+// gatheringParticipant reports whether uid can still take part in the round's
+// book gathering.
+func gatheringParticipant(uid int64) func(*models.BookClubSession) bool {
+	return func(s *models.BookClubSession) bool {
+		if s.Status != models.StatusGathering {
+			return false
+		}
+		p := findParticipant(s, uid)
+		return p != nil && p.Step != models.StepSkipped
+	}
+}
+
+// readingMember reports whether uid is reading the round's book.
+func readingMember(uid int64) func(*models.BookClubSession) bool {
+	return func(s *models.BookClubSession) bool {
+		return s.Status == models.StatusReading && findReadingMember(s, uid) != nil
+	}
+}
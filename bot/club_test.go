@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPickClub(t *testing.T) {
+	t.Run("a single club needs no routing", func(t *testing.T) {
+		assert.Equal(t, int64(-1), pickClub([]int64{-1}, nil, 0))
+	})
+
+	t.Run("the only involved club wins over the current one", func(t *testing.T) {
+		assert.Equal(t, int64(-2), pickClub([]int64{-1, -2}, []int64{-2}, -1))
+	})
+
+	t.Run("current club breaks a tie between involved clubs", func(t *testing.T) {
+		assert.Equal(t, int64(-2), pickClub([]int64{-1, -2, -3}, []int64{-2, -3}, -2))
+	})
+
+	t.Run("current club outside the involved clubs is ignored", func(t *testing.T) {
+		assert.Zero(t, pickClub([]int64{-1, -2, -3}, []int64{-2, -3}, -1))
+	})
+
+	t.Run("current club is used when no round involves the user", func(t *testing.T) {
+		assert.Equal(t, int64(-1), pickClub([]int64{-1, -2}, nil, -1))
+	})
+
+	t.Run("ambiguous without a current club", func(t *testing.T) {
+		assert.Zero(t, pickClub([]int64{-1, -2}, nil, 0))
+	})
+
+	t.Run("a current club the user has left is ignored", func(t *testing.T) {
+		assert.Zero(t, pickClub([]int64{-1, -2}, nil, -3))
+	})
+}
+
+func TestClubList(t *testing.T) {
+	clubs := []*models.Club{
+		{ChatID: -1, Title: "Readers"},
+		{ChatID: -2},
+	}
+	assert.Equal(t, "\n1. Readers\n2. -2 ✓", clubList(clubs, -2))
+	assert.Equal(t, "\n1. Readers\n2. -2", clubList(clubs, 0))
+}
+
+func TestParseClubNumber(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want int
+		ok   bool
+	}{
+		{"1", 1, true},
+		{" 3 ", 3, true},
+		{"0", 0, false},
+		{"4", 0, false},
+		{"", 0, false},
+		{"first", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseClubNumber(tt.arg, 3)
+		assert.Equal(t, tt.ok, ok, tt.arg)
+		assert.Equal(t, tt.want, got, tt.arg)
+	}
+}
+
+func TestAwaitsAnswer(t *testing.T) {
+	gathering := sessionWith(
+		&models.Participant{SubscriberID: 1, Step: models.StepAuthor},
+		&models.Participant{SubscriberID: 2, Step: models.StepDone},
+	)
+	gathering.Status = models.StatusGathering
+	assert.True(t, awaitsAnswer(1)(gathering))
+	assert.False(t, awaitsAnswer(2)(gathering), "a finished submission awaits nothing")
+	assert.False(t, awaitsAnswer(3)(gathering))
+
+	reading := &models.BookClubSession{
+		Status: models.StatusReading,
+		Reading: &models.Reading{Members: []*models.ReadingMember{
+			{SubscriberID: 1, Status: models.ReadingFinished, Step: models.ReviewStepReview},
+			{SubscriberID: 2, Status: models.ReadingInProgress},
+		}},
+	}
+	assert.True(t, awaitsAnswer(1)(reading))
+	assert.False(t, awaitsAnswer(2)(reading), "a member still reading is not mid-conversation")
+	assert.True(t, readingMember(2)(reading))
+	assert.False(t, gatheringParticipant(2)(reading))
+}
+
+func TestVotingSessionForPoll(t *testing.T) {
+	a := &models.BookClubSession{ChatID: -1, Status: models.StatusVoting, Voting: &models.Voting{PollID: "a"}}
+	b := &models.BookClubSession{ChatID: -2, Status: models.StatusVoting, Voting: &models.Voting{PollID: "b"}}
+	reading := &models.BookClubSession{ChatID: -3, Status: models.StatusReading}
+	match := func(sessions []*models.BookClubSession, pollID string) *models.BookClubSession {
+		s, _ := votingSessionForPoll(sessions, pollID)
+		return s
+	}
+
+	assert.Same(t, b, match([]*models.BookClubSession{a, b, reading}, "b"))
+	assert.Nil(t, match([]*models.BookClubSession{a, b}, "c"))
+
+	t.Run("a poll without a stored id matches only when it is the only one", func(t *testing.T) {
+		legacy := &models.BookClubSession{ChatID: -4, Status: models.StatusVoting, Voting: &models.Voting{}}
+		assert.Same(t, legacy, match([]*models.BookClubSession{a, legacy}, "c"))
+
+		other := &models.BookClubSession{ChatID: -5, Status: models.StatusVoting, Voting: &models.Voting{}}
+		assert.Nil(t, match([]*models.BookClubSession{legacy, other}, "c"))
+	})
+
+	t.Run("preliminary heats", func(t *testing.T) {
+		heats := &models.BookClubSession{ChatID: -6, Status: models.StatusVoting, Voting: &models.Voting{
+			Heats: []*models.Heat{{PollID: "h1"}, {PollID: "h2"}},
+		}}
+		s, heat := votingSessionForPoll([]*models.BookClubSession{a, heats}, "h2")
+		assert.Same(t, heats, s)
+		assert.Equal(t, 1, heat)
+
+		s, heat = votingSessionForPoll([]*models.BookClubSession{a, heats}, "a")
+		assert.Same(t, a, s)
+		assert.Equal(t, -1, heat, "the final poll")
+
+		assert.Nil(t, match([]*models.BookClubSession{heats}, "c"), "heats are never a legacy poll")
+	})
+}
@@ -0,0 +1,268 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"unicode"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// duplicatePrefix starts the callback data of the buttons under a likely
+// duplicate: "dup:<session ID>:<keep|other>".
+const duplicatePrefix = "dup"
+
+// defaultDuplicateThreshold is the title similarity that marks a likely
+// duplicate when duplicate_threshold is not set.
+const defaultDuplicateThreshold = 0.85
+
+// cyrillicToLatin transliterates Russian and Ukrainian letters, so a title
+// typed in either script matches the other.
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'і': "i", 'ї': "i", 'є': "e", 'ґ': "g",
+}
+
+// titleStopWords are dropped from normalized titles, so "The Master and
+// Margarita" matches "Мастер и Маргарита".
+var titleStopWords = map[string]bool{"a": true, "an": true, "the": true, "and": true}
+
+// normalizeTitle reduces a title to what tells books apart: lower-case Latin
+// words without punctuation or stop words, single-spaced.
+func normalizeTitle(title string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		case r == '\'' || r == '’':
+			// "Hitchhiker's" and "Hitchhikers" are the same word.
+		default:
+			sb.WriteRune(' ')
+		}
+	}
+	var words []string
+	for _, w := range strings.Fields(sb.String()) {
+		if w = transliterate(w); w != "" && !titleStopWords[w] {
+			words = append(words, w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// transliterate spells a lower-case word in Latin letters. The conjunction «и»
+// becomes "and", a stop word, where a letter-by-letter "i" would be taken for
+// the English "I".
+func transliterate(word string) string {
+	if word == "и" {
+		return "and"
+	}
+	var sb strings.Builder
+	for _, r := range word {
+		if latin, ok := cyrillicToLatin[r]; ok {
+			sb.WriteString(latin)
+		} else {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// titleSimilarity scores two titles from 0 (nothing alike) to 1 (the same
+// once normalized) by the edit distance between their normalized forms. Titles
+// with different numbers in them score 0.
+func titleSimilarity(a, b string) float64 {
+	na, nb := []rune(normalizeTitle(a)), []rune(normalizeTitle(b))
+	if len(na) == 0 || len(nb) == 0 {
+		// A title of stop words only is compared as typed.
+		if strings.EqualFold(strings.TrimSpace(a), strings.TrimSpace(b)) {
+			return 1
+		}
+		return 0
+	}
+	if !slices.Equal(titleNumbers(na), titleNumbers(nb)) {
+		// Volumes of a series differ by little more than their number.
+		return 0
+	}
+	return 1 - float64(editDistance(na, nb))/float64(max(len(na), len(nb)))
+}
+
+// titleNumbers returns the numbers in a normalized title, in order.
+func titleNumbers(title []rune) []string {
+	return strings.FieldsFunc(string(title), func(r rune) bool { return !unicode.IsDigit(r) })
+}
+
+// editDistance is the Levenshtein distance between two rune strings.
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// duplicateThreshold is the similarity from which a title is a likely
+// duplicate.
+func (b *Bot) duplicateThreshold() float64 {
+	if b.cfg.DuplicateThreshold > 0 {
+		return b.cfg.DuplicateThreshold
+	}
+	return defaultDuplicateThreshold
+}
+
+// findDuplicate returns the participant whose book is most likely the one
+// titled title, or nil when none comes close enough. The participant uid's own
+// book and books not proposed yet (awaiting confirmation) are not compared.
+func (b *Bot) findDuplicate(session *models.BookClubSession, title string, uid int64) *models.Participant {
+	var best *models.Participant
+	bestScore := b.duplicateThreshold()
+	for _, p := range session.Gathering.Participants {
+		if p.SubscriberID == uid || p.Book == nil || p.Step == models.StepConfirm || p.Step == models.StepDuplicate {
+			continue
+		}
+		if score := titleSimilarity(title, p.Book.Title); score >= bestScore {
+			best, bestScore = p, score
+		}
+	}
+	return best
+}
+
+// takeBook makes bk the participant's book, unless the club has read it and
+// the past_winner_policy refuses it (the participant is asked for the title
+// again), or it is likely another participant's: then the participant is
+// shown that book and asked whether theirs is a different one
+// (StepDuplicate). A book from the catalog or a link (p.Lookup set) goes on
+// to its confirmation, any other to the next question.
+func (b *Bot) takeBook(session *models.BookClubSession, p *models.Participant, bk *models.Book) {
+	if !b.mayPropose(session, p, bk.Title) {
+		p.Book, p.Lookup = nil, ""
+		p.Step = models.StepBook
+		b.persistParticipant(session.ID, p)
+		return
+	}
+	p.Book = bk
+	if b.findDuplicate(session, bk.Title, p.SubscriberID) != nil {
+		p.Step = models.StepDuplicate
+		b.persistParticipant(session.ID, p)
+		b.sendDuplicateNotice(session, p)
+		return
+	}
+	b.carryOnWithBook(session, p)
+}
+
+// keepBook goes on with a participant's book that is not a duplicate after
+// all: an edited title held back is stored, a new book carries on.
+func (b *Bot) keepBook(session *models.BookClubSession, p *models.Participant) {
+	if p.PendingTitle != "" {
+		p.Book.Title = p.PendingTitle
+		p.Editing, p.PendingTitle = "", ""
+		b.persistParticipant(session.ID, p)
+		b.sendMessage(p.SubscriberID, b.messages.BookUpdated)
+		return
+	}
+	b.carryOnWithBook(session, p)
+}
+
+// carryOnWithBook moves a participant whose new book is not a duplicate on:
+// to its catalog confirmation, or to the next question.
+func (b *Bot) carryOnWithBook(session *models.BookClubSession, p *models.Participant) {
+	if p.Lookup != "" {
+		p.Step = models.StepConfirm
+		b.persistParticipant(session.ID, p)
+		b.sendBookProposal(session, p)
+		return
+	}
+	b.askNextQuestion(session, p)
+}
+
+// sendDuplicateNotice shows the participant the proposal theirs (or the title
+// they are editing it to) likely duplicates, with the buttons to keep theirs
+// or pick another book.
+func (b *Bot) sendDuplicateNotice(session *models.BookClubSession, p *models.Participant) {
+	title := p.Book.Title
+	if p.PendingTitle != "" {
+		title = p.PendingTitle
+	}
+	existing := b.findDuplicate(session, title, p.SubscriberID)
+	if existing == nil {
+		// The other book has since been withdrawn or changed.
+		b.keepBook(session, p)
+		return
+	}
+	msg := tgbotapi.NewMessage(p.SubscriberID, fmt.Sprintf(b.messages.DuplicateFound, existing.Book.Title, existing.Book.Author))
+	data := func(answer string) string {
+		return fmt.Sprintf("%s:%s:%s", duplicatePrefix, session.ID.Hex(), answer)
+	}
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.messages.DuplicateKeep, data("keep")),
+		tgbotapi.NewInlineKeyboardButtonData(b.messages.DuplicateOther, data("other")),
+	))
+	if _, err := b.tgBot.Send(msg); err != nil {
+		log.Printf("cannot send a duplicate notice to %d: %v", p.SubscriberID, err)
+	}
+}
+
+// handleDuplicateCallback handles the answer to a likely duplicate: the
+// participant's book, or its edited title, is a different one and stands, or
+// they pick another.
+func (b *Bot) handleDuplicateCallback(q *tgbotapi.CallbackQuery, sessionID, answer string) {
+	uid := q.From.ID
+	session, p := b.pendingBookFor(uid, sessionID, models.StepDuplicate)
+	if p == nil {
+		// A submitted book whose edited title is held back.
+		if session, p = b.pendingBookFor(uid, sessionID, models.StepDone); p != nil && p.PendingTitle == "" {
+			p = nil
+		}
+	}
+	if p == nil {
+		b.answerCallback(q.ID, b.messages.BookProposalClosed)
+		return
+	}
+	b.answerCallback(q.ID, "")
+	if q.Message != nil {
+		// Drop the buttons, so the notice cannot be answered twice.
+		if _, err := b.tgBot.Send(tgbotapi.NewEditMessageReplyMarkup(uid, q.Message.MessageID,
+			tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}})); err != nil {
+			log.Printf("cannot close the duplicate notice of %d: %v", uid, err)
+		}
+	}
+
+	if answer != "keep" && p.PendingTitle != "" {
+		// The book keeps its title; the participant is asked for another.
+		p.PendingTitle = ""
+		b.persistParticipant(session.ID, p)
+		if tq, ok := b.question(models.BookTitle); ok {
+			b.askQuestion(uid, tq)
+		}
+		return
+	}
+	if answer != "keep" {
+		p.Book, p.Lookup = nil, ""
+		p.Step = models.StepBook
+		b.persistParticipant(session.ID, p)
+		b.sendMessage(uid, b.messages.AskBookTitle)
+		return
+	}
+	b.keepBook(session, p)
+	if allBooksChosen(session) {
+		b.runTelegramPollFlow(session.ChatID)
+	}
+}
@@ -0,0 +1,118 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTitleSimilarity(t *testing.T) {
+	assert.Equal(t, "master margarita", normalizeTitle("  Мастер и   Маргарита! "))
+	assert.Equal(t, "hitchhikers guide galaxy", normalizeTitle("The Hitchhiker’s Guide — Galaxy"))
+
+	for _, tc := range []struct {
+		a, b string
+		same bool
+	}{
+		{"Мастер и Маргарита", "мастер и маргарита", true},
+		{"Мастер и Маргарита", "Мастер  и Маргарита.", true},
+		{"Мастер и Маргарита", "The Master and Margarita", true},
+		{"Преступление и наказание", "Prestuplenie i nakazanie", true},
+		{"Ёлка", "Елка", true},
+		{"Solaris", "Solyaris", true},
+		{"Dune", "June", false},
+		{"Dune", "Dune Messiah", false},
+		{"Harry Potter 1", "Harry Potter 2", false},
+		{"The", "the", true},
+		{"I, Robot", "Robot", false},
+	} {
+		score := titleSimilarity(tc.a, tc.b)
+		assert.Equal(t, tc.same, score >= defaultDuplicateThreshold, "%q vs %q: %.2f", tc.a, tc.b, score)
+	}
+}
+
+func TestDuplicateProposal(t *testing.T) {
+	dupRound := func(t *testing.T) (*Bot, *fakeMessenger, *memSessionRepo) {
+		b, fake, sessions := gatheringRound(t)
+		b.messages.DuplicateFound = "dup of %s by %s"
+		b.messages.DuplicateKeep = "keep"
+		b.messages.DuplicateOther = "other"
+		b.messages.BookProposalClosed = "closed"
+		b.serve(fake.inject(submit(2, "Мастер и Маргарита", "Булгаков")...))
+		b.serve(fake.inject(dm(1, "master & margarita")))
+		return b, fake, sessions
+	}
+	participant := func(sessions *memSessionRepo) *models.Participant {
+		return findParticipant(sessions.latest(testClubID), 1)
+	}
+
+	t.Run("a different book goes on", func(t *testing.T) {
+		b, fake, sessions := dupRound(t)
+		assert.Equal(t, "dup of Мастер и Маргарита by Булгаков", lastText(fake, 1))
+		assert.Equal(t, models.StepDuplicate, participant(sessions).Step)
+
+		b.serve(fake.inject(dm(1, "anything")))
+		assert.Equal(t, "dup of Мастер и Маргарита by Булгаков", lastText(fake, 1), "the notice is repeated")
+
+		tap := fake.tap(1, "keep")
+		b.serve(fake.inject(tap))
+		assert.Equal(t, "author?", lastText(fake, 1))
+		assert.Equal(t, "master & margarita", participant(sessions).Book.Title)
+
+		b.serve(fake.inject(tap))
+		assert.Equal(t, "closed", fake.callbacks[len(fake.callbacks)-1].Text, "a notice is answered once")
+	})
+
+	t.Run("picking another book asks for the title", func(t *testing.T) {
+		b, fake, sessions := dupRound(t)
+		b.serve(fake.inject(fake.tap(1, "other")))
+		assert.Equal(t, "title?", lastText(fake, 1))
+		assert.Equal(t, models.StepBook, participant(sessions).Step)
+		assert.Nil(t, participant(sessions).Book)
+
+		b.serve(fake.inject(dm(1, "Белая гвардия")))
+		assert.Equal(t, "author?", lastText(fake, 1))
+	})
+
+	editRound := func(t *testing.T) (*Bot, *fakeMessenger, *memSessionRepo) {
+		b, fake, sessions := gatheringRound(t)
+		b.messages.DuplicateFound = "dup of %s by %s"
+		b.messages.DuplicateKeep = "keep"
+		b.messages.DuplicateOther = "other"
+		b.messages.BookProposalClosed = "closed"
+		b.serve(fake.inject(submit(1, "Dune", "Herbert")...))
+		b.serve(fake.inject(dm(2, "Мастер и Маргарита"), dm(2, "Булгаков")))
+		b.serve(fake.inject(dm(1, "/edit title Master i Margarita")))
+		return b, fake, sessions
+	}
+
+	t.Run("an edited title close to another book is held back", func(t *testing.T) {
+		b, fake, sessions := editRound(t)
+		assert.Equal(t, "dup of Мастер и Маргарита by Булгаков", lastText(fake, 1))
+		p := participant(sessions)
+		assert.Equal(t, models.StepDone, p.Step, "the book stays in the round")
+		assert.Equal(t, "Dune", p.Book.Title)
+		assert.Equal(t, "Master i Margarita", p.PendingTitle)
+
+		b.serve(fake.inject(fake.tap(1, "keep")))
+		p = participant(sessions)
+		assert.Equal(t, "updated", lastText(fake, 1))
+		assert.Equal(t, "Master i Margarita", p.Book.Title)
+		assert.Empty(t, p.PendingTitle)
+		assert.Empty(t, p.Editing)
+	})
+
+	t.Run("picking another title for an edited book keeps its title", func(t *testing.T) {
+		b, fake, sessions := editRound(t)
+		b.serve(fake.inject(fake.tap(1, "other")))
+		assert.Equal(t, "title?", lastText(fake, 1))
+		assert.Equal(t, "Dune", participant(sessions).Book.Title)
+		assert.Empty(t, participant(sessions).PendingTitle)
+
+		b.serve(fake.inject(dm(1, "Dune Messiah")))
+		assert.Equal(t, "updated", lastText(fake, 1))
+		assert.Equal(t, "Dune Messiah", participant(sessions).Book.Title)
+		assert.Equal(t, models.StepDone, participant(sessions).Step)
+	})
+}
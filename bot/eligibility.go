@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"slices"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// eligibleVoters applies the voter_eligibility rule when voting starts: every
+// member (the default), only the members who submitted a book, or anyone in
+// the group. The eligible set is stored on the voting, so members joining
+// later do not change who counts; with EligibleAnyone no set is stored and
+// the members at the start are the early-close quorum, as before.
+func (b *Bot) eligibleVoters(session *models.BookClubSession, voting *models.Voting, subs []*models.Subscriber) {
+	voting.Eligibility = b.cfg.VoterEligibility
+	voting.EligibleIDs = nil
+	switch b.cfg.VoterEligibility {
+	case models.EligibleAnyone:
+		voting.TotalParticipants = len(subs)
+		return
+	case models.EligibleParticipants:
+		for _, p := range session.Gathering.Participants {
+			if p.Step == models.StepDone && p.Book != nil {
+				voting.EligibleIDs = append(voting.EligibleIDs, p.SubscriberID)
+			}
+		}
+	default:
+		voting.Eligibility = models.EligibleSubscribers
+		for _, sub := range subs {
+			voting.EligibleIDs = append(voting.EligibleIDs, sub.ID)
+		}
+	}
+	if voting.EligibleIDs == nil {
+		voting.EligibleIDs = []int64{}
+	}
+	voting.TotalParticipants = len(voting.EligibleIDs)
+}
+
+// eligible reports whether uid's vote counts in the voting.
+func eligible(v *models.Voting, uid int64) bool {
+	if v.Eligibility == models.EligibleAnyone || v.Eligibility == "" {
+		return true
+	}
+	return slices.Contains(v.EligibleIDs, uid)
+}
+
+// counted reports whether an answer counts in the tally: it comes from an
+// eligible voter and picks no more than limit books.
+func counted(v *models.Voting, a *models.Answer, limit int) bool {
+	return eligible(v, a.VoterID) && len(a.OptionIDs) <= limit
+}
+
+// countedPoll returns a copy of the closed poll with the answers that do not
+// count taken out of its counts: those of outsiders, and those that picked too
+// many books until the voter revotes. The poll itself keeps Telegram's counts.
+func countedPoll(raw tgbotapi.Poll, v *models.Voting, limit int) tgbotapi.Poll {
+	poll := raw
+	poll.Options = slices.Clone(raw.Options)
+	for _, a := range v.Answers {
+		if a.PollID != poll.ID || counted(v, a, limit) {
+			continue
+		}
+		for _, i := range a.OptionIDs {
+			if i >= 0 && i < len(poll.Options) && poll.Options[i].VoterCount > 0 {
+				poll.Options[i].VoterCount--
+			}
+		}
+		if len(a.OptionIDs) > 0 && poll.TotalVoterCount > 0 {
+			poll.TotalVoterCount--
+		}
+	}
+	return poll
+}
@@ -0,0 +1,115 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoterEligibility(t *testing.T) {
+	t.Run("outsiders do not count", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600})
+		poll := fake.lastPoll()
+
+		session := sessions.latest(testClubID)
+		assert.Equal(t, models.EligibleSubscribers, session.Voting.Eligibility)
+		assert.ElementsMatch(t, []int64{1, 2, 3}, session.Voting.EligibleIDs)
+		assert.Equal(t, 3, session.Voting.TotalParticipants)
+
+		// A member who subscribes after voting started is an outsider too.
+		b.serve(fake.inject(
+			dm(4, "/subscribe"),
+			fake.vote(4, poll.ID, "Dune"),
+			fake.vote(77, poll.ID, "Dune"),
+			fake.vote(78, poll.ID, "Dune"),
+			fake.vote(1, poll.ID, "Solaris"),
+			fake.vote(2, poll.ID, "Solaris"),
+		))
+
+		session = sessions.latest(testClubID)
+		require.Equal(t, models.StatusVoting, session.Status, "outsiders cannot close the poll early")
+		assert.ElementsMatch(t, []int64{1, 2}, session.Voting.VoterIDs)
+		assert.ElementsMatch(t, []int64{4, 77, 78}, session.Voting.OutsiderIDs)
+
+		b.serve(fake.inject(fake.vote(3, poll.ID, "Dune")))
+
+		session = sessions.latest(testClubID)
+		require.Equal(t, models.StatusReading, session.Status)
+		assert.Equal(t, "Solaris", session.Winners[0].Title, "outsiders' votes are not tallied")
+
+		// The archive keeps both the tally and Telegram's counts.
+		require.Len(t, session.Voting.Results, 1)
+		result := session.Voting.Results[0]
+		assert.Equal(t, []int{3, 6}, []int{result.TotalVoters, result.RawTotalVoters})
+		for _, o := range result.Options {
+			switch o.Title {
+			case "Dune":
+				assert.Equal(t, []int{1, 4}, []int{o.Votes, o.RawVotes})
+			case "Solaris":
+				assert.Equal(t, []int{2, 2}, []int{o.Votes, o.RawVotes})
+			}
+		}
+	})
+
+	t.Run("participants only", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600, VoterEligibility: models.EligibleParticipants})
+		poll := fake.lastPoll()
+
+		session := sessions.latest(testClubID)
+		assert.ElementsMatch(t, []int64{1, 2}, session.Voting.EligibleIDs, "member 3 skipped")
+		assert.Equal(t, 2, session.Voting.TotalParticipants)
+
+		b.serve(fake.inject(
+			fake.vote(3, poll.ID, "Dune"),
+			fake.vote(1, poll.ID, "Solaris"),
+			fake.vote(2, poll.ID, "Solaris"),
+		))
+
+		session = sessions.latest(testClubID)
+		assert.Equal(t, models.StatusReading, session.Status)
+		assert.Equal(t, []int64{3}, session.Voting.OutsiderIDs)
+	})
+
+	t.Run("anyone", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600, VoterEligibility: models.EligibleAnyone})
+		poll := fake.lastPoll()
+
+		b.serve(fake.inject(
+			fake.vote(77, poll.ID, "Dune"),
+			fake.vote(78, poll.ID, "Dune"),
+			fake.vote(1, poll.ID, "Solaris"),
+		))
+
+		session := sessions.latest(testClubID)
+		assert.Equal(t, models.StatusReading, session.Status)
+		assert.Empty(t, session.Voting.EligibleIDs)
+		assert.Empty(t, session.Voting.OutsiderIDs)
+		assert.Equal(t, "Dune", session.Winners[0].Title)
+	})
+}
+
+func TestCountedPoll(t *testing.T) {
+	poll := &tgbotapi.Poll{ID: "p", TotalVoterCount: 4, Options: []tgbotapi.PollOption{
+		{Text: "a", VoterCount: 3},
+		{Text: "b", VoterCount: 2},
+		{Text: "c", VoterCount: 1},
+	}}
+	v := &models.Voting{Eligibility: models.EligibleSubscribers, EligibleIDs: []int64{1, 2, 3}, Answers: []*models.Answer{
+		{PollID: "p", VoterID: 1, OptionIDs: []int{0, 1, 2}},
+		{PollID: "p", VoterID: 2, OptionIDs: []int{0, 1}},
+		{PollID: "p", VoterID: 3},
+		{PollID: "p", VoterID: 9, OptionIDs: []int{0}},
+		{PollID: "other", VoterID: 2, OptionIDs: []int{0, 1, 2}},
+	}}
+
+	counted := countedPoll(*poll, v, 2)
+
+	assert.Equal(t, 2, counted.TotalVoterCount)
+	assert.Equal(t, []int{1, 1, 0}, []int{counted.Options[0].VoterCount, counted.Options[1].VoterCount, counted.Options[2].VoterCount})
+	assert.Equal(t, 4, poll.TotalVoterCount, "the poll keeps Telegram's counts")
+	assert.Equal(t, 3, poll.Options[0].VoterCount)
+}
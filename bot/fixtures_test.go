@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/metadata"
+	"BookClubBot/internal/models"
+	"BookClubBot/message"
+	"context"
+	"errors"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testSelfID int64 = 999
+	testClubID int64 = -100
+)
+
+// testConfig is a config whose phases each last an hour, with member 1 as the
+// admin.
+func testConfig() *config.AppConfig {
+	return &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, TimeForTelegramPoll: 3600, TimeForReading: 3600}
+}
+
+// roundBot wires a bot to the fake messenger and in-memory repositories.
+func roundBot(cfg *config.AppConfig) (*Bot, *fakeMessenger, *memSessionRepo) {
+	fake := newFakeMessenger()
+	sessions := &memSessionRepo{}
+	b := NewBot(cfg, roundMessages(), newMemSubscriberRepo(), &memClubRepo{}, nil, sessions, &memBookRepo{})
+	b.tgBot = fake
+	b.selfID = testSelfID
+	return b, fake, sessions
+}
+
+// roundMessages are the few texts the round tests look for; the rest stay empty.
+func roundMessages() *message.LocalizedMessages {
+	return &message.LocalizedMessages{
+		GreetingMessage:        "hello club",
+		PleaseSuggestBookTitle: "suggest a book",
+		BookLabel:              "Book",
+		AuthorLabel:            "Author",
+		WeHaveAWinner:          "winner",
+		ChooseUpToBooks:        "choose up to %d",
+		ReadingStarted:         "reading %s until %s",
+		ReadingDigestTitle:     "digest of %s",
+		ReadingDigestAverage:   "average %.1f from %d",
+		ReadingDigestNoRatings: "no ratings",
+	}
+}
+
+// clubWithMembers sets up a club whose members have subscribed.
+func clubWithMembers(t *testing.T, cfg *config.AppConfig, members ...int64) (*Bot, *fakeMessenger, *memSessionRepo) {
+	t.Helper()
+	b, fake, sessions := roundBot(cfg)
+	updates := []tgbotapi.Update{botAdded(testClubID, "Readers", testSelfID)}
+	for _, id := range members {
+		updates = append(updates, dm(id, "/subscribe"))
+	}
+	b.serve(fake.inject(updates...))
+	return b, fake, sessions
+}
+
+// votingRound runs a club of members 1–3 (1 is the admin) up to an open poll
+// on two books; member 3 skips the gathering.
+func votingRound(t *testing.T, cfg *config.AppConfig) (*Bot, *fakeMessenger, *memSessionRepo) {
+	t.Helper()
+	cfg.Admins = []int64{1}
+	b, fake, sessions := clubWithMembers(t, cfg, 1, 2, 3)
+	updates := []tgbotapi.Update{dm(1, "/start_vote")}
+	updates = append(updates, submit(1, "Dune", "Herbert")...)
+	updates = append(updates, submit(2, "Solaris", "Lem")...)
+	updates = append(updates, dm(3, "/skip"))
+	b.serve(fake.inject(updates...))
+	require.Equal(t, models.StatusVoting, sessions.latest(testClubID).Status)
+	return b, fake, sessions
+}
+
+// gatheringRound starts gathering in a club of two, with the submission
+// questions named so the tests can tell them apart.
+func gatheringRound(t *testing.T) (*Bot, *fakeMessenger, *memSessionRepo) {
+	t.Helper()
+	cfg := testConfig()
+	b, fake, sessions := clubWithMembers(t, cfg, 1, 2)
+	b.messages.AskBookTitle = "title?"
+	b.messages.WhoIsAuthor = "author?"
+	b.messages.WriteBookDescription = "description?"
+	b.messages.AttachCoverPhoto = "cover?"
+	b.messages.NothingToGoBack = "nowhere to go"
+	b.messages.BookSubmittedUseEdit = "use edit"
+	b.messages.EditUsage = "edit %s"
+	b.messages.EditFinishFirst = "finish first"
+	b.messages.EditCancelled = "edit cancelled"
+	b.messages.BookUpdated = "updated"
+	b.messages.MyBook = "%s|%s|%s|%s|%s"
+	b.messages.MyBookDraft = "draft"
+	b.messages.NoBookYet = "no book"
+	b.messages.BookWithdrawn = "withdrawn"
+	b.messages.VotingNotStartedOrEnded = "not gathering"
+	b.serve(fake.inject(dm(1, "/start_vote")))
+	return b, fake, sessions
+}
+
+// rankedRound starts a ranked vote in a club of five: members 1–3 propose Dune,
+// Solaris and Emma, members 4 and 5 only vote.
+func rankedRound(t *testing.T) (*Bot, *fakeMessenger, *memSessionRepo) {
+	t.Helper()
+	cfg := testConfig()
+	cfg.VotingMode = models.VotingRanked
+	b, fake, sessions := clubWithMembers(t, cfg, 1, 2, 3, 4, 5)
+	b.messages.RankedBallot = "ballot"
+	b.messages.RankedBallotSubmitted = "submitted"
+	b.messages.RankedBallotClosed = "closed"
+	b.messages.RankedDone = "done"
+	b.messages.RankedReset = "reset"
+
+	updates := []tgbotapi.Update{dm(1, "/start_vote")}
+	updates = append(updates, submit(1, "Dune", "Herbert")...)
+	updates = append(updates, submit(2, "Solaris", "Lem")...)
+	updates = append(updates, submit(3, "Emma", "Austen")...)
+	updates = append(updates, dm(4, "/skip"), dm(5, "/skip"))
+	b.serve(fake.inject(updates...))
+	require.Equal(t, models.StatusVoting, sessions.latest(testClubID).Status)
+	return b, fake, sessions
+}
+
+// stubCatalog is a metadata.Provider over a fixed set of books, keyed by ISBN
+// or by search query.
+type stubCatalog map[string]*metadata.Book
+
+func (c stubCatalog) LookupISBN(_ context.Context, isbn string) (*metadata.Book, error) {
+	return c.Search(context.Background(), isbn)
+}
+
+func (c stubCatalog) Search(_ context.Context, query string) (*metadata.Book, error) {
+	if book, ok := c[query]; ok {
+		copied := *book
+		return &copied, nil
+	}
+	return nil, metadata.ErrNotFound
+}
+
+// stubLinks is a metadata.LinkResolver over a fixed set of book pages. A link
+// mapped to nil is a page it cannot read.
+type stubLinks map[string]*metadata.Book
+
+func (l stubLinks) Resolves(link string) bool {
+	_, ok := l[link]
+	return ok
+}
+
+func (l stubLinks) Resolve(_ context.Context, link string) (*metadata.Book, error) {
+	if book := l[link]; book != nil {
+		copied := *book
+		return &copied, nil
+	}
+	return nil, errors.New("page unavailable")
+}
+
+// catalogRound starts gathering in a club of two with a catalog knowing Dune by
+// its ISBN (with everything) and Solaris by its title (without a description),
+// and a LiveLib page of Emma.
+func catalogRound(t *testing.T) (*Bot, *fakeMessenger, *memSessionRepo) {
+	t.Helper()
+	cfg := testConfig()
+	b, fake, sessions := clubWithMembers(t, cfg, 1, 2)
+	b.metadata = stubCatalog{
+		"9780441172719": {Title: "Dune", Author: "Frank Herbert", Description: "Desert planet.", Pages: 604, CoverURL: "https://covers.example/dune.jpg"},
+		"Solaris":       {Title: "Solaris", Author: "Stanisław Lem", Pages: 204},
+	}
+	b.links = stubLinks{
+		"https://www.livelib.ru/book/1-emma": {Title: "Emma", Author: "Jane Austen", Description: "Matchmaking.", CoverURL: "https://covers.example/emma.jpg"},
+		"https://www.livelib.ru/book/2-gone": nil,
+	}
+	b.messages.BookFound = "found %s by %s, %s pages: %s"
+	b.messages.BookFoundYes = "yes"
+	b.messages.BookFoundNo = "no"
+	b.messages.BookNotFoundByISBN = "unknown isbn"
+	b.messages.BookLinkUnreadable = "unreadable link"
+	b.messages.BookRejectedAskTitle = "title then"
+	b.messages.BookProposalClosed = "closed"
+	b.messages.WhoIsAuthor = "author?"
+	b.messages.WriteBookDescription = "description?"
+	b.messages.AttachCoverPhoto = "cover?"
+	b.serve(fake.inject(dm(1, "/start_vote")))
+	return b, fake, sessions
+}
+
+// submit walks a user through the book submission conversation, without a
+// cover photo.
+func submit(uid int64, title, author string) []tgbotapi.Update {
+	return []tgbotapi.Update{
+		dm(uid, title),
+		dm(uid, author),
+		dm(uid, "About "+title),
+		dm(uid, "no cover"),
+	}
+}
+
+// rank has a member tap the books in order of preference.
+func rank(b *Bot, fake *fakeMessenger, uid int64, titles ...string) {
+	for _, title := range titles {
+		b.serve(fake.inject(fake.tap(uid, title)))
+	}
+}
+
+// serveEach serves the updates one by one, so that a book lookup started by
+// one is done before the next arrives, as it is for a member who waits.
+func serveEach(b *Bot, fake *fakeMessenger, updates ...tgbotapi.Update) {
+	for _, u := range updates {
+		b.serve(fake.inject(u))
+	}
+}
+
+// lastText returns the last text sent to a chat.
+func lastText(fake *fakeMessenger, chatID int64) string {
+	texts := fake.textsTo(chatID)
+	if len(texts) == 0 {
+		return ""
+	}
+	return texts[len(texts)-1]
+}
+
+// photo builds a private message carrying a photo.
+func photo(from int64, fileID string) tgbotapi.Update {
+	u := dm(from, "")
+	u.Message.Photo = []tgbotapi.PhotoSize{{FileID: fileID + "-small"}, {FileID: fileID}}
+	return u
+}
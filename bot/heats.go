@@ -0,0 +1,274 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// maxPollOptions is Telegram's limit on the options of one poll.
+const maxPollOptions = 10
+
+// startVotingStage sends the polls for one voting stage and fills in the
+// stage's polls and deadlines on voting. Books that fit in one poll go to the
+// final poll; otherwise they are split into preliminary heats of at most
+// maxPollOptions, and the leaders of each heat go through to the next stage
+// when the heats close (see closeHeats). Every stage lasts the round's voting
+// duration, except a runoff, which has its own when time_for_runoff is set.
+func (b *Bot) startVotingStage(session *models.BookClubSession, voting *models.Voting, books []models.PollOption) error {
+	duration := b.votingDuration(session)
+	question := fmt.Sprintf(b.messages.ChooseUpToBooks, b.maxChoices())
+	if voting.Runoff {
+		question = b.messages.RunoffQuestion
+		if b.cfg.TimeForRunoff > 0 {
+			duration = time.Duration(b.cfg.TimeForRunoff) * time.Second
+		}
+	}
+	votingEnds := fmt.Sprintf(b.messages.VotingEndsInHours, duration.Hours())
+
+	if len(books) <= maxPollOptions {
+		msg, err := b.sendPoll(session.ChatID, fmt.Sprintf("%s.%s", question, votingEnds), optionTexts(books), !voting.Runoff && b.maxChoices() > 1)
+		if err != nil {
+			return err
+		}
+		voting.TelegramPollID = msg.MessageID
+		if msg.Poll != nil {
+			voting.PollID = msg.Poll.ID
+		}
+		voting.Options = books
+	} else {
+		voting.Options = nil
+		stage := 1
+		if n := len(voting.Heats); n > 0 {
+			stage = voting.Heats[n-1].Stage + 1
+		}
+		groups := splitHeats(books)
+		b.sendMessage(session.ChatID, fmt.Sprintf(b.messages.HeatsStarted, len(books), len(groups), advancePerHeat(len(groups))))
+		for i, group := range groups {
+			question := fmt.Sprintf(b.messages.HeatQuestion, i+1, len(groups), b.maxChoices()) + votingEnds
+			options := optionTexts(group)
+			msg, err := b.sendPoll(session.ChatID, question, options, b.maxChoices() > 1)
+			if err != nil {
+				return err
+			}
+			heat := &models.Heat{Stage: stage, TelegramPollID: msg.MessageID, Options: options, Books: group}
+			if msg.Poll != nil {
+				heat.PollID = msg.Poll.ID
+			}
+			voting.Heats = append(voting.Heats, heat)
+		}
+	}
+
+	b.scheduleStage(voting, duration)
+	return nil
+}
+
+// scheduleStage starts a voting stage's clock: it lasts duration from now and
+// starts with no voters.
+func (b *Bot) scheduleStage(voting *models.Voting, duration time.Duration) {
+	now := time.Now().UTC()
+	voting.Deadline = now.Add(duration)
+	voting.NotifyAt = reminderAt(now, voting.Deadline, b.cfg.NotifyBeforePoll)
+	voting.NotifiedAt = nil
+	voting.VoterIDs = nil
+	voting.StartedAt = now
+}
+
+// sendPoll sends a public poll to the group.
+func (b *Bot) sendPoll(chatID int64, question string, options []string, multipleAnswers bool) (tgbotapi.Message, error) {
+	poll := tgbotapi.NewPoll(chatID, question, options...)
+	poll.IsAnonymous = false
+	poll.AllowsMultipleAnswers = multipleAnswers
+	return b.tgBot.Send(poll)
+}
+
+// closeHeats stops the running heats, records who goes through and starts the
+// next stage. A heat is stopped and recorded one at a time, so when a StopPoll
+// fails the close is retried later without stopping the other heats twice. It
+// runs under b.mu, like the rest of closeTelegramPoll's state change.
+func (b *Bot) closeHeats(session *models.BookClubSession) error {
+	voting := session.Voting
+	current := currentHeats(voting)
+	perHeat := advancePerHeat(len(current))
+
+	var advanced []models.PollOption
+	for _, i := range current {
+		h := voting.Heats[i]
+		books := h.Books
+		if len(books) != len(h.Options) {
+			books = b.pollOptions(session, h.Options) // a heat from before books were stored
+		}
+		if h.ClosedAt == nil {
+			res, err := b.tgBot.StopPoll(tgbotapi.StopPollConfig{
+				BaseEdit: tgbotapi.BaseEdit{ChatID: session.ChatID, MessageID: h.TelegramPollID},
+			})
+			if err != nil {
+				return err
+			}
+			now := time.Now().UTC()
+			counted := countedPoll(res, voting, b.maxChoices())
+			h.Advanced = heatLeaders(&counted, perHeat)
+			if len(h.Advanced) == len(h.Options) && len(h.Options) > perHeat {
+				// Sending every book through would only run the heat again.
+				h.Advanced = drawAtCutOff(&counted, h.Advanced, perHeat, drawSeed(session)+int64(i))
+				log.Printf("heat %d of session %s is level at the cut-off, lots drawn", i, session.ID.Hex())
+			}
+			if err := b.sessionRepository.CloseHeat(context.Background(), session.ID, i, h.Advanced, now); err != nil {
+				return err
+			}
+			h.ClosedAt = &now
+			result := b.pollResult(session, books, &res, &counted, now)
+			result.TelegramPollID, result.Stage = h.TelegramPollID, h.Stage
+			b.recordPollResult(session, result)
+		}
+		for _, j := range h.Advanced {
+			if j < len(books) {
+				advanced = append(advanced, books[j])
+			}
+		}
+	}
+	log.Printf("heats of session %s closed, %d books go through", session.ID.Hex(), len(advanced))
+
+	names := make([]string, len(advanced))
+	for i, a := range advanced {
+		names[i] = strings.TrimSpace(a.Text)
+	}
+	b.sendMessage(session.ChatID, fmt.Sprintf(b.messages.HeatsFinished, strings.Join(names, "\n")))
+
+	next := *voting
+	next.TelegramPollID, next.PollID = 0, ""
+	if err := b.startVotingStage(session, &next, advanced); err != nil {
+		return err
+	}
+	return b.sessionRepository.StartVoting(context.Background(), session.ID, &next)
+}
+
+// splitHeats splits the books into the fewest groups of at most maxPollOptions,
+// keeping the groups' sizes within one of each other.
+func splitHeats[T any](books []T) [][]T {
+	n := (len(books) + maxPollOptions - 1) / maxPollOptions
+	groups := make([][]T, n)
+	for i := range groups {
+		groups[i] = books[i*len(books)/n : (i+1)*len(books)/n]
+	}
+	return groups
+}
+
+// optionTexts returns the texts of poll options, as sent to Telegram.
+func optionTexts(options []models.PollOption) []string {
+	texts := make([]string, len(options))
+	for i, o := range options {
+		texts[i] = o.Text
+	}
+	return texts
+}
+
+// advancePerHeat is how many books go through from each of n heats, so that
+// the next stage has at most maxPollOptions books where possible.
+func advancePerHeat(n int) int {
+	return max(1, maxPollOptions/n)
+}
+
+// heatLeaders returns the indexes of the options going through a heat, most
+// votes first and in the heat's option order where votes are level: the n with
+// the most votes, and any level with the last of them, so a tie at the cut-off
+// is not settled by the order the options were shuffled into. Options nobody
+// voted for do not go through while any other got a vote.
+func heatLeaders(poll *tgbotapi.Poll, n int) []int {
+	order := make([]int, len(poll.Options))
+	for i := range order {
+		order[i] = i
+	}
+	votes := func(i int) int { return poll.Options[i].VoterCount }
+	slices.SortStableFunc(order, func(a, b int) int { return votes(b) - votes(a) })
+	if len(order) > 0 && votes(order[0]) > 0 {
+		order = slices.DeleteFunc(order, func(i int) bool { return votes(i) == 0 })
+	}
+	if n <= 0 || n >= len(order) {
+		return order
+	}
+	end := n
+	for end < len(order) && votes(order[end]) == votes(order[n-1]) {
+		end++
+	}
+	return order[:end]
+}
+
+// drawAtCutOff cuts a heat's leaders to n: those ahead of the cut-off go
+// through, and lots seeded by seed are drawn among those level at it.
+func drawAtCutOff(poll *tgbotapi.Poll, leaders []int, n int, seed int64) []int {
+	cut := poll.Options[leaders[n-1]].VoterCount
+	var ahead, level []int
+	for _, j := range leaders {
+		if poll.Options[j].VoterCount > cut {
+			ahead = append(ahead, j)
+		} else {
+			level = append(level, j)
+		}
+	}
+	rand.New(rand.NewSource(seed)).Shuffle(len(level), func(a, b int) { level[a], level[b] = level[b], level[a] })
+	return append(ahead, level[:n-len(ahead)]...)
+}
+
+// inHeats reports whether the voting is still in its preliminary stage, i.e.
+// the final poll has not been sent yet.
+func inHeats(v *models.Voting) bool {
+	return v.TelegramPollID == 0 && len(v.Heats) > 0
+}
+
+// currentHeats returns the indexes of the heats in the latest stage.
+func currentHeats(v *models.Voting) []int {
+	if len(v.Heats) == 0 {
+		return nil
+	}
+	stage := v.Heats[len(v.Heats)-1].Stage
+	var idx []int
+	for i, h := range v.Heats {
+		if h.Stage == stage {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// allVoted reports whether every member has voted in the current stage: in
+// every running heat, or in the final poll.
+func allVoted(v *models.Voting) bool {
+	if v.TotalParticipants <= 0 {
+		return false
+	}
+	if !inHeats(v) {
+		return len(v.VoterIDs) >= v.TotalParticipants
+	}
+	for _, i := range currentHeats(v) {
+		if h := v.Heats[i]; h.ClosedAt == nil && len(h.VoterIDs) < v.TotalParticipants {
+			return false
+		}
+	}
+	return true
+}
+
+// openPolls returns the message IDs of the voting's polls still open. A ranked
+// vote has none.
+func openPolls(v *models.Voting) []int {
+	if !inHeats(v) {
+		if v.ClosedAt != nil || v.TelegramPollID == 0 {
+			return nil
+		}
+		return []int{v.TelegramPollID}
+	}
+	var ids []int
+	for _, i := range currentHeats(v) {
+		if v.Heats[i].ClosedAt == nil {
+			ids = append(ids, v.Heats[i].TelegramPollID)
+		}
+	}
+	return ids
+}
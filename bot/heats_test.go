@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVotingWithHeats(t *testing.T) {
+	const members = 12
+	cfg := &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, TimeForTelegramPoll: 3600}
+	ids := make([]int64, members)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	b, fake, sessions := clubWithMembers(t, cfg, ids...)
+	b.messages.HeatsStarted = "%d books, %d heats, %d through"
+	b.messages.HeatsFinished = "through:\n%s"
+	b.messages.HeatQuestion = "heat %d of %d, pick up to %d"
+
+	updates := []tgbotapi.Update{dm(1, "/start_vote")}
+	for _, id := range ids {
+		updates = append(updates, submit(id, fmt.Sprintf("Title%02d", id), "Author")...)
+	}
+	b.serve(fake.inject(updates...))
+
+	// Twelve books do not fit in one poll: two heats of six are run instead, and
+	// no book is dropped.
+	session := sessions.latest(testClubID)
+	require.Equal(t, models.StatusVoting, session.Status)
+	heats := session.Voting.Heats
+	require.Len(t, heats, 2)
+	assert.Zero(t, session.Voting.TelegramPollID, "the final poll is not sent yet")
+	assert.Len(t, heats[0].Options, 6)
+	assert.Len(t, heats[1].Options, 6)
+	assert.Len(t, append(heats[0].Options, heats[1].Options...), members)
+	assert.Contains(t, fake.textsTo(testClubID), "12 books, 2 heats, 5 through")
+	assert.True(t, strings.HasPrefix(fake.polls[heats[1].TelegramPollID].Question, "heat 2 of 2, pick up to 2"))
+
+	// Everyone votes in both heats, which closes them early. In the first heat
+	// all pick the favourite and spread their second vote over four other books;
+	// in the second all pick the same book.
+	favourite := strings.TrimSpace(heats[0].Options[3])
+	var votes []tgbotapi.Update
+	for _, id := range ids {
+		second := strings.TrimSpace(heats[0].Options[[]int{0, 1, 2, 4}[id%4]])
+		votes = append(votes, fake.vote(id, heats[0].PollID, favourite, second))
+		votes = append(votes, fake.vote(id, heats[1].PollID, strings.TrimSpace(heats[1].Options[0])))
+	}
+	b.serve(fake.inject(votes...))
+
+	session = sessions.latest(testClubID)
+	require.Equal(t, models.StatusVoting, session.Status)
+	for _, h := range session.Voting.Heats {
+		assert.NotNil(t, h.ClosedAt)
+	}
+	assert.Len(t, session.Voting.Heats[0].Advanced, 5, "the book nobody voted for is out")
+	assert.Equal(t, []int{0}, session.Voting.Heats[1].Advanced, "only the book that got votes goes through")
+	assert.Equal(t, 3, session.Voting.Heats[0].Advanced[0], "the heat's leader goes through first")
+
+	final := fake.lastPoll()
+	require.NotNil(t, final)
+	assert.Equal(t, final.ID, session.Voting.PollID)
+	assert.Len(t, final.Options, 6)
+	assert.Empty(t, session.Voting.VoterIDs, "the final starts with no voters")
+	group := fake.textsTo(testClubID)
+	assert.True(t, strings.HasPrefix(group[len(group)-1], "through:\n"))
+
+	// The final is an ordinary poll.
+	votes = nil
+	for _, id := range ids {
+		votes = append(votes, fake.vote(id, final.ID, favourite))
+	}
+	b.serve(fake.inject(votes...))
+
+	session = sessions.latest(testClubID)
+	assert.Equal(t, models.StatusReading, session.Status)
+	require.Len(t, session.Voting.Results, 3, "both heats and the final are archived")
+	assert.Equal(t, []int{1, 1, 0}, []int{session.Voting.Results[0].Stage, session.Voting.Results[1].Stage, session.Voting.Results[2].Stage})
+	assert.Equal(t, members, session.Voting.Results[0].Options[3].Votes)
+	require.Len(t, session.Winners, 1)
+	assert.Equal(t, favourite, strings.TrimSpace(b.pollOptionFor(&models.Book{Title: session.Winners[0].Title, Author: "Author"})))
+}
+
+func TestCancelDuringHeats(t *testing.T) {
+	cfg := &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, TimeForTelegramPoll: 3600}
+	b, fake, sessions := clubWithMembers(t, cfg, 1)
+	b.serve(fake.inject(dm(1, "/start_vote")))
+
+	// Give the round eleven books without walking eleven members through it.
+	session := sessions.latest(testClubID)
+	for i := 0; i < 11; i++ {
+		session.Gathering.Participants = append(session.Gathering.Participants, &models.Participant{
+			SubscriberID: int64(100 + i),
+			Step:         models.StepDone,
+			Book:         &models.Book{Title: fmt.Sprintf("Title%02d", i)},
+		})
+	}
+	require.NoError(t, sessions.SetStatus(context.Background(), session.ID, models.StatusVoting))
+	require.NoError(t, b.runTelegramPoll(session))
+	require.Len(t, sessions.latest(testClubID).Voting.Heats, 2)
+
+	b.serve(fake.inject(dm(1, "/cancel_vote")))
+
+	assert.Equal(t, models.StatusCancelled, sessions.latest(testClubID).Status)
+	for _, p := range fake.polls {
+		assert.True(t, p.IsClosed, "every heat is stopped")
+	}
+}
+
+func TestSplitHeats(t *testing.T) {
+	books := make([]string, 25)
+	for i := range books {
+		books[i] = fmt.Sprint(i)
+	}
+	groups := splitHeats(books)
+	require.Len(t, groups, 3)
+	assert.Equal(t, []int{8, 8, 9}, []int{len(groups[0]), len(groups[1]), len(groups[2])})
+	assert.Equal(t, books, append(append(groups[0], groups[1]...), groups[2]...))
+
+	assert.Len(t, splitHeats(books[:10]), 1)
+	assert.Len(t, splitHeats(books[:11]), 2)
+}
+
+func TestAdvancePerHeat(t *testing.T) {
+	assert.Equal(t, 5, advancePerHeat(2))
+	assert.Equal(t, 3, advancePerHeat(3))
+	assert.Equal(t, 1, advancePerHeat(10))
+	assert.Equal(t, 1, advancePerHeat(11), "more heats than final options need another stage")
+}
+
+func TestHeatLeaders(t *testing.T) {
+	poll := &tgbotapi.Poll{Options: []tgbotapi.PollOption{
+		{Text: "a", VoterCount: 1},
+		{Text: "b", VoterCount: 3},
+		{Text: "c", VoterCount: 1},
+		{Text: "d", VoterCount: 0},
+	}}
+	assert.Equal(t, []int{1, 0, 2}, heatLeaders(poll, 3), "level votes keep the heat's order")
+	assert.Equal(t, []int{1, 0, 2}, heatLeaders(poll, 2), "a tie at the cut-off goes through together")
+	assert.Equal(t, []int{1}, heatLeaders(poll, 1))
+	assert.Equal(t, []int{1, 0, 2}, heatLeaders(poll, 10), "an option nobody voted for is dropped")
+
+	silent := &tgbotapi.Poll{Options: make([]tgbotapi.PollOption, 4)}
+	assert.Equal(t, []int{0, 1, 2, 3}, heatLeaders(silent, 2), "nobody voted, so all are level")
+}
+
+func TestDrawAtCutOff(t *testing.T) {
+	poll := &tgbotapi.Poll{Options: []tgbotapi.PollOption{{VoterCount: 1}, {VoterCount: 1}, {VoterCount: 2}, {VoterCount: 1}}}
+	leaders := heatLeaders(poll, 2)
+	require.Len(t, leaders, 4)
+
+	got := drawAtCutOff(poll, leaders, 2, 42)
+	require.Len(t, got, 2)
+	assert.Equal(t, 2, got[0], "the leader goes through without a draw")
+	assert.Contains(t, []int{0, 1, 3}, got[1])
+	assert.Equal(t, got, drawAtCutOff(poll, heatLeaders(poll, 2), 2, 42), "the same seed draws the same")
+}
+
+func TestAllVoted(t *testing.T) {
+	closed := time.Now()
+	v := &models.Voting{TotalParticipants: 2, Heats: []*models.Heat{
+		{Stage: 1, VoterIDs: []int64{1, 2}, ClosedAt: &closed},
+		{Stage: 2, VoterIDs: []int64{1, 2}},
+		{Stage: 2, VoterIDs: []int64{1}},
+	}}
+	assert.False(t, allVoted(v), "one running heat is missing a voter")
+	assert.Equal(t, []int{1, 2}, currentHeats(v))
+	assert.Len(t, openPolls(v), 2)
+
+	v.Heats[2].VoterIDs = append(v.Heats[2].VoterIDs, 2)
+	assert.True(t, allVoted(v))
+
+	v.TelegramPollID = 7
+	assert.False(t, allVoted(v), "the final counts its own voters")
+	assert.Equal(t, []int{7}, openPolls(v))
+}
@@ -2,6 +2,8 @@ package bot
 
 import (
 	"math/rand"
+	"strings"
+	"unicode/utf16"
 	"unicode/utf8"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -61,6 +63,45 @@ func truncateString(input string, limit int) string {
 	return string(runes[:limit]) // Take only the first 'limit' runes
 }
 
+// maxPollOptionLength is Telegram's limit on the text of a poll option.
+const maxPollOptionLength = 100
+
+// maxMessageLength is Telegram's limit on the text of a message.
+const maxMessageLength = 4096
+
+// truncateOption cuts text to fit a poll option, marking the cut with "…".
+func truncateOption(text string) string {
+	return truncateText(text, maxPollOptionLength)
+}
+
+// truncateText cuts text to at most limit characters, marking the cut with
+// "…". Telegram counts its limits in UTF-16 code units, so a character outside
+// the Basic Multilingual Plane (an emoji) takes two; no character is ever split.
+func truncateText(text string, limit int) string {
+	if textLength(text) <= limit {
+		return text
+	}
+	var sb strings.Builder
+	n := 0
+	for _, r := range text {
+		l := utf16.RuneLen(r)
+		if l < 0 {
+			l = 1 // an invalid rune is sent as U+FFFD
+		}
+		if n+l > limit-1 {
+			break
+		}
+		sb.WriteRune(r)
+		n += l
+	}
+	return sb.String() + "…"
+}
+
+// textLength is the length of text as Telegram counts it, in UTF-16 code units.
+func textLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
 func shuffleSlice[T any](s []T) []T {
 	copyS := make([]T, len(s))
 	copy(copyS, s)
@@ -69,3 +110,13 @@ func shuffleSlice[T any](s []T) []T {
 	})
 	return copyS
 }
+
+// displayName renders a person's name for group messages, falling back to
+// their @nick when no name is set.
+func displayName(firstName, lastName, nick string) string {
+	name := strings.TrimSpace(firstName + " " + lastName)
+	if name == "" && nick != "" {
+		return "@" + nick
+	}
+	return name
+}
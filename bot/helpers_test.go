@@ -4,7 +4,10 @@ import (
 	"reflect"
 	"slices"
 	"sort"
+	"strings"
 	"testing"
+	"unicode/utf16"
+	"unicode/utf8"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/stretchr/testify/assert"
@@ -265,3 +268,28 @@ func TestShuffleSlice(t *testing.T) {
 	})
 
 }
+
+func TestDisplayName(t *testing.T) {
+	assert.Equal(t, "Alice Smith", displayName("Alice", "Smith", "alice"))
+	assert.Equal(t, "Alice", displayName("Alice", "", "alice"))
+	assert.Equal(t, "@alice", displayName("", "", "alice"))
+	assert.Equal(t, "", displayName("", "", ""))
+}
+
+func TestTruncateOption(t *testing.T) {
+	short := "Book: Dune. Author: Herbert\n"
+	assert.Equal(t, short, truncateOption(short))
+
+	exact := strings.Repeat("ж", maxPollOptionLength)
+	assert.Equal(t, exact, truncateOption(exact), "the limit counts characters, not bytes")
+
+	long := truncateOption(strings.Repeat("ж", 150))
+	assert.Equal(t, maxPollOptionLength, utf8.RuneCountInString(long))
+	assert.True(t, strings.HasSuffix(long, "…"))
+
+	// Emoji take two UTF-16 code units and are never split.
+	emoji := truncateOption(strings.Repeat("a📚", 50))
+	assert.LessOrEqual(t, len(utf16.Encode([]rune(emoji))), maxPollOptionLength)
+	assert.True(t, utf8.ValidString(emoji))
+	assert.Equal(t, strings.Repeat("a📚", 33)+"…", emoji)
+}
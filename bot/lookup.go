@@ -0,0 +1,284 @@
+package bot
+
+import (
+	"BookClubBot/internal/metadata"
+	"BookClubBot/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// bookPrefix starts the callback data of the buttons under a catalog match:
+// "book:<session ID>:<yes|no>".
+const bookPrefix = "book"
+
+// lookupTimeout bounds one catalog lookup or page fetch. Lookups run off the
+// update loop (see startLookup), so a slow site keeps only the member who
+// asked waiting.
+const lookupTimeout = 10 * time.Second
+
+// needsLookup reports whether an answer to the title question is looked up: a
+// link to a book page (Goodreads, LiveLib) always, anything else when a catalog
+// is configured. A blank answer (e.g. a photo alone) never is.
+func (b *Bot) needsLookup(text string) bool {
+	if strings.TrimSpace(text) == "" {
+		return false
+	}
+	return b.metadata != nil || b.links != nil && b.links.Resolves(text)
+}
+
+// startLookup looks up the participant's answer to the title question as a
+// link, an ISBN or a title. The participant waits at StepLookup, with the
+// answer in p.Lookup, while the lookup runs in the background; finishLookup
+// then carries on from the stored session.
+func (b *Bot) startLookup(session *models.BookClubSession, p *models.Participant, text string) {
+	p.Step = models.StepLookup
+	p.Lookup = text
+	b.persistParticipant(session.ID, p)
+	b.runLookup(session.ChatID, p.SubscriberID, text)
+}
+
+// runLookup runs the lookup of text for uid in a goroutine, unless one is
+// already running.
+func (b *Bot) runLookup(chatID, uid int64, text string) {
+	b.lookupMu.Lock()
+	if b.inLookup == nil {
+		b.inLookup = make(map[int64]bool)
+		b.lookedUp = make(map[int64]string)
+	}
+	if b.inLookup[uid] {
+		b.lookupMu.Unlock()
+		return
+	}
+	b.inLookup[uid] = true
+	b.lookedUp[uid] = text
+	b.lookupMu.Unlock()
+
+	b.lookups.Add(1)
+	go func() {
+		defer b.lookups.Done()
+		defer func() {
+			b.lookupMu.Lock()
+			delete(b.inLookup, uid)
+			b.lookupMu.Unlock()
+		}()
+		found, notFound := b.lookUp(text)
+		b.finishLookup(chatID, uid, text, found, notFound)
+	}()
+}
+
+// resumeLookup carries on with a participant still waiting at StepLookup, as
+// found by a message sent meanwhile or by the recovery loop. A lookup cut short
+// by a restart is run again; one that already ran, but whose result could not
+// be saved, is not: the participant goes on as if it had found nothing, so the
+// catalog is asked once, not on every recovery tick.
+func (b *Bot) resumeLookup(chatID, uid int64, text string) {
+	b.lookupMu.Lock()
+	running, tried := b.inLookup[uid], b.lookedUp[uid] == text
+	b.lookupMu.Unlock()
+	switch {
+	case running:
+	case tried:
+		notFound := ""
+		if b.links != nil && b.links.Resolves(text) {
+			notFound = b.messages.BookLinkUnreadable
+		} else if _, isISBN := metadata.NormalizeISBN(text); isISBN {
+			notFound = b.messages.BookNotFoundByISBN
+		}
+		b.finishLookup(chatID, uid, text, nil, notFound)
+	default:
+		b.runLookup(chatID, uid, text)
+	}
+}
+
+// lookUp reads text as a link to a book page, an ISBN or a title and looks it
+// up. It returns the match, or nil and the message explaining why a link or an
+// ISBN gave nothing; an unknown title gets no message, as it goes on by hand.
+func (b *Bot) lookUp(text string) (*metadata.Book, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), lookupTimeout)
+	defer cancel()
+
+	var found *metadata.Book
+	var err error
+	notFound := ""
+	switch isbn, isISBN := metadata.NormalizeISBN(text); {
+	case b.links != nil && b.links.Resolves(text):
+		found, err = b.links.Resolve(ctx, text)
+		notFound = b.messages.BookLinkUnreadable
+	case b.metadata == nil:
+		return nil, ""
+	case isISBN:
+		found, err = b.metadata.LookupISBN(ctx, isbn)
+		notFound = b.messages.BookNotFoundByISBN
+	default:
+		found, err = b.metadata.Search(ctx, text)
+	}
+	if err != nil {
+		if !errors.Is(err, metadata.ErrNotFound) {
+			log.Printf("cannot look up %q: %v", text, err)
+		}
+		return nil, notFound
+	}
+	return found, ""
+}
+
+// finishLookup carries on with the participant's book once its lookup is
+// done. The session is loaded again under b.mu, as the round has gone on
+// meanwhile; a participant no longer waiting for this lookup (who went /back,
+// withdrew or skipped, or whose gathering closed) is left alone. A match is saved as the
+// participant's book and shown for confirmation (StepConfirm), once the
+// participant has said it is not a book already proposed; a link or an ISBN
+// that gave nothing asks for the title; an unknown title goes on by hand.
+func (b *Bot) finishLookup(chatID, uid int64, text string, found *metadata.Book, notFound string) {
+	b.mu.Lock()
+	session, err := b.sessionRepository.GetActiveSession(context.Background(), chatID)
+	if err != nil {
+		b.mu.Unlock()
+		log.Printf("cannot get active session after a lookup: %v", err)
+		return
+	}
+	if session == nil || session.Status != models.StatusGathering {
+		b.mu.Unlock()
+		return
+	}
+	p := findParticipant(session, uid)
+	if p == nil || p.Step != models.StepLookup || p.Lookup != text {
+		b.mu.Unlock()
+		return
+	}
+
+	switch {
+	case found != nil:
+		b.takeBook(session, p, &models.Book{
+			Title:       found.Title,
+			Author:      found.Author,
+			Description: found.Description,
+			Pages:       found.Pages,
+			CoverURL:    found.CoverURL,
+		})
+	case notFound != "":
+		p.Step, p.Lookup = models.StepBook, ""
+		b.persistParticipant(session.ID, p)
+		b.sendMessage(uid, notFound)
+	default:
+		p.Step, p.Lookup = models.StepBook, ""
+		q, _ := b.question(models.BookTitle)
+		if _, ok := b.readAnswer(uid, q, text, nil); !ok {
+			b.persistParticipant(session.ID, p)
+			b.mu.Unlock()
+			return
+		}
+		b.takeBook(session, p, &models.Book{Title: text})
+	}
+	b.mu.Unlock()
+	// runTelegramPollFlow takes b.mu itself.
+	if allBooksChosen(session) {
+		b.runTelegramPollFlow(chatID)
+	}
+}
+
+// sendBookProposal shows the participant the catalog's match with the buttons
+// to confirm or reject it.
+func (b *Bot) sendBookProposal(session *models.BookClubSession, p *models.Participant) {
+	msg := tgbotapi.NewMessage(p.SubscriberID, b.bookProposalText(p.Book))
+	data := func(answer string) string {
+		return fmt.Sprintf("%s:%s:%s", bookPrefix, session.ID.Hex(), answer)
+	}
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData(b.messages.BookFoundYes, data("yes")),
+		tgbotapi.NewInlineKeyboardButtonData(b.messages.BookFoundNo, data("no")),
+	))
+	if _, err := b.tgBot.Send(msg); err != nil {
+		log.Printf("cannot send a book proposal to %d: %v", p.SubscriberID, err)
+	}
+}
+
+// bookProposalText renders a catalog match, with a dash for what the catalog
+// does not know.
+func (b *Bot) bookProposalText(bk *models.Book) string {
+	orDash := func(s string) string {
+		if s = strings.TrimSpace(s); s == "" {
+			return "—"
+		}
+		return s
+	}
+	pages := ""
+	if bk.Pages > 0 {
+		pages = strconv.Itoa(bk.Pages)
+	}
+	return fmt.Sprintf(b.messages.BookFound, bk.Title, orDash(bk.Author), orDash(pages), orDash(truncateString(bk.Description, 600)))
+}
+
+// handleBookCallback handles the answer to a catalog match. Confirmed, the
+// match stands and the participant is asked only what the catalog did not
+// know; rejected, they go on by hand with the title they typed (checked for
+// duplicates again), or are asked for the title when they had sent an ISBN or
+// a link.
+func (b *Bot) handleBookCallback(q *tgbotapi.CallbackQuery, sessionID, answer string) {
+	uid := q.From.ID
+	session, p := b.pendingBookFor(uid, sessionID, models.StepConfirm)
+	if p == nil {
+		b.answerCallback(q.ID, b.messages.BookProposalClosed)
+		return
+	}
+	b.answerCallback(q.ID, "")
+	if q.Message != nil {
+		// Drop the buttons, so the match cannot be answered twice.
+		if _, err := b.tgBot.Send(tgbotapi.NewEditMessageText(uid, q.Message.MessageID, b.bookProposalText(p.Book))); err != nil {
+			log.Printf("cannot close the book proposal of %d: %v", uid, err)
+		}
+	}
+
+	lookup := p.Lookup
+	p.Lookup = ""
+	if answer != "yes" {
+		if !b.isTitle(lookup) {
+			p.Book = nil
+			p.Step = models.StepBook
+			b.persistParticipant(session.ID, p)
+			b.sendMessage(uid, b.messages.BookRejectedAskTitle)
+			return
+		}
+		b.takeBook(session, p, &models.Book{Title: strings.TrimSpace(lookup)})
+	} else {
+		b.askNextQuestion(session, p)
+	}
+	if allBooksChosen(session) {
+		b.runTelegramPollFlow(session.ChatID)
+	}
+}
+
+// isTitle reports whether a looked-up answer was a title, rather than an ISBN
+// or a link.
+func (b *Bot) isTitle(text string) bool {
+	if _, isISBN := metadata.NormalizeISBN(text); isISBN {
+		return false
+	}
+	return b.links == nil || !b.links.Resolves(text)
+}
+
+// pendingBookFor finds uid's participant whose book awaits an answer at step
+// (a catalog match or a likely duplicate) in the given session, or nil when
+// the question is no longer open.
+func (b *Bot) pendingBookFor(uid int64, sessionID, step string) (*models.BookClubSession, *models.Participant) {
+	sessions, err := b.sessionRepository.GetActiveSessions(context.Background())
+	if err != nil {
+		log.Printf("cannot get active sessions for a book proposal: %v", err)
+		return nil, nil
+	}
+	for _, s := range sessions {
+		if s.ID.Hex() != sessionID || s.Status != models.StatusGathering {
+			continue
+		}
+		if p := findParticipant(s, uid); p != nil && p.Step == step && p.Book != nil {
+			return s, p
+		}
+	}
+	return nil, nil
+}
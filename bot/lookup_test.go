@@ -0,0 +1,244 @@
+package bot
+
+import (
+	"BookClubBot/internal/metadata"
+	"BookClubBot/internal/models"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowLinks reads every link as Emma's page once release is closed.
+type slowLinks struct{ release chan struct{} }
+
+func (slowLinks) Resolves(text string) bool { return strings.HasPrefix(text, "https://") }
+
+func (l slowLinks) Resolve(context.Context, string) (*metadata.Book, error) {
+	<-l.release
+	return &metadata.Book{Title: "Emma", Author: "Jane Austen"}, nil
+}
+
+// countingCatalog counts the lookups it passes on to a catalog.
+type countingCatalog struct {
+	metadata.Provider
+	calls int
+}
+
+func (c *countingCatalog) LookupISBN(ctx context.Context, isbn string) (*metadata.Book, error) {
+	c.calls++
+	return c.Provider.LookupISBN(ctx, isbn)
+}
+
+func (c *countingCatalog) Search(ctx context.Context, query string) (*metadata.Book, error) {
+	c.calls++
+	return c.Provider.Search(ctx, query)
+}
+
+func TestBookLookup(t *testing.T) {
+	t.Run("a confirmed ISBN match skips every question", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.serve(fake.inject(dm(1, "978-0-441-17271-9")))
+
+		p := findParticipant(sessions.latest(testClubID), 1)
+		require.Equal(t, models.StepConfirm, p.Step)
+		assert.Equal(t, "978-0-441-17271-9", p.Lookup)
+		assert.Contains(t, fake.textsTo(1), "found Dune by Frank Herbert, 604 pages: Desert planet.")
+
+		b.serve(fake.inject(fake.tap(1, "yes")))
+		p = findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepDone, p.Step)
+		assert.Empty(t, p.Lookup)
+		assert.Equal(t, &models.Book{Title: "Dune", Author: "Frank Herbert", Description: "Desert planet.", Pages: 604, CoverURL: "https://covers.example/dune.jpg"}, p.Book)
+
+		// The second member goes by hand; the poll starts with Dune's cover.
+		serveEach(b, fake, submit(2, "Emma", "Austen")...)
+		require.Equal(t, models.StatusVoting, sessions.latest(testClubID).Status)
+		require.NotEmpty(t, fake.media)
+		var covers []tgbotapi.RequestFileData
+		for _, m := range fake.media[0].Media {
+			covers = append(covers, m.(tgbotapi.InputMediaPhoto).Media)
+		}
+		assert.Contains(t, covers, tgbotapi.FileURL("https://covers.example/dune.jpg"))
+	})
+
+	t.Run("a confirmed title match asks only what it lacks", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.serve(fake.inject(dm(1, "Solaris")))
+		b.serve(fake.inject(fake.tap(1, "yes")))
+		require.Equal(t, models.StepDescription, findParticipant(sessions.latest(testClubID), 1).Step)
+
+		b.serve(fake.inject(dm(1, "Ocean."), dm(1, "no cover")))
+		p := findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepDone, p.Step)
+		assert.Equal(t, models.Book{Title: "Solaris", Author: "Stanisław Lem", Description: "Ocean.", Pages: 204}, *p.Book)
+		assert.Equal(t, []string{"description?", "cover?"}, fake.textsTo(1)[len(fake.textsTo(1))-3:len(fake.textsTo(1))-1])
+	})
+
+	t.Run("a rejected title match goes on by hand", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.serve(fake.inject(dm(1, "Solaris")))
+		tap := fake.tap(1, "no")
+		b.serve(fake.inject(tap))
+
+		p := findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepAuthor, p.Step)
+		assert.Equal(t, &models.Book{Title: "Solaris"}, p.Book)
+
+		// The buttons are gone and a second tap is refused.
+		assert.NotContains(t, fake.keyboards, tap.CallbackQuery.Message.MessageID)
+		b.serve(fake.inject(tap))
+		assert.Equal(t, "closed", fake.callbacks[len(fake.callbacks)-1].Text)
+	})
+
+	t.Run("a rejected or unknown ISBN asks for the title", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.serve(fake.inject(dm(1, "9780441172719")))
+		b.serve(fake.inject(fake.tap(1, "no")))
+		p := findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepBook, p.Step)
+		assert.Nil(t, p.Book)
+		assert.Equal(t, "title then", fake.textsTo(1)[len(fake.textsTo(1))-1])
+
+		b.serve(fake.inject(dm(1, "0-306-40615-2")))
+		assert.Equal(t, "unknown isbn", fake.textsTo(1)[len(fake.textsTo(1))-1])
+		assert.Equal(t, models.StepBook, findParticipant(sessions.latest(testClubID), 1).Step)
+	})
+
+	t.Run("a link is read without a catalog", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.metadata = nil
+		b.serve(fake.inject(dm(1, "https://www.livelib.ru/book/1-emma")))
+		assert.Contains(t, fake.textsTo(1), "found Emma by Jane Austen, — pages: Matchmaking.")
+
+		b.serve(fake.inject(fake.tap(1, "yes")))
+		p := findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepDone, p.Step)
+		assert.Equal(t, "https://covers.example/emma.jpg", p.Book.CoverURL)
+	})
+
+	t.Run("a rejected or unreadable link asks for the title", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.serve(fake.inject(dm(1, "https://www.livelib.ru/book/1-emma")))
+		b.serve(fake.inject(fake.tap(1, "no")))
+		assert.Equal(t, models.StepBook, findParticipant(sessions.latest(testClubID), 1).Step)
+
+		b.serve(fake.inject(dm(1, "https://www.livelib.ru/book/2-gone")))
+		assert.Equal(t, "unreadable link", fake.textsTo(1)[len(fake.textsTo(1))-1])
+		assert.Equal(t, models.StepBook, findParticipant(sessions.latest(testClubID), 1).Step)
+	})
+
+	t.Run("an unknown title goes on by hand", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.serve(fake.inject(dm(1, "Emma")))
+		p := findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepAuthor, p.Step)
+		assert.Equal(t, "Emma", p.Book.Title)
+	})
+
+	t.Run("a match already proposed is confirmed as a different book first", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.messages.DuplicateFound = "dup of %s by %s"
+		b.messages.DuplicateKeep = "keep"
+		serveEach(b, fake, submit(2, "Dune", "Herbert")...)
+		b.serve(fake.inject(dm(1, "9780441172719")))
+		assert.Equal(t, "dup of Dune by Herbert", lastText(fake, 1))
+		assert.Equal(t, models.StepDuplicate, findParticipant(sessions.latest(testClubID), 1).Step)
+
+		b.serve(fake.inject(fake.tap(1, "keep")))
+		assert.Equal(t, "found Dune by Frank Herbert, 604 pages: Desert planet.", lastText(fake, 1))
+		assert.Equal(t, models.StepConfirm, findParticipant(sessions.latest(testClubID), 1).Step)
+	})
+
+	t.Run("a lookup cut short by a restart is run again", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.messages.BookLookupPending = "wait"
+		session := sessions.latest(testClubID)
+		p := findParticipant(session, 1)
+		p.Step, p.Lookup = models.StepLookup, "Solaris"
+		require.NoError(t, sessions.UpdateParticipant(context.Background(), session.ID, p))
+
+		b.serve(fake.inject(dm(1, "hello?")))
+		assert.Contains(t, fake.textsTo(1), "wait")
+		p = findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepConfirm, p.Step)
+		assert.Equal(t, "Solaris", p.Lookup)
+	})
+
+	t.Run("a lookup that already ran is not run again", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.serve(fake.inject(dm(1, "Solaris")))
+
+		// The match is lost, as when it could not be saved.
+		session := sessions.latest(testClubID)
+		p := findParticipant(session, 1)
+		p.Step, p.Book = models.StepLookup, nil
+		require.NoError(t, sessions.UpdateParticipant(context.Background(), session.ID, p))
+		catalog := &countingCatalog{Provider: b.metadata}
+		b.metadata = catalog
+
+		b.recoverTick()
+		b.recoverTick()
+		b.lookups.Wait()
+		assert.Zero(t, catalog.calls)
+		p = findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepAuthor, p.Step)
+		assert.Equal(t, &models.Book{Title: "Solaris"}, p.Book)
+	})
+
+	t.Run("a lookup outrun by the member is dropped", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.messages.UnableToSuggestBook = "skipped"
+		b.metadata = nil
+		links := slowLinks{release: make(chan struct{})}
+		b.links = links
+
+		served := make(chan struct{})
+		go func() {
+			defer close(served)
+			b.serve(fake.inject(dm(1, "https://www.livelib.ru/book/1-emma")))
+		}()
+		require.Eventually(t, func() bool {
+			return findParticipant(sessions.latest(testClubID), 1).Step == models.StepLookup
+		}, time.Second, time.Millisecond)
+		b.handleUpdate(dm(1, "/skip"))
+
+		close(links.release)
+		<-served
+		assert.Equal(t, models.StepSkipped, findParticipant(sessions.latest(testClubID), 1).Step)
+		assert.Equal(t, "skipped", lastText(fake, 1))
+	})
+
+	t.Run("a photo alone is not looked up", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.serve(fake.inject(photo(1, "cover")))
+		p := findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.StepBook, p.Step)
+		assert.Empty(t, p.Lookup)
+	})
+
+	t.Run("a slow page keeps only its member waiting", func(t *testing.T) {
+		b, fake, sessions := catalogRound(t)
+		b.metadata = nil
+		links := slowLinks{release: make(chan struct{})}
+		b.links = links
+
+		served := make(chan struct{})
+		go func() {
+			defer close(served)
+			b.serve(fake.inject(append([]tgbotapi.Update{dm(1, "https://www.livelib.ru/book/1-emma")}, submit(2, "Dune", "Herbert")...)...))
+		}()
+		require.Eventually(t, func() bool {
+			return findParticipant(sessions.latest(testClubID), 2).Step == models.StepDone
+		}, time.Second, time.Millisecond, "the other member goes on while the page is fetched")
+		assert.Equal(t, models.StepLookup, findParticipant(sessions.latest(testClubID), 1).Step)
+
+		close(links.release)
+		<-served
+		assert.Equal(t, models.StepConfirm, findParticipant(sessions.latest(testClubID), 1).Step)
+	})
+}
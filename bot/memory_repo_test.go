@@ -0,0 +1,629 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"BookClubBot/internal/repository"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// The in-memory repositories below behave like their MongoDB counterparts
+// closely enough to drive a whole round in a test. Sessions are stored as BSON
+// copies, so a handler that forgets to persist a change is caught just as it
+// would be against the database.
+
+type memSubscriberRepo struct {
+	mu   sync.Mutex
+	subs map[int64]*models.Subscriber
+}
+
+func newMemSubscriberRepo() *memSubscriberRepo {
+	return &memSubscriberRepo{subs: make(map[int64]*models.Subscriber)}
+}
+
+func (r *memSubscriberRepo) SaveSubscriber(_ context.Context, s *models.Subscriber) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *s
+	r.subs[s.ID] = &copied
+	return nil
+}
+
+func (r *memSubscriberRepo) SetArchiveSubscriber(_ context.Context, id int64, archived bool) error {
+	return r.update(id, func(s *models.Subscriber) { s.Archived = archived })
+}
+
+func (r *memSubscriberRepo) GetAllSubscribers(_ context.Context, chatID int64) ([]*models.Subscriber, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var subs []*models.Subscriber
+	for _, s := range r.subs {
+		if !s.Archived && slices.Contains(s.ClubIDs, chatID) {
+			copied := *s
+			subs = append(subs, &copied)
+		}
+	}
+	slices.SortFunc(subs, func(a, b *models.Subscriber) int { return int(a.ID - b.ID) })
+	return subs, nil
+}
+
+func (r *memSubscriberRepo) GetSubscriberById(_ context.Context, id int64) (*models.Subscriber, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.subs[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *s
+	copied.ClubIDs = slices.Clone(s.ClubIDs)
+	return &copied, nil
+}
+
+func (r *memSubscriberRepo) AddClub(_ context.Context, id, chatID int64) error {
+	return r.update(id, func(s *models.Subscriber) {
+		if !slices.Contains(s.ClubIDs, chatID) {
+			s.ClubIDs = append(s.ClubIDs, chatID)
+		}
+		s.Archived = false
+	})
+}
+
+func (r *memSubscriberRepo) RemoveClub(_ context.Context, id, chatID int64) error {
+	return r.update(id, func(s *models.Subscriber) {
+		s.ClubIDs = slices.DeleteFunc(s.ClubIDs, func(c int64) bool { return c == chatID })
+	})
+}
+
+func (r *memSubscriberRepo) SetCurrentClub(_ context.Context, id, chatID int64) error {
+	return r.update(id, func(s *models.Subscriber) { s.CurrentClubID = chatID })
+}
+
+func (r *memSubscriberRepo) AssignLegacyClub(context.Context, int64) (int64, error) {
+	return 0, nil
+}
+
+func (r *memSubscriberRepo) update(id int64, fn func(*models.Subscriber)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.subs[id]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	fn(s)
+	return nil
+}
+
+type memClubRepo struct {
+	mu    sync.Mutex
+	clubs []*models.Club
+}
+
+func (r *memClubRepo) SaveClub(_ context.Context, club *models.Club) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := *club
+	for i, c := range r.clubs {
+		if c.ChatID == club.ChatID {
+			r.clubs[i] = &copied
+			return nil
+		}
+	}
+	r.clubs = append(r.clubs, &copied)
+	return nil
+}
+
+func (r *memClubRepo) DeleteClub(_ context.Context, chatID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.clubs)
+	r.clubs = slices.DeleteFunc(r.clubs, func(c *models.Club) bool { return c.ChatID == chatID })
+	if len(r.clubs) == n {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *memClubRepo) GetClubById(_ context.Context, chatID int64) (*models.Club, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.clubs {
+		if c.ChatID == chatID {
+			copied := *c
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memClubRepo) GetAllClubs(context.Context) ([]*models.Club, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	clubs := make([]*models.Club, 0, len(r.clubs))
+	for _, c := range r.clubs {
+		copied := *c
+		clubs = append(clubs, &copied)
+	}
+	return clubs, nil
+}
+
+func (r *memClubRepo) SetClubAdmins(_ context.Context, chatID int64, adminIDs []int64, syncedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, c := range r.clubs {
+		if c.ChatID == chatID {
+			c.AdminIDs = slices.Clone(adminIDs)
+			c.AdminsSyncedAt = &syncedAt
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// memBookRepo is the book catalog, keyed like the books collection by title
+// and author keys.
+type memBookRepo struct {
+	mu    sync.Mutex
+	books []*models.CatalogBook
+}
+
+func (r *memBookRepo) RecordProposal(_ context.Context, book *models.CatalogBook, proposal models.BookProposal) (primitive.ObjectID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := slices.IndexFunc(r.books, func(b *models.CatalogBook) bool {
+		return b.TitleKey == book.TitleKey && b.AuthorKey == book.AuthorKey
+	})
+	if i < 0 {
+		r.books = append(r.books, &models.CatalogBook{
+			ID:        primitive.NewObjectID(),
+			Title:     book.Title,
+			Author:    book.Author,
+			TitleKey:  book.TitleKey,
+			AuthorKey: book.AuthorKey,
+		})
+		i = len(r.books) - 1
+	}
+	stored := r.books[i]
+	if !slices.ContainsFunc(stored.Proposals, func(p models.BookProposal) bool {
+		return p.SessionID == proposal.SessionID && p.SubscriberID == proposal.SubscriberID
+	}) {
+		stored.Proposals = append(stored.Proposals, proposal)
+		stored.ProposedCount++
+	}
+	return stored.ID, nil
+}
+
+func (r *memBookRepo) MarkWon(_ context.Context, id, sessionID primitive.ObjectID, subscriberID int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.books {
+		if b.ID != id {
+			continue
+		}
+		for i, p := range b.Proposals {
+			if p.SessionID == sessionID && p.SubscriberID == subscriberID && !p.Won {
+				b.Proposals[i].Won = true
+				b.WonCount++
+				return nil
+			}
+		}
+	}
+	return repository.ErrNotFound
+}
+
+// book returns a copy of the catalog book with the given title key, or nil.
+func (r *memBookRepo) book(titleKey string) *models.CatalogBook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, b := range r.books {
+		if b.TitleKey == titleKey {
+			copied := *b
+			copied.Proposals = slices.Clone(b.Proposals)
+			return &copied
+		}
+	}
+	return nil
+}
+
+type memSessionRepo struct {
+	mu       sync.Mutex
+	sessions []*models.BookClubSession
+}
+
+func (r *memSessionRepo) CreateSession(_ context.Context, session *models.BookClubSession) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.active(session.ChatID) != nil {
+		return repository.ErrActiveSessionExists
+	}
+	now := time.Now().UTC()
+	session.ID = primitive.NewObjectID()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	r.sessions = append(r.sessions, cloneSession(session))
+	return nil
+}
+
+func (r *memSessionRepo) GetActiveSession(_ context.Context, chatID int64) (*models.BookClubSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s := r.active(chatID); s != nil {
+		return cloneSession(s), nil
+	}
+	return nil, nil
+}
+
+func (r *memSessionRepo) GetActiveSessions(context.Context) ([]*models.BookClubSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []*models.BookClubSession
+	for _, s := range r.sessions {
+		if isActiveStatus(s.Status) {
+			sessions = append(sessions, cloneSession(s))
+		}
+	}
+	return sessions, nil
+}
+
+// ListPastSessions returns the club's completed sessions, newest first.
+func (r *memSessionRepo) ListPastSessions(_ context.Context, chatID int64, limit int64) ([]*models.BookClubSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []*models.BookClubSession
+	for i := len(r.sessions) - 1; i >= 0; i-- {
+		s := r.sessions[i]
+		if s.ChatID == chatID && s.Status == models.StatusCompleted {
+			sessions = append(sessions, cloneSession(s))
+		}
+		if limit > 0 && int64(len(sessions)) == limit {
+			break
+		}
+	}
+	return sessions, nil
+}
+
+// ListPastWinners returns the club's completed sessions, newest first, with
+// only their winners, createdAt and reading start, as the projection does.
+func (r *memSessionRepo) ListPastWinners(ctx context.Context, chatID int64) ([]*models.BookClubSession, error) {
+	past, _ := r.ListPastSessions(ctx, chatID, 0)
+	for i, s := range past {
+		winners := &models.BookClubSession{ID: s.ID, Winners: s.Winners, CreatedAt: s.CreatedAt}
+		if s.Reading != nil {
+			winners.Reading = &models.Reading{StartedAt: s.Reading.StartedAt}
+		}
+		past[i] = winners
+	}
+	return past, nil
+}
+
+func (r *memSessionRepo) AssignLegacyChat(context.Context, int64) (int64, error) {
+	return 0, nil
+}
+
+// ListUncataloguedSessions returns the sessions past gathering with a
+// submitted book not in the book catalog, oldest first.
+func (r *memSessionRepo) ListUncataloguedSessions(context.Context) ([]*models.BookClubSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var sessions []*models.BookClubSession
+	for _, s := range r.sessions {
+		if s.Status != models.StatusVoting && s.Status != models.StatusReading && s.Status != models.StatusCompleted {
+			continue
+		}
+		if slices.ContainsFunc(s.Gathering.Participants, func(p *models.Participant) bool {
+			return p.Step == models.StepDone && p.Book != nil && p.BookID.IsZero()
+		}) {
+			sessions = append(sessions, cloneSession(s))
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memSessionRepo) UpdateParticipant(_ context.Context, id primitive.ObjectID, p *models.Participant) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		for i, existing := range s.Gathering.Participants {
+			if existing.SubscriberID == p.SubscriberID {
+				copied := *p
+				s.Gathering.Participants[i] = &copied
+				return nil
+			}
+		}
+		return repository.ErrNotFound
+	})
+}
+
+func (r *memSessionRepo) UpdateReadingMember(_ context.Context, id primitive.ObjectID, m *models.ReadingMember) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Reading == nil {
+			return repository.ErrNotFound
+		}
+		for i, existing := range s.Reading.Members {
+			if existing.SubscriberID == m.SubscriberID {
+				copied := *m
+				s.Reading.Members[i] = &copied
+				return nil
+			}
+		}
+		return repository.ErrNotFound
+	})
+}
+
+func (r *memSessionRepo) AddVoter(_ context.Context, id primitive.ObjectID, voterID int64) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		if !slices.Contains(s.Voting.VoterIDs, voterID) {
+			s.Voting.VoterIDs = append(s.Voting.VoterIDs, voterID)
+		}
+		return nil
+	})
+}
+
+func (r *memSessionRepo) AddOutsider(_ context.Context, id primitive.ObjectID, voterID int64) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		if !slices.Contains(s.Voting.OutsiderIDs, voterID) {
+			s.Voting.OutsiderIDs = append(s.Voting.OutsiderIDs, voterID)
+		}
+		return nil
+	})
+}
+
+func (r *memSessionRepo) RemoveVoter(_ context.Context, id primitive.ObjectID, voterID int64) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		s.Voting.VoterIDs = slices.DeleteFunc(s.Voting.VoterIDs, func(v int64) bool { return v == voterID })
+		return nil
+	})
+}
+
+func (r *memSessionRepo) SetAnswer(_ context.Context, id primitive.ObjectID, answer *models.Answer) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		copied := *answer
+		copied.OptionIDs = slices.Clone(answer.OptionIDs)
+		for i, a := range s.Voting.Answers {
+			if a.PollID == answer.PollID && a.VoterID == answer.VoterID {
+				s.Voting.Answers[i] = &copied
+				return nil
+			}
+		}
+		s.Voting.Answers = append(s.Voting.Answers, &copied)
+		return nil
+	})
+}
+
+func (r *memSessionRepo) AddPollResult(_ context.Context, id primitive.ObjectID, result *models.PollResult) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		copied := *result
+		copied.Options = slices.Clone(result.Options)
+		s.Voting.Results = append(s.Voting.Results, &copied)
+		return nil
+	})
+}
+
+func (r *memSessionRepo) UpdateBallot(_ context.Context, id primitive.ObjectID, ballot *models.Ballot) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		for i, b := range s.Voting.Ballots {
+			if b.VoterID == ballot.VoterID {
+				copied := *ballot
+				copied.Ranking = slices.Clone(ballot.Ranking)
+				s.Voting.Ballots[i] = &copied
+				return nil
+			}
+		}
+		return repository.ErrNotFound
+	})
+}
+
+func (r *memSessionRepo) AddHeatVoter(_ context.Context, id primitive.ObjectID, heat int, voterID int64) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil || heat >= len(s.Voting.Heats) {
+			return repository.ErrNotFound
+		}
+		h := s.Voting.Heats[heat]
+		if !slices.Contains(h.VoterIDs, voterID) {
+			h.VoterIDs = append(h.VoterIDs, voterID)
+		}
+		return nil
+	})
+}
+
+func (r *memSessionRepo) RemoveHeatVoter(_ context.Context, id primitive.ObjectID, heat int, voterID int64) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil || heat >= len(s.Voting.Heats) {
+			return repository.ErrNotFound
+		}
+		h := s.Voting.Heats[heat]
+		h.VoterIDs = slices.DeleteFunc(h.VoterIDs, func(v int64) bool { return v == voterID })
+		return nil
+	})
+}
+
+func (r *memSessionRepo) CloseHeat(_ context.Context, id primitive.ObjectID, heat int, advanced []int, at time.Time) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil || heat >= len(s.Voting.Heats) {
+			return repository.ErrNotFound
+		}
+		s.Voting.Heats[heat].Advanced = slices.Clone(advanced)
+		s.Voting.Heats[heat].ClosedAt = &at
+		return nil
+	})
+}
+
+func (r *memSessionRepo) StartVoting(_ context.Context, id primitive.ObjectID, voting *models.Voting) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Status != models.StatusVoting {
+			return repository.ErrNotFound
+		}
+		s.Voting = cloneSession(&models.BookClubSession{Voting: voting}).Voting
+		return nil
+	})
+}
+
+func (r *memSessionRepo) StartReading(_ context.Context, id primitive.ObjectID, reading *models.Reading) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Status != models.StatusVoting {
+			return repository.ErrNotFound
+		}
+		copied := *reading
+		s.Reading = &copied
+		s.Status = models.StatusReading
+		return nil
+	})
+}
+
+func (r *memSessionRepo) AddReadingMilestone(_ context.Context, id primitive.ObjectID, milestone int) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Reading == nil {
+			return repository.ErrNotFound
+		}
+		if !slices.Contains(s.Reading.MilestonesSent, milestone) {
+			s.Reading.MilestonesSent = append(s.Reading.MilestonesSent, milestone)
+		}
+		return nil
+	})
+}
+
+func (r *memSessionRepo) SetWinners(_ context.Context, id primitive.ObjectID, winners []models.Winner) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		s.Winners = slices.Clone(winners)
+		return nil
+	})
+}
+
+func (r *memSessionRepo) SetTieBreak(_ context.Context, id primitive.ObjectID, tieBreak *models.TieBreak) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		s.TieBreak = cloneSession(&models.BookClubSession{TieBreak: tieBreak}).TieBreak
+		return nil
+	})
+}
+
+func (r *memSessionRepo) SetStatus(_ context.Context, id primitive.ObjectID, status string) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		s.Status = status
+		return nil
+	})
+}
+
+func (r *memSessionRepo) SetGatheringNotified(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		s.Gathering.NotifiedAt = &at
+		return nil
+	})
+}
+
+func (r *memSessionRepo) SetVotingNotified(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		s.Voting.NotifiedAt = &at
+		return nil
+	})
+}
+
+func (r *memSessionRepo) SetVotingClosed(_ context.Context, id primitive.ObjectID, at time.Time) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		s.Voting.ClosedAt = &at
+		return nil
+	})
+}
+
+func (r *memSessionRepo) ExtendGathering(_ context.Context, id primitive.ObjectID, deadline, notifyAt time.Time) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Status != models.StatusGathering {
+			return repository.ErrNotFound
+		}
+		s.Gathering.Deadline, s.Gathering.NotifyAt, s.Gathering.NotifiedAt = deadline, notifyAt, nil
+		return nil
+	})
+}
+
+func (r *memSessionRepo) ExtendVoting(_ context.Context, id primitive.ObjectID, deadline, notifyAt time.Time) error {
+	return r.update(id, func(s *models.BookClubSession) error {
+		if s.Status != models.StatusVoting || s.Voting == nil {
+			return repository.ErrNotFound
+		}
+		s.Voting.Deadline, s.Voting.NotifyAt, s.Voting.NotifiedAt = deadline, notifyAt, nil
+		return nil
+	})
+}
+
+// latest returns a copy of the club's most recent session, whatever its status.
+func (r *memSessionRepo) latest(chatID int64) *models.BookClubSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := len(r.sessions) - 1; i >= 0; i-- {
+		if r.sessions[i].ChatID == chatID {
+			return cloneSession(r.sessions[i])
+		}
+	}
+	return nil
+}
+
+func (r *memSessionRepo) active(chatID int64) *models.BookClubSession {
+	for _, s := range r.sessions {
+		if s.ChatID == chatID && isActiveStatus(s.Status) {
+			return s
+		}
+	}
+	return nil
+}
+
+func (r *memSessionRepo) update(id primitive.ObjectID, fn func(*models.BookClubSession) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		if s.ID == id {
+			if err := fn(s); err != nil {
+				return err
+			}
+			s.UpdatedAt = time.Now().UTC()
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func isActiveStatus(status string) bool {
+	switch status {
+	case models.StatusGathering, models.StatusVoting, models.StatusReading:
+		return true
+	}
+	return false
+}
+
+// cloneSession deep-copies a session through BSON, as a database round trip
+// would.
+func cloneSession(s *models.BookClubSession) *models.BookClubSession {
+	data, err := bson.Marshal(s)
+	if err != nil {
+		panic(err)
+	}
+	var copied models.BookClubSession
+	if err := bson.Unmarshal(data, &copied); err != nil {
+		panic(err)
+	}
+	return &copied
+}
@@ -0,0 +1,25 @@
+package bot
+
+import (
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// messenger is the part of the Telegram Bot API the bot talks to. In production
+// it is a *tgbotapi.BotAPI; tests use an in-memory fake that records what is
+// sent and feeds updates in, so a whole round runs without the network.
+//
+// Polls are sent with Send (a tgbotapi.SendPollConfig) like any other message,
+// and messages are edited with Send too. Request is for calls that return no
+// message, such as answering a callback query.
+type messenger interface {
+	Send(c tgbotapi.Chattable) (tgbotapi.Message, error)
+	Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error)
+	SendMediaGroup(config tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error)
+	StopPoll(config tgbotapi.StopPollConfig) (tgbotapi.Poll, error)
+	GetChat(config tgbotapi.ChatInfoConfig) (tgbotapi.Chat, error)
+	GetChatAdministrators(config tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error)
+	GetChatMember(config tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error)
+	GetUpdatesChan(config tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel
+}
+
+var _ messenger = (*tgbotapi.BotAPI)(nil)
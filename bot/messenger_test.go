@@ -0,0 +1,315 @@
+package bot
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// sentText is a text message the bot sent through the fake messenger.
+type sentText struct {
+	chatID int64
+	text   string
+}
+
+// sentKeyboard is the inline keyboard a message currently carries.
+type sentKeyboard struct {
+	chatID int64
+	markup tgbotapi.InlineKeyboardMarkup
+}
+
+// fakeMessenger is an in-memory messenger. It records everything the bot sends,
+// runs polls (votes are cast with vote), keeps the inline keyboards of its
+// messages (buttons are pressed with tap) and hands out injected updates, so a
+// whole round can be driven from a test without Telegram.
+type fakeMessenger struct {
+	mu        sync.Mutex
+	nextID    int
+	texts     []sentText
+	edits     map[int]string // latest edited text by message ID
+	media     []tgbotapi.MediaGroupConfig
+	polls     map[int]*tgbotapi.Poll // by message ID
+	keyboards map[int]sentKeyboard   // by message ID
+	choices   map[string][]int       // option IDs by poll ID and voter
+	callbacks []tgbotapi.CallbackConfig
+	updates   tgbotapi.UpdatesChannel
+
+	chatAdmins    map[int64][]int64 // group chat ID → administrator user IDs
+	outsiders     map[int64][]int64 // group chat ID → users not in it; everyone else is
+	adminRequests int
+	adminsErr     error
+	unreachable   []int64 // users a message cannot be sent to, as when they blocked the bot
+}
+
+func newFakeMessenger() *fakeMessenger {
+	return &fakeMessenger{
+		edits:     make(map[int]string),
+		polls:     make(map[int]*tgbotapi.Poll),
+		keyboards: make(map[int]sentKeyboard),
+		choices:   make(map[string][]int),
+	}
+}
+
+func (f *fakeMessenger) Send(c tgbotapi.Chattable) (tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	msg := tgbotapi.Message{MessageID: f.nextID}
+
+	switch cfg := c.(type) {
+	case tgbotapi.MessageConfig:
+		if slices.Contains(f.unreachable, cfg.ChatID) {
+			return tgbotapi.Message{}, fmt.Errorf("fake messenger: %d blocked the bot", cfg.ChatID)
+		}
+		f.texts = append(f.texts, sentText{chatID: cfg.ChatID, text: cfg.Text})
+		msg.Chat = &tgbotapi.Chat{ID: cfg.ChatID}
+		msg.Text = cfg.Text
+		if markup, ok := cfg.ReplyMarkup.(tgbotapi.InlineKeyboardMarkup); ok {
+			f.keyboards[f.nextID] = sentKeyboard{chatID: cfg.ChatID, markup: markup}
+		}
+	case tgbotapi.EditMessageTextConfig:
+		f.edits[cfg.MessageID] = cfg.Text
+		if cfg.ReplyMarkup != nil {
+			f.keyboards[cfg.MessageID] = sentKeyboard{chatID: cfg.ChatID, markup: *cfg.ReplyMarkup}
+		} else {
+			delete(f.keyboards, cfg.MessageID)
+		}
+		msg.MessageID = cfg.MessageID
+		msg.Chat = &tgbotapi.Chat{ID: cfg.ChatID}
+		msg.Text = cfg.Text
+	case tgbotapi.EditMessageReplyMarkupConfig:
+		if cfg.ReplyMarkup != nil && len(cfg.ReplyMarkup.InlineKeyboard) > 0 {
+			f.keyboards[cfg.MessageID] = sentKeyboard{chatID: cfg.ChatID, markup: *cfg.ReplyMarkup}
+		} else {
+			delete(f.keyboards, cfg.MessageID)
+		}
+		msg.MessageID = cfg.MessageID
+		msg.Chat = &tgbotapi.Chat{ID: cfg.ChatID}
+	case tgbotapi.SendPollConfig:
+		poll := &tgbotapi.Poll{
+			ID:                    fmt.Sprintf("poll-%d", f.nextID),
+			Question:              cfg.Question,
+			IsAnonymous:           cfg.IsAnonymous,
+			AllowsMultipleAnswers: cfg.AllowsMultipleAnswers,
+		}
+		for _, o := range cfg.Options {
+			poll.Options = append(poll.Options, tgbotapi.PollOption{Text: o})
+		}
+		f.polls[f.nextID] = poll
+		msg.Chat = &tgbotapi.Chat{ID: cfg.ChatID}
+		copied := *poll
+		msg.Poll = &copied
+	default:
+		return tgbotapi.Message{}, fmt.Errorf("fake messenger: unsupported %T", c)
+	}
+	return msg, nil
+}
+
+func (f *fakeMessenger) Request(c tgbotapi.Chattable) (*tgbotapi.APIResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	cfg, ok := c.(tgbotapi.CallbackConfig)
+	if !ok {
+		return nil, fmt.Errorf("fake messenger: unsupported %T", c)
+	}
+	f.callbacks = append(f.callbacks, cfg)
+	return &tgbotapi.APIResponse{Ok: true}, nil
+}
+
+func (f *fakeMessenger) SendMediaGroup(cfg tgbotapi.MediaGroupConfig) ([]tgbotapi.Message, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.media = append(f.media, cfg)
+	msgs := make([]tgbotapi.Message, len(cfg.Media))
+	for i := range msgs {
+		f.nextID++
+		msgs[i] = tgbotapi.Message{MessageID: f.nextID, Chat: &tgbotapi.Chat{ID: cfg.ChatID}}
+	}
+	return msgs, nil
+}
+
+func (f *fakeMessenger) StopPoll(cfg tgbotapi.StopPollConfig) (tgbotapi.Poll, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	poll, ok := f.polls[cfg.MessageID]
+	if !ok {
+		return tgbotapi.Poll{}, fmt.Errorf("fake messenger: no poll in message %d", cfg.MessageID)
+	}
+	poll.IsClosed = true
+	return *poll, nil
+}
+
+func (f *fakeMessenger) GetChat(cfg tgbotapi.ChatInfoConfig) (tgbotapi.Chat, error) {
+	return tgbotapi.Chat{ID: cfg.ChatID, Type: "supergroup"}, nil
+}
+
+func (f *fakeMessenger) GetChatAdministrators(cfg tgbotapi.ChatAdministratorsConfig) ([]tgbotapi.ChatMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.adminRequests++
+	if f.adminsErr != nil {
+		return nil, f.adminsErr
+	}
+	// The bot itself is an administrator of every group it runs polls in.
+	members := []tgbotapi.ChatMember{{User: &tgbotapi.User{ID: testSelfID, IsBot: true}, Status: "administrator"}}
+	for _, id := range f.chatAdmins[cfg.ChatID] {
+		members = append(members, tgbotapi.ChatMember{User: &tgbotapi.User{ID: id}, Status: "administrator"})
+	}
+	return members, nil
+}
+
+func (f *fakeMessenger) GetChatMember(cfg tgbotapi.GetChatMemberConfig) (tgbotapi.ChatMember, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	status := "member"
+	if slices.Contains(f.outsiders[cfg.ChatID], cfg.UserID) {
+		status = "left"
+	}
+	return tgbotapi.ChatMember{User: &tgbotapi.User{ID: cfg.UserID}, Status: status}, nil
+}
+
+func (f *fakeMessenger) GetUpdatesChan(tgbotapi.UpdateConfig) tgbotapi.UpdatesChannel {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.updates
+}
+
+// inject queues updates for the bot and closes the channel, so serving it
+// returns once every update has been handled.
+func (f *fakeMessenger) inject(updates ...tgbotapi.Update) tgbotapi.UpdatesChannel {
+	ch := make(chan tgbotapi.Update, len(updates))
+	for _, u := range updates {
+		ch <- u
+	}
+	close(ch)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.updates = ch
+	return ch
+}
+
+// textsTo returns the texts sent to a chat, oldest first.
+func (f *fakeMessenger) textsTo(chatID int64) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var texts []string
+	for _, t := range f.texts {
+		if t.chatID == chatID {
+			texts = append(texts, t.text)
+		}
+	}
+	return texts
+}
+
+// lastPoll returns the most recently sent poll, or nil.
+func (f *fakeMessenger) lastPoll() *tgbotapi.Poll {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var last *tgbotapi.Poll
+	lastID := 0
+	for id, p := range f.polls {
+		if id > lastID {
+			last, lastID = p, id
+		}
+	}
+	return last
+}
+
+// vote casts a user's vote for the poll options whose text contains want and
+// returns the matching poll answer update.
+func (f *fakeMessenger) vote(userID int64, pollID string, want ...string) tgbotapi.Update {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	answer := &tgbotapi.PollAnswer{PollID: pollID, User: tgbotapi.User{ID: userID}}
+	for _, p := range f.polls {
+		if p.ID != pollID {
+			continue
+		}
+		for i := range p.Options {
+			for _, w := range want {
+				if strings.Contains(p.Options[i].Text, w) {
+					p.Options[i].VoterCount++
+					answer.OptionIDs = append(answer.OptionIDs, i)
+				}
+			}
+		}
+		p.TotalVoterCount++
+	}
+	f.choices[fmt.Sprintf("%s/%d", pollID, userID)] = answer.OptionIDs
+	return tgbotapi.Update{PollAnswer: answer}
+}
+
+// retract takes back a user's vote in a poll, as Telegram's "Retract vote"
+// does, and returns the matching poll answer update with no options.
+func (f *fakeMessenger) retract(userID int64, pollID string) tgbotapi.Update {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := fmt.Sprintf("%s/%d", pollID, userID)
+	for _, p := range f.polls {
+		if p.ID != pollID {
+			continue
+		}
+		for _, i := range f.choices[key] {
+			p.Options[i].VoterCount--
+		}
+		p.TotalVoterCount--
+	}
+	delete(f.choices, key)
+	return tgbotapi.Update{PollAnswer: &tgbotapi.PollAnswer{PollID: pollID, User: tgbotapi.User{ID: userID}}}
+}
+
+// tap presses the button whose text contains label on the inline keyboard
+// last sent to a user, and returns the matching callback query update. It
+// panics when there is no such button, as a test cannot go on without it.
+func (f *fakeMessenger) tap(userID int64, label string) tgbotapi.Update {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	lastID := 0
+	for id, k := range f.keyboards {
+		if k.chatID == userID && id > lastID {
+			lastID = id
+		}
+	}
+	for _, row := range f.keyboards[lastID].markup.InlineKeyboard {
+		for _, button := range row {
+			if strings.Contains(button.Text, label) && button.CallbackData != nil {
+				f.nextID++
+				return tgbotapi.Update{CallbackQuery: &tgbotapi.CallbackQuery{
+					ID:      fmt.Sprintf("callback-%d", f.nextID),
+					From:    &tgbotapi.User{ID: userID},
+					Message: &tgbotapi.Message{MessageID: lastID, Chat: &tgbotapi.Chat{ID: userID}},
+					Data:    *button.CallbackData,
+				}}
+			}
+		}
+	}
+	panic(fmt.Sprintf("fake messenger: no %q button for %d", label, userID))
+}
+
+// dm builds a private message from a user. A leading /command is marked as a
+// bot command, as Telegram does.
+func dm(from int64, text string) tgbotapi.Update {
+	msg := &tgbotapi.Message{
+		From: &tgbotapi.User{ID: from, FirstName: fmt.Sprintf("User%d", from)},
+		Chat: &tgbotapi.Chat{ID: from, Type: "private"},
+		Text: text,
+	}
+	if strings.HasPrefix(text, "/") {
+		cmd, _, _ := strings.Cut(text, " ")
+		msg.Entities = []tgbotapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: len(cmd)}}
+	}
+	return tgbotapi.Update{Message: msg}
+}
+
+// botAdded builds the service message Telegram sends when the bot joins a group.
+func botAdded(chatID int64, title string, self int64) tgbotapi.Update {
+	return tgbotapi.Update{Message: &tgbotapi.Message{
+		From:           &tgbotapi.User{ID: 1},
+		Chat:           &tgbotapi.Chat{ID: chatID, Type: "supergroup", Title: title},
+		NewChatMembers: []tgbotapi.User{{ID: self, IsBot: true}},
+	}}
+}
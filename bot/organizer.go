@@ -0,0 +1,245 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"BookClubBot/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleCancelVote handles the admin /cancel_vote: the club's active round is
+// cancelled whatever its phase, an open poll is stopped, and the participants
+// and the group are told.
+func (b *Bot) handleCancelVote(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	chatID, err := b.clubFor(uid, nil)
+	if err != nil || chatID == 0 {
+		return err
+	}
+
+	b.mu.Lock()
+	session, err := b.sessionRepository.GetActiveSession(context.Background(), chatID)
+	if err != nil {
+		b.mu.Unlock()
+		return fmt.Errorf("cannot get active session: %w", err)
+	}
+	if session == nil {
+		b.mu.Unlock()
+		b.sendMessage(uid, b.messages.NothingToCancel)
+		return nil
+	}
+
+	// A poll left open would keep collecting votes for a round that no longer
+	// exists. Failing to stop it must not block the cancel, though.
+	if session.Status == models.StatusVoting && session.Voting != nil {
+		b.stopPolls(chatID, session.Voting)
+	}
+	if err := b.sessionRepository.SetStatus(context.Background(), session.ID, models.StatusCancelled); err != nil {
+		b.mu.Unlock()
+		return fmt.Errorf("failed to cancel session: %w", err)
+	}
+	b.mu.Unlock()
+	log.Printf("user %d cancelled session %s of club %d", uid, session.ID.Hex(), chatID)
+
+	for _, id := range roundParticipants(session) {
+		if id != uid {
+			b.sendMessage(id, b.messages.RoundCancelled)
+		}
+	}
+	b.sendMessage(uid, b.messages.RoundCancelledConfirm)
+	b.sendMessage(chatID, b.messages.RoundCancelledGroup)
+	return nil
+}
+
+// roundParticipants returns who is taking part in the round's current phase:
+// the members reading the book, or the gathering participants who did not skip.
+func roundParticipants(session *models.BookClubSession) []int64 {
+	var ids []int64
+	if session.Status == models.StatusReading && session.Reading != nil {
+		for _, m := range session.Reading.Members {
+			ids = append(ids, m.SubscriberID)
+		}
+		return ids
+	}
+	for _, p := range session.Gathering.Participants {
+		if p.Step != models.StepSkipped {
+			ids = append(ids, p.SubscriberID)
+		}
+	}
+	return ids
+}
+
+// handleExtend handles the admin /extend <duration>: the deadline of the running
+// gathering or poll moves forward by the duration, its reminder is rescheduled
+// to fire again, and the group is told the new deadline. The recovery loop reads
+// the deadlines from the session, so nothing else needs to be rescheduled.
+func (b *Bot) handleExtend(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	by, ok := parseDuration(update.Message.CommandArguments())
+	if !ok {
+		b.sendMessage(uid, b.messages.ExtendUsage)
+		return nil
+	}
+	chatID, err := b.clubFor(uid, nil)
+	if err != nil || chatID == 0 {
+		return err
+	}
+
+	b.mu.Lock()
+	session, err := b.sessionRepository.GetActiveSession(context.Background(), chatID)
+	if err != nil {
+		b.mu.Unlock()
+		return fmt.Errorf("cannot get active session: %w", err)
+	}
+
+	var deadline time.Time
+	var announcement string
+	switch {
+	case session != nil && session.Status == models.StatusGathering:
+		deadline = session.Gathering.Deadline.Add(by)
+		notifyAt := reminderAt(time.Now().UTC(), deadline, b.cfg.NotifyBeforeGathering)
+		err = b.sessionRepository.ExtendGathering(context.Background(), session.ID, deadline, notifyAt)
+		announcement = b.messages.GatheringExtended
+	case session != nil && session.Status == models.StatusVoting && session.Voting != nil && session.Voting.ClosedAt == nil:
+		deadline = session.Voting.Deadline.Add(by)
+		notifyAt := reminderAt(time.Now().UTC(), deadline, b.cfg.NotifyBeforePoll)
+		err = b.sessionRepository.ExtendVoting(context.Background(), session.ID, deadline, notifyAt)
+		announcement = b.messages.VotingExtended
+	default:
+		b.mu.Unlock()
+		b.sendMessage(uid, b.messages.NothingToExtend)
+		return nil
+	}
+	b.mu.Unlock()
+	if errors.Is(err, repository.ErrNotFound) {
+		// The round moved on before the new deadline was saved.
+		b.sendMessage(uid, b.messages.NothingToExtend)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to extend session: %w", err)
+	}
+	log.Printf("user %d extended %s of session %s by %s", uid, session.Status, session.ID.Hex(), by)
+
+	txt := fmt.Sprintf(announcement, deadline.Format("02.01.2006 15:04"))
+	if session.Status == models.StatusGathering {
+		for _, p := range session.Gathering.Participants {
+			if p.Step != models.StepDone && p.Step != models.StepSkipped && p.SubscriberID != uid {
+				b.sendMessage(p.SubscriberID, txt)
+			}
+		}
+	}
+	b.sendMessage(uid, txt)
+	b.sendMessage(chatID, txt)
+	return nil
+}
+
+// parseDuration parses a positive duration such as "3d", "12h" or "1h30m": a
+// whole number of days, or anything time.ParseDuration accepts.
+func parseDuration(arg string) (time.Duration, bool) {
+	arg = strings.TrimSpace(arg)
+	if days, ok := strings.CutSuffix(arg, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, false
+		}
+		return time.Duration(n) * 24 * time.Hour, true
+	}
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		return 0, false
+	}
+	return d, true
+}
+
+// roundOptions are the per-round overrides given to /start_vote. Zero values
+// fall back to the config and the default name.
+type roundOptions struct {
+	gather time.Duration
+	vote   time.Duration
+	name   string
+	theme  string
+}
+
+// quotePairs maps the opening quotes accepted around option values to their
+// closing quote; Telegram clients often replace straight quotes with typographic
+// ones.
+var quotePairs = map[rune]rune{'"': '"', '“': '”', '«': '»'}
+
+// parseRoundOptions parses /start_vote arguments such as
+// `gather=3d vote=2d name="Sci-fi spring" theme="sci-fi"`. Every option is
+// optional; an unknown key, a bad duration or an unterminated quote is an error.
+func parseRoundOptions(args string) (roundOptions, error) {
+	var opts roundOptions
+	rest := strings.TrimSpace(args)
+	for rest != "" {
+		key, after, ok := strings.Cut(rest, "=")
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return roundOptions{}, fmt.Errorf("expected key=value at %q", rest)
+		}
+
+		var value string
+		first, size := utf8.DecodeRuneInString(after)
+		if closing, quoted := quotePairs[first]; quoted {
+			end := strings.IndexRune(after[size:], closing)
+			if end < 0 {
+				return roundOptions{}, fmt.Errorf("unterminated quote in %s", key)
+			}
+			value = after[size : size+end]
+			after = after[size+end+utf8.RuneLen(closing):]
+		} else {
+			value, after, _ = strings.Cut(after, " ")
+		}
+		rest = strings.TrimSpace(after)
+
+		switch key {
+		case "gather", "vote":
+			d, ok := parseDuration(value)
+			if !ok {
+				return roundOptions{}, fmt.Errorf("bad %s duration %q", key, value)
+			}
+			if key == "gather" {
+				opts.gather = d
+			} else {
+				opts.vote = d
+			}
+		case "name":
+			opts.name = strings.TrimSpace(value)
+		case "theme":
+			opts.theme = strings.TrimSpace(value)
+		default:
+			return roundOptions{}, fmt.Errorf("unknown option %q", key)
+		}
+	}
+	return opts, nil
+}
+
+// settings returns the round's phase durations: the overrides where given and
+// the config defaults otherwise.
+func (o roundOptions) settings(cfg *config.AppConfig) models.RoundSettings {
+	s := models.RoundSettings{GatherSeconds: cfg.TimeToGatherBooks, VoteSeconds: cfg.TimeForTelegramPoll}
+	if o.gather > 0 {
+		s.GatherSeconds = int(o.gather / time.Second)
+	}
+	if o.vote > 0 {
+		s.VoteSeconds = int(o.vote / time.Second)
+	}
+	return s
+}
+
+// votingDuration is how long the session's poll stays open.
+func (b *Bot) votingDuration(session *models.BookClubSession) time.Duration {
+	if session.Settings.VoteSeconds > 0 {
+		return time.Duration(session.Settings.VoteSeconds) * time.Second
+	}
+	return time.Duration(b.cfg.TimeForTelegramPoll) * time.Second
+}
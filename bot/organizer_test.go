@@ -0,0 +1,265 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelVote(t *testing.T) {
+	t.Run("cancels an open poll", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600})
+		b.messages.RoundCancelled = "cancelled"
+		b.messages.RoundCancelledGroup = "group cancelled"
+
+		b.serve(fake.inject(dm(1, "/cancel_vote")))
+
+		assert.Equal(t, models.StatusCancelled, sessions.latest(testClubID).Status)
+		assert.True(t, fake.lastPoll().IsClosed, "the poll is stopped")
+		assert.Contains(t, fake.textsTo(2), "cancelled")
+		assert.NotContains(t, fake.textsTo(3), "cancelled", "member 3 skipped the round")
+		group := fake.textsTo(testClubID)
+		assert.Equal(t, "group cancelled", group[len(group)-1])
+
+		// The club can start a new round straight away.
+		b.serve(fake.inject(dm(1, "/start_vote")))
+		assert.Equal(t, models.StatusGathering, sessions.latest(testClubID).Status)
+	})
+
+	t.Run("cancels a gathering", func(t *testing.T) {
+		b, fake, sessions := clubWithMembers(t, &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600}, 1, 2)
+
+		b.serve(fake.inject(dm(1, "/start_vote"), dm(1, "/cancel_vote")))
+
+		assert.Equal(t, models.StatusCancelled, sessions.latest(testClubID).Status)
+		assert.Nil(t, fake.lastPoll())
+	})
+
+	t.Run("nothing to cancel", func(t *testing.T) {
+		b, fake, _ := clubWithMembers(t, &config.AppConfig{Admins: []int64{1}}, 1)
+		b.messages.NothingToCancel = "nothing"
+
+		b.serve(fake.inject(dm(1, "/cancel_vote")))
+
+		texts := fake.textsTo(1)
+		assert.Equal(t, "nothing", texts[len(texts)-1])
+	})
+
+	t.Run("a round cancelled while its poll is sent stays cancelled", func(t *testing.T) {
+		b, fake, sessions := clubWithMembers(t, &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, TimeForTelegramPoll: 3600}, 1, 2, 3)
+		updates := []tgbotapi.Update{dm(1, "/start_vote")}
+		updates = append(updates, submit(1, "Dune", "Herbert")...)
+		updates = append(updates, submit(2, "Solaris", "Lem")...)
+		b.serve(fake.inject(updates...))
+
+		// runTelegramPollFlow has claimed the voting status and is sending the
+		// poll when /cancel_vote comes in.
+		session := sessions.latest(testClubID)
+		require.NoError(t, sessions.SetStatus(context.Background(), session.ID, models.StatusVoting))
+		b.serve(fake.inject(dm(1, "/cancel_vote")))
+
+		assert.ErrorIs(t, b.runTelegramPoll(session), errRoundCancelled)
+		assert.Equal(t, models.StatusCancelled, sessions.latest(testClubID).Status)
+		assert.Nil(t, sessions.latest(testClubID).Voting)
+		assert.True(t, fake.lastPoll().IsClosed, "the poll just sent is stopped")
+	})
+
+	t.Run("members cannot cancel", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600})
+
+		b.serve(fake.inject(dm(2, "/cancel_vote")))
+
+		assert.Equal(t, models.StatusVoting, sessions.latest(testClubID).Status)
+		assert.False(t, fake.lastPoll().IsClosed)
+	})
+}
+
+func TestRoundParticipants(t *testing.T) {
+	gathering := sessionWith(
+		&models.Participant{SubscriberID: 1, Step: models.StepDone},
+		&models.Participant{SubscriberID: 2, Step: models.StepSkipped},
+		&models.Participant{SubscriberID: 3, Step: models.StepAuthor},
+	)
+	gathering.Status = models.StatusGathering
+	assert.Equal(t, []int64{1, 3}, roundParticipants(gathering))
+
+	gathering.Status = models.StatusReading
+	gathering.Reading = &models.Reading{Members: []*models.ReadingMember{{SubscriberID: 2}, {SubscriberID: 4}}}
+	assert.Equal(t, []int64{2, 4}, roundParticipants(gathering))
+}
+
+func TestExtend(t *testing.T) {
+	t.Run("gathering", func(t *testing.T) {
+		cfg := &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, NotifyBeforeGathering: 600}
+		b, fake, sessions := clubWithMembers(t, cfg, 1, 2)
+		b.messages.GatheringExtended = "until %s"
+		b.serve(fake.inject(dm(1, "/start_vote")))
+		before := sessions.latest(testClubID)
+		require.NoError(t, sessions.SetGatheringNotified(context.Background(), before.ID, time.Now()))
+
+		b.serve(fake.inject(dm(1, "/extend 2d")))
+
+		after := sessions.latest(testClubID)
+		assert.Equal(t, before.Gathering.Deadline.Add(48*time.Hour), after.Gathering.Deadline)
+		assert.Equal(t, after.Gathering.Deadline.Add(-10*time.Minute), after.Gathering.NotifyAt)
+		assert.Nil(t, after.Gathering.NotifiedAt, "the reminder fires again")
+
+		want := "until " + after.Gathering.Deadline.Format("02.01.2006 15:04")
+		assert.Contains(t, fake.textsTo(testClubID), want)
+		assert.Contains(t, fake.textsTo(2), want, "members still submitting are told")
+	})
+
+	t.Run("voting", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600, NotifyBeforePoll: 600})
+		before := sessions.latest(testClubID)
+
+		b.serve(fake.inject(dm(1, "/extend 90m")))
+
+		after := sessions.latest(testClubID)
+		assert.Equal(t, before.Voting.Deadline.Add(90*time.Minute), after.Voting.Deadline)
+		assert.Equal(t, after.Voting.Deadline.Add(-10*time.Minute), after.Voting.NotifyAt)
+
+		// The recovery loop honours the new deadline.
+		b.recoverVoting(after, before.Voting.Deadline.Add(time.Minute))
+		assert.Equal(t, models.StatusVoting, sessions.latest(testClubID).Status)
+	})
+
+	t.Run("bad duration", func(t *testing.T) {
+		b, fake, sessions := clubWithMembers(t, &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600}, 1)
+		b.messages.ExtendUsage = "usage"
+		b.serve(fake.inject(dm(1, "/start_vote")))
+		before := sessions.latest(testClubID)
+
+		b.serve(fake.inject(dm(1, "/extend soon")))
+
+		texts := fake.textsTo(1)
+		assert.Equal(t, "usage", texts[len(texts)-1])
+		assert.Equal(t, before.Gathering.Deadline, sessions.latest(testClubID).Gathering.Deadline)
+	})
+
+	t.Run("nothing to extend", func(t *testing.T) {
+		b, fake, _ := clubWithMembers(t, &config.AppConfig{Admins: []int64{1}}, 1)
+		b.messages.NothingToExtend = "nothing"
+
+		b.serve(fake.inject(dm(1, "/extend 1d")))
+
+		texts := fake.textsTo(1)
+		assert.Equal(t, "nothing", texts[len(texts)-1])
+	})
+}
+
+func TestParseDuration(t *testing.T) {
+	cases := []struct {
+		arg  string
+		want time.Duration
+		ok   bool
+	}{
+		{"3d", 72 * time.Hour, true},
+		{" 12h ", 12 * time.Hour, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"0d", 0, false},
+		{"-2h", 0, false},
+		{"1.5d", 0, false},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := parseDuration(c.arg)
+		assert.Equal(t, c.ok, ok, c.arg)
+		assert.Equal(t, c.want, got, c.arg)
+	}
+}
+
+func TestStartVoteOverrides(t *testing.T) {
+	t.Run("stored on the session", func(t *testing.T) {
+		cfg := &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, TimeForTelegramPoll: 3600}
+		b, fake, sessions := clubWithMembers(t, cfg, 1, 2)
+		b.messages.RoundTheme = "theme: %s"
+
+		b.serve(fake.inject(dm(1, `/start_vote gather=3d vote=2d name="Sci-fi spring" theme="sci-fi"`)))
+
+		session := sessions.latest(testClubID)
+		require.NotNil(t, session)
+		assert.Equal(t, "Sci-fi spring", session.Name)
+		assert.Equal(t, "sci-fi", session.Theme)
+		assert.Equal(t, models.RoundSettings{GatherSeconds: 3 * 86400, VoteSeconds: 2 * 86400}, session.Settings)
+		assert.Equal(t, 72*time.Hour, session.Gathering.Deadline.Sub(session.Gathering.Participants[0].InvitedAt))
+		assert.Contains(t, fake.textsTo(2), "theme: sci-fi")
+
+		updates := append(submit(1, "Dune", "Herbert"), submit(2, "Solaris", "Lem")...)
+		b.serve(fake.inject(updates...))
+
+		voting := sessions.latest(testClubID).Voting
+		require.NotNil(t, voting)
+		assert.Equal(t, 48*time.Hour, voting.Deadline.Sub(voting.StartedAt), "the poll uses the round's duration")
+	})
+
+	t.Run("defaults from config", func(t *testing.T) {
+		cfg := &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, TimeForTelegramPoll: 1800}
+		b, fake, sessions := clubWithMembers(t, cfg, 1)
+
+		b.serve(fake.inject(dm(1, "/start_vote")))
+
+		session := sessions.latest(testClubID)
+		require.NotNil(t, session)
+		assert.Equal(t, time.Now().UTC().Format("January 2006"), session.Name)
+		assert.Empty(t, session.Theme)
+		assert.Equal(t, models.RoundSettings{GatherSeconds: 3600, VoteSeconds: 1800}, session.Settings)
+	})
+
+	t.Run("a round shorter than the reminder lead", func(t *testing.T) {
+		cfg := &config.AppConfig{Admins: []int64{1}, TimeToGatherBooks: 3600, NotifyBeforeGathering: 12 * 3600}
+		b, fake, sessions := clubWithMembers(t, cfg, 1)
+		b.messages.BookSubmissionDeadline = "%.f h left"
+
+		b.serve(fake.inject(dm(1, "/start_vote gather=6h")))
+
+		session := sessions.latest(testClubID)
+		require.NotNil(t, session)
+		assert.Equal(t, session.Gathering.Deadline.Add(-3*time.Hour), session.Gathering.NotifyAt, "reminded halfway, not at once")
+
+		b.recoverGathering(session, session.Gathering.NotifyAt)
+		assert.Equal(t, "3 h left", lastText(fake, 1))
+	})
+
+	t.Run("bad arguments", func(t *testing.T) {
+		b, fake, sessions := clubWithMembers(t, &config.AppConfig{Admins: []int64{1}}, 1)
+		b.messages.StartVoteUsage = "usage"
+
+		b.serve(fake.inject(dm(1, "/start_vote gather=soon")))
+
+		assert.Nil(t, sessions.latest(testClubID))
+		texts := fake.textsTo(1)
+		assert.Equal(t, "usage", texts[len(texts)-1])
+	})
+}
+
+func TestParseRoundOptions(t *testing.T) {
+	cases := []struct {
+		args string
+		want roundOptions
+	}{
+		{"", roundOptions{}},
+		{"gather=3d vote=12h", roundOptions{gather: 72 * time.Hour, vote: 12 * time.Hour}},
+		{`name="Sci-fi spring" theme="sci-fi"`, roundOptions{name: "Sci-fi spring", theme: "sci-fi"}},
+		{"name=“Весна фантастики”  vote=1d", roundOptions{name: "Весна фантастики", vote: 24 * time.Hour}},
+		{"theme=«нон-фикшн»", roundOptions{theme: "нон-фикшн"}},
+		{"name=Spring", roundOptions{name: "Spring"}},
+	}
+	for _, c := range cases {
+		got, err := parseRoundOptions(c.args)
+		require.NoError(t, err, c.args)
+		assert.Equal(t, c.want, got, c.args)
+	}
+
+	for _, bad := range []string{"gather=soon", "vote=0d", "colour=red", "3d", `name="unterminated`} {
+		_, err := parseRoundOptions(bad)
+		assert.Error(t, err, bad)
+	}
+}
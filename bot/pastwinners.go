@@ -0,0 +1,74 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// defaultPastWinnerMonths is how long a past winner is blocked under
+// PastWinnerAfterMonths when past_winner_months is not set.
+const defaultPastWinnerMonths = 12
+
+// pastWinner returns the club's past winner that title likely is, matched as
+// duplicates are, with when the club read it; nil when the club has not read
+// it. The most recent reading counts.
+func (b *Bot) pastWinner(chatID int64, title string) (*models.Winner, time.Time) {
+	past, err := b.sessionRepository.ListPastWinners(context.Background(), chatID)
+	if err != nil {
+		log.Printf("cannot load past sessions to check %q: %v", title, err)
+		return nil, time.Time{}
+	}
+	threshold := b.duplicateThreshold()
+	for _, s := range past { // newest first
+		for i := range s.Winners {
+			if titleSimilarity(title, s.Winners[i].Title) < threshold {
+				continue
+			}
+			readAt := s.CreatedAt
+			if s.Reading != nil {
+				readAt = s.Reading.StartedAt
+			}
+			return &s.Winners[i], readAt
+		}
+	}
+	return nil, time.Time{}
+}
+
+// mayPropose applies the past_winner_policy to a book the participant wants to
+// propose: a book the club has read is refused (the default), proposed with a
+// warning, or refused only until past_winner_months have passed since it was
+// read. It reports whether the book may be proposed; a refusal has been
+// explained to the participant.
+func (b *Bot) mayPropose(session *models.BookClubSession, p *models.Participant, title string) bool {
+	if b.cfg.PastWinnerPolicy == models.PastWinnerAllow {
+		return true
+	}
+	winner, readAt := b.pastWinner(session.ChatID, title)
+	if winner == nil {
+		return true
+	}
+	read := readAt.Format("02.01.2006")
+
+	switch b.cfg.PastWinnerPolicy {
+	case models.PastWinnerWarn:
+		b.sendMessage(p.SubscriberID, fmt.Sprintf(b.messages.PastWinnerWarning, winner.Title, read))
+		return true
+	case models.PastWinnerAfterMonths:
+		months := b.cfg.PastWinnerMonths
+		if months <= 0 {
+			months = defaultPastWinnerMonths
+		}
+		allowedAt := readAt.AddDate(0, months, 0)
+		if !time.Now().Before(allowedAt) {
+			return true
+		}
+		b.sendMessage(p.SubscriberID, fmt.Sprintf(b.messages.PastWinnerTooSoon, winner.Title, read, allowedAt.Format("02.01.2006")))
+		return false
+	default:
+		b.sendMessage(p.SubscriberID, fmt.Sprintf(b.messages.PastWinnerRejected, winner.Title, read))
+		return false
+	}
+}
@@ -0,0 +1,78 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPastWinners(t *testing.T) {
+	// pastRound starts gathering in a club that read "Мастер и Маргарита" the
+	// given number of months ago.
+	pastRound := func(t *testing.T, policy string, monthsAgo int) (*Bot, *fakeMessenger, *memSessionRepo) {
+		t.Helper()
+		cfg := testConfig()
+		cfg.PastWinnerPolicy, cfg.PastWinnerMonths = policy, 6
+		b, fake, sessions := clubWithMembers(t, cfg, 1, 2)
+		readAt := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+		if monthsAgo > 0 {
+			readAt = time.Now().UTC().AddDate(0, -monthsAgo, 0)
+		}
+		past := &models.BookClubSession{
+			ChatID:  testClubID,
+			Status:  models.StatusCompleted,
+			Winners: []models.Winner{{SubscriberID: 2, Title: "Мастер и Маргарита", Author: "Булгаков"}},
+			Reading: &models.Reading{StartedAt: readAt},
+		}
+		require.NoError(t, sessions.CreateSession(context.Background(), past))
+		b.messages.WhoIsAuthor = "author?"
+		b.messages.BookUpdated = "updated"
+		b.messages.PastWinnerRejected = "read %s on %s"
+		b.messages.PastWinnerTooSoon = "read %s on %s, wait until %s"
+		b.messages.PastWinnerWarning = "note: read %s on %s"
+		b.serve(fake.inject(dm(1, "/start_vote")))
+		return b, fake, sessions
+	}
+	participant := func(sessions *memSessionRepo) *models.Participant {
+		return findParticipant(sessions.latest(testClubID), 1)
+	}
+
+	t.Run("a past winner is refused", func(t *testing.T) {
+		b, fake, sessions := pastRound(t, "", 0)
+		b.serve(fake.inject(dm(1, "The Master and Margarita")))
+		assert.Equal(t, "read Мастер и Маргарита on 01.03.2020", lastText(fake, 1))
+		assert.Equal(t, models.StepBook, participant(sessions).Step)
+		assert.Nil(t, participant(sessions).Book)
+
+		b.serve(fake.inject(dm(1, "Белая гвардия")))
+		assert.Equal(t, "author?", lastText(fake, 1))
+
+		b.serve(fake.inject(dm(1, "Булгаков"), dm(1, "About"), dm(1, "no cover"), dm(1, "/edit title Мастер и Маргарита")))
+		assert.Equal(t, "read Мастер и Маргарита on 01.03.2020", lastText(fake, 1))
+		assert.Equal(t, "Белая гвардия", participant(sessions).Book.Title)
+	})
+
+	t.Run("a warning lets the book through", func(t *testing.T) {
+		b, fake, sessions := pastRound(t, models.PastWinnerWarn, 0)
+		b.serve(fake.inject(dm(1, "мастер и маргарита")))
+		texts := fake.textsTo(1)
+		assert.Equal(t, []string{"note: read Мастер и Маргарита on 01.03.2020", "author?"}, texts[len(texts)-2:])
+		assert.Equal(t, models.StepAuthor, participant(sessions).Step)
+	})
+
+	t.Run("a past winner is refused only for the months configured", func(t *testing.T) {
+		b, fake, sessions := pastRound(t, models.PastWinnerAfterMonths, 2)
+		b.serve(fake.inject(dm(1, "Мастер и Маргарита")))
+		assert.Contains(t, lastText(fake, 1), "wait until")
+		assert.Equal(t, models.StepBook, participant(sessions).Step)
+
+		b, fake, sessions = pastRound(t, models.PastWinnerAfterMonths, 7)
+		b.serve(fake.inject(dm(1, "Мастер и Маргарита")))
+		assert.Equal(t, "author?", lastText(fake, 1))
+		assert.Equal(t, models.StepAuthor, participant(sessions).Step)
+	})
+}
@@ -26,6 +26,9 @@ type book struct {
 	author      string
 	description string
 	photoId     string
+	coverURL    string
+	pages       int
+	attributes  []string // answers to the questionnaire's own questions, "field: answer"
 }
 
 // viewParticipant converts a persisted participant into a render-only view.
@@ -42,23 +45,37 @@ func viewParticipant(p *models.Participant) *participant {
 			author:      p.Book.Author,
 			description: p.Book.Description,
 			photoId:     p.Book.PhotoID,
+			coverURL:    p.Book.CoverURL,
+			pages:       p.Book.Pages,
 		}
 	}
 	return vp
 }
 
 func (p *participant) bookCaption() string {
-	return fmt.Sprintf(
-		"📚 *Название*: %s\n👤 *Автор*: %s\n📝 *Описание*: %s",
+	pages := ""
+	if p.book.pages > 0 {
+		pages = fmt.Sprintf("\n📖 *Страниц*: %d", p.book.pages)
+	}
+	caption := fmt.Sprintf(
+		"📚 *Название*: %s\n👤 *Автор*: %s%s\n📝 *Описание*: %s",
 		p.book.title,
 		p.book.author,
+		pages,
 		p.book.description,
 	)
+	for _, a := range p.book.attributes {
+		caption += "\n🏷 " + a
+	}
+	return caption
 }
 
 func (p *participant) bookImage() tgbotapi.InputMediaPhoto {
 	if p.book.photoId != "" {
 		return tgbotapi.NewInputMediaPhoto(tgbotapi.FileID(p.book.photoId))
 	}
+	if p.book.coverURL != "" {
+		return tgbotapi.NewInputMediaPhoto(tgbotapi.FileURL(p.book.coverURL))
+	}
 	return tgbotapi.NewInputMediaPhoto(tgbotapi.FilePath(defaultImagePath))
 }
@@ -0,0 +1,260 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// skipAnswer is what a participant sends to leave an optional question
+// unanswered. An optional photo is skipped with any message but a photo.
+const skipAnswer = "-"
+
+// questionnaire returns the book submission questions, in order.
+func (b *Bot) questionnaire() []config.Question {
+	if len(b.cfg.Questionnaire) > 0 {
+		return b.cfg.Questionnaire
+	}
+	return config.DefaultQuestionnaire
+}
+
+// stepFor returns the participant step a question is asked in. The title and
+// cover keep the steps they had before the questionnaire was configurable, so
+// sessions in flight carry on.
+func stepFor(field string) string {
+	switch field {
+	case models.BookTitle:
+		return models.StepBook
+	case models.BookCover:
+		return models.StepImage
+	}
+	return field
+}
+
+// questionIndex returns the index of the question asked in step, or -1. A
+// lookup, a catalog match awaiting confirmation and a likely duplicate all
+// answer the title.
+func (b *Bot) questionIndex(step string) int {
+	if step == models.StepLookup || step == models.StepConfirm || step == models.StepDuplicate {
+		step = models.StepBook
+	}
+	for i, q := range b.questionnaire() {
+		if stepFor(q.Field) == step {
+			return i
+		}
+	}
+	return -1
+}
+
+// question returns the questionnaire question for a field.
+func (b *Bot) question(field string) (config.Question, bool) {
+	for _, q := range b.questionnaire() {
+		if q.Field == field {
+			return q, true
+		}
+	}
+	return config.Question{}, false
+}
+
+// askQuestion sends a question, with its choices and how to skip it when it is
+// optional.
+func (b *Bot) askQuestion(uid int64, q config.Question) {
+	text := b.messages.Get(q.Message)
+	if q.Type == config.QuestionChoice {
+		text += "\n\n" + strings.Join(q.Choices, " / ")
+	}
+	if !q.Required && q.Type != config.QuestionPhoto {
+		text += "\n\n" + b.messages.QuestionOptional
+	}
+	b.sendMessage(uid, text)
+}
+
+// askNextQuestion moves the participant to the next question after the one
+// they are at that their book has no answer to yet, or submits the book when
+// there is none. A book from the catalog may already have its author,
+// description or cover.
+func (b *Bot) askNextQuestion(session *models.BookClubSession, p *models.Participant) {
+	questions := b.questionnaire()
+	for _, q := range questions[b.questionIndex(p.Step)+1:] {
+		if bookField(p.Book, q.Field) == "" {
+			p.Step = stepFor(q.Field)
+			b.persistParticipant(session.ID, p)
+			b.askQuestion(p.SubscriberID, q)
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	p.Step = models.StepDone
+	p.SubmittedAt = &now
+	b.persistParticipant(session.ID, p)
+	if _, asked := b.question(models.BookCover); asked && bookField(p.Book, models.BookCover) == "" {
+		b.sendMessage(p.SubscriberID, b.messages.ImageMissingBookAdded)
+	} else {
+		b.sendMessage(p.SubscriberID, b.messages.BookAddedToNextVoting)
+	}
+	log.Printf("user: %s %s suggested a book.\n", p.FirstName, p.LastName)
+}
+
+// handleQuestionAnswer takes the answer to the question the participant is
+// at, past the title, and asks the next one.
+func (b *Bot) handleQuestionAnswer(session *models.BookClubSession, p *models.Participant, msg *tgbotapi.Message) {
+	i := b.questionIndex(p.Step)
+	if i < 0 || p.Book == nil {
+		// A step the questionnaire no longer has: go on from the start.
+		if p.Book != nil {
+			b.askNextQuestion(session, p)
+		}
+		return
+	}
+	q := b.questionnaire()[i]
+	value, ok := b.readAnswer(p.SubscriberID, q, msg.Text, msg.Photo)
+	if !ok {
+		return
+	}
+	setBookField(p.Book, q.Field, value)
+	b.askNextQuestion(session, p)
+}
+
+// readAnswer validates an answer to q and returns the value to store: the text
+// (a choice as configured, a number normalized), or a photo's file ID. An
+// empty value skips an optional question. When the answer is invalid the
+// participant is told why and ok is false.
+func (b *Bot) readAnswer(uid int64, q config.Question, text string, photos []tgbotapi.PhotoSize) (value string, ok bool) {
+	text = strings.TrimSpace(text)
+	if q.Type == config.QuestionPhoto {
+		if len(photos) > 0 {
+			return photos[len(photos)-1].FileID, true
+		}
+		if q.Required {
+			b.sendMessage(uid, b.messages.AnswerNotPhoto)
+			return "", false
+		}
+		return "", true
+	}
+
+	switch {
+	case text == "":
+		b.sendMessage(uid, b.messages.AnswerInvalid)
+		return "", false
+	case text == skipAnswer && q.Required:
+		b.sendMessage(uid, b.messages.AnswerRequired)
+		return "", false
+	case text == skipAnswer:
+		return "", true
+	}
+
+	switch q.Type {
+	case config.QuestionNumber:
+		n, err := strconv.Atoi(strings.ReplaceAll(text, " ", ""))
+		if err != nil {
+			b.sendMessage(uid, b.messages.AnswerNotNumber)
+			return "", false
+		}
+		min, max, _ := q.Range()
+		if min != nil && n < *min || max != nil && n > *max {
+			b.sendMessage(uid, b.messages.AnswerInvalid)
+			return "", false
+		}
+		return strconv.Itoa(n), true
+	case config.QuestionChoice:
+		for _, c := range q.Choices {
+			if strings.EqualFold(c, text) {
+				return c, true
+			}
+		}
+		b.sendMessage(uid, fmt.Sprintf(b.messages.AnswerNotChoice, strings.Join(q.Choices, " / ")))
+		return "", false
+	case config.QuestionURL:
+		u, err := url.ParseRequestURI(text)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			b.sendMessage(uid, b.messages.AnswerNotURL)
+			return "", false
+		}
+	}
+	if q.Validation != "" {
+		if matched, _ := regexp.MatchString(q.Validation, text); !matched {
+			b.sendMessage(uid, b.messages.AnswerInvalid)
+			return "", false
+		}
+	}
+	return text, true
+}
+
+// bookField returns the answer a book holds for a questionnaire field, empty
+// when there is none.
+func bookField(bk *models.Book, field string) string {
+	switch field {
+	case models.BookTitle:
+		return bk.Title
+	case models.BookAuthor:
+		return bk.Author
+	case models.BookDescription:
+		return bk.Description
+	case models.BookCover:
+		if bk.PhotoID != "" {
+			return bk.PhotoID
+		}
+		return bk.CoverURL
+	case models.BookPages:
+		if bk.Pages > 0 {
+			return strconv.Itoa(bk.Pages)
+		}
+		return ""
+	}
+	return bk.Attributes[field]
+}
+
+// setBookField stores the answer to a questionnaire field; an empty value
+// clears it.
+func setBookField(bk *models.Book, field, value string) {
+	switch field {
+	case models.BookTitle:
+		bk.Title = value
+	case models.BookAuthor:
+		bk.Author = value
+	case models.BookDescription:
+		bk.Description = value
+	case models.BookCover:
+		bk.PhotoID = value
+	case models.BookPages:
+		bk.Pages, _ = strconv.Atoi(value)
+	default:
+		if value == "" {
+			delete(bk.Attributes, field)
+			return
+		}
+		if bk.Attributes == nil {
+			bk.Attributes = make(map[string]string)
+		}
+		bk.Attributes[field] = value
+	}
+}
+
+// bookAttributes lists a book's answers to the questionnaire's own text
+// questions, in questionnaire order, as "field: answer".
+func (b *Bot) bookAttributes(bk *models.Book) []string {
+	var lines []string
+	for _, q := range b.questionnaire() {
+		if _, own := bookFields[q.Field]; own || q.Type == config.QuestionPhoto {
+			continue
+		}
+		if v := bk.Attributes[q.Field]; v != "" {
+			lines = append(lines, fmt.Sprintf("%s: %s", q.Field, v))
+		}
+	}
+	return lines
+}
+
+// bookFields are the questionnaire fields models.Book has fields for.
+var bookFields = map[string]struct{}{
+	models.BookTitle: {}, models.BookAuthor: {}, models.BookDescription: {}, models.BookCover: {}, models.BookPages: {},
+}
@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clubQuestionnaire asks for the page count, a genre, a link and why the book
+// is proposed on top of the title and author, and takes no cover.
+var clubQuestionnaire = []config.Question{
+	{Field: models.BookTitle, Type: config.QuestionText, Required: true, Message: "ask_book_title"},
+	{Field: models.BookAuthor, Type: config.QuestionText, Required: true, Message: "who_is_author"},
+	{Field: models.BookPages, Type: config.QuestionNumber, Required: true, Validation: "1..5000", Message: "ask_page_count"},
+	{Field: "genre", Type: config.QuestionChoice, Choices: []string{"Fiction", "Non-fiction"}, Message: "ask_genre"},
+	{Field: "link", Type: config.QuestionURL, Validation: `goodreads\.com`, Message: "ask_link"},
+	{Field: "why", Type: config.QuestionText, Required: true, Validation: `\S{3,}`, Message: "ask_why"},
+}
+
+func TestQuestionnaire(t *testing.T) {
+	cfg := testConfig()
+	cfg.Questionnaire = clubQuestionnaire
+	b, fake, sessions := clubWithMembers(t, cfg, 1, 2)
+	b.messages.WhoIsAuthor = "author?"
+	b.messages.QuestionOptional = "(optional)"
+	b.messages.AnswerRequired = "required"
+	b.messages.AnswerInvalid = "invalid"
+	b.messages.AnswerNotNumber = "not a number"
+	b.messages.AnswerNotURL = "not a url"
+	b.messages.AnswerNotChoice = "pick %s"
+	b.messages.BookAddedToNextVoting = "added"
+	b.messages.Questions = map[string]string{
+		"ask_page_count": "pages?",
+		"ask_genre":      "genre?",
+		"ask_link":       "link?",
+		"ask_why":        "why?",
+	}
+	b.serve(fake.inject(dm(1, "/start_vote")))
+	participant := func() *models.Participant { return findParticipant(sessions.latest(testClubID), 1) }
+
+	answers := []struct{ answer, reply string }{
+		{"Dune", "author?"},
+		{"Frank Herbert", "pages?"},
+		{"many", "not a number"},
+		{"0", "invalid"},
+		{"-", "required"},
+		{"6 04", "genre?\n\nFiction / Non-fiction\n\n(optional)"},
+		{"poetry", "pick Fiction / Non-fiction"},
+		{"fiction", "link?\n\n(optional)"},
+		{"goodreads.com/book/show/1", "not a url"},
+		{"https://example.com/dune", "invalid"},
+		{"-", "why?"},
+		{"ok", "invalid"},
+		{"Because of the spice.", "added"},
+	}
+	for _, a := range answers {
+		b.serve(fake.inject(dm(1, a.answer)))
+		require.Equal(t, a.reply, lastText(fake, 1), "answering %q", a.answer)
+	}
+
+	p := participant()
+	assert.Equal(t, models.StepDone, p.Step)
+	assert.Equal(t, &models.Book{
+		Title:      "Dune",
+		Author:     "Frank Herbert",
+		Pages:      604,
+		Attributes: map[string]string{"genre": "Fiction", "why": "Because of the spice."},
+	}, p.Book)
+
+	// The answers are edited like the built-in fields, and shown with the book.
+	b.messages.BookUpdated = "updated"
+	b.messages.MyBook = "%s|%s|%s|%s|%s"
+	b.serve(fake.inject(dm(1, "/edit genre Non-Fiction"), dm(1, "/edit pages lots")))
+	assert.Equal(t, "not a number", lastText(fake, 1))
+	b.serve(fake.inject(dm(1, "/my_book")))
+	assert.Equal(t, "Dune|Frank Herbert|604|—|—\ngenre: Non-fiction\nwhy: Because of the spice.", lastText(fake, 1))
+
+	// /back steps through the configured questions.
+	b.serve(fake.inject(dm(2, "Solaris"), dm(2, "Lem"), dm(2, "300"), dm(2, "/back")))
+	assert.Equal(t, models.BookPages, findParticipant(sessions.latest(testClubID), 2).Step)
+	assert.Equal(t, "pages?", lastText(fake, 2))
+
+	// The gathered books carry their answers to the group.
+	b.serve(fake.inject(dm(2, "288"), dm(2, "-"), dm(2, "-"), dm(2, "Ocean!")))
+	require.Equal(t, models.StatusVoting, sessions.latest(testClubID).Status)
+	require.NotEmpty(t, fake.media)
+	var captions []string
+	for _, m := range fake.media[0].Media {
+		captions = append(captions, m.(tgbotapi.InputMediaPhoto).Caption)
+	}
+	assert.Contains(t, strings.Join(captions, "\n"), "🏷 genre: Non-fiction\n🏷 why: Because of the spice.")
+	assert.Contains(t, strings.Join(captions, "\n"), "🏷 why: Ocean!")
+}
@@ -0,0 +1,311 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// rankPrefix starts the callback data of ranked ballot buttons:
+// "rank:<session ID>:<candidate index|done|reset>".
+const rankPrefix = "rank"
+
+// startRankedVoting sets up a ranked-choice vote instead of a poll: every
+// eligible member gets a ballot listing the books, ranks them by tapping them
+// in order of preference, and confirms. The ballots are tallied by instant
+// runoff when the vote closes (see tallyRanked). There is no option cap, so
+// every book stands. Nothing is sent until the vote is saved (see sendBallots).
+func (b *Bot) startRankedVoting(session *models.BookClubSession, voting *models.Voting, subs []*models.Subscriber) {
+	voting.Mode = models.VotingRanked
+	for _, p := range shuffleSlice(session.Gathering.Participants) {
+		if p.Step == models.StepDone && p.Book != nil {
+			voting.Candidates = append(voting.Candidates, models.Candidate{
+				SubscriberID: p.SubscriberID,
+				Title:        p.Book.Title,
+				Author:       p.Book.Author,
+			})
+		}
+	}
+	b.scheduleStage(voting, b.votingDuration(session))
+	for _, sub := range subs {
+		if eligible(voting, sub.ID) {
+			voting.Ballots = append(voting.Ballots, &models.Ballot{VoterID: sub.ID})
+		}
+	}
+}
+
+// sendBallots announces a saved ranked vote and DMs every member their ballot,
+// recording the message it is redrawn in. A ballot that cannot be sent keeps
+// MessageID 0 and its member cannot vote. Each send runs under b.mu, so a tap
+// on the ballot is handled only once its message is recorded.
+func (b *Bot) sendBallots(session *models.BookClubSession, voting *models.Voting) {
+	hours := voting.Deadline.Sub(voting.StartedAt).Hours()
+	b.sendMessage(session.ChatID, fmt.Sprintf(b.messages.RankedVotingStarted, hours))
+
+	for _, ballot := range voting.Ballots {
+		msg := tgbotapi.NewMessage(ballot.VoterID, b.ballotText(voting, ballot))
+		msg.ReplyMarkup = b.ballotKeyboard(session, voting, ballot)
+		b.mu.Lock()
+		sent, err := b.tgBot.Send(msg)
+		if err != nil {
+			b.mu.Unlock()
+			log.Printf("cannot send a ballot to %d: %v", ballot.VoterID, err)
+			continue
+		}
+		ballot.MessageID = sent.MessageID
+		if err := b.sessionRepository.UpdateBallot(context.Background(), session.ID, ballot); err != nil {
+			log.Printf("cannot save the ballot message of %d: %v", ballot.VoterID, err)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// handleCallback routes a tap on an inline button: on a ranked ballot, or
+// under a catalog match or a likely duplicate of a proposed book.
+func (b *Bot) handleCallback(q *tgbotapi.CallbackQuery) {
+	parts := strings.Split(q.Data, ":")
+	if len(parts) != 3 {
+		b.answerCallback(q.ID, "")
+		return
+	}
+	switch parts[0] {
+	case rankPrefix:
+		b.handleRankCallback(q, parts[1], parts[2])
+	case bookPrefix:
+		b.handleBookCallback(q, parts[1], parts[2])
+	case duplicatePrefix:
+		b.handleDuplicateCallback(q, parts[1], parts[2])
+	default:
+		b.answerCallback(q.ID, "")
+	}
+}
+
+// handleRankCallback handles a tap on a ballot button: a book is appended to
+// the member's ranking, the ranking is reset, or it is confirmed. The ballot is
+// persisted on every tap and its DM redrawn. Once every member has confirmed,
+// the vote closes early, as a poll does.
+func (b *Bot) handleRankCallback(q *tgbotapi.CallbackQuery, sessionID, action string) {
+	uid := q.From.ID
+	b.mu.Lock()
+	session, ballot, notice := b.ballotFor(uid, sessionID)
+	if ballot == nil {
+		b.mu.Unlock()
+		b.answerCallback(q.ID, notice)
+		return
+	}
+
+	now := time.Now().UTC()
+	switch action {
+	case "reset":
+		ballot.Ranking = nil
+	case "done":
+		if len(ballot.Ranking) == 0 {
+			b.mu.Unlock()
+			b.answerCallback(q.ID, b.messages.RankedBallotEmpty)
+			return
+		}
+		ballot.SubmittedAt = &now
+	default:
+		i, err := strconv.Atoi(action)
+		if err != nil || i < 0 || i >= len(session.Voting.Candidates) || ranked(ballot, i) {
+			b.mu.Unlock()
+			b.answerCallback(q.ID, "")
+			return
+		}
+		ballot.Ranking = append(ballot.Ranking, i)
+	}
+
+	if err := b.sessionRepository.UpdateBallot(context.Background(), session.ID, ballot); err != nil {
+		b.mu.Unlock()
+		log.Printf("cannot save the ballot of %d: %v", uid, err)
+		b.answerCallback(q.ID, b.messages.SomethingWrong)
+		return
+	}
+	submitted := ballot.SubmittedAt != nil
+	if submitted {
+		if err := b.sessionRepository.AddVoter(context.Background(), session.ID, uid); err != nil {
+			log.Printf("cannot record voter: %v", err)
+		}
+	}
+	b.mu.Unlock()
+
+	var edit tgbotapi.EditMessageTextConfig
+	if submitted {
+		edit = tgbotapi.NewEditMessageText(uid, ballot.MessageID, b.ballotText(session.Voting, ballot))
+	} else {
+		edit = tgbotapi.NewEditMessageTextAndMarkup(uid, ballot.MessageID, b.ballotText(session.Voting, ballot),
+			b.ballotKeyboard(session, session.Voting, ballot))
+	}
+	if _, err := b.tgBot.Send(edit); err != nil {
+		log.Printf("cannot redraw the ballot of %d: %v", uid, err)
+	}
+	b.answerCallback(q.ID, "")
+
+	if !submitted {
+		return
+	}
+	updated, err := b.sessionRepository.GetActiveSession(context.Background(), session.ChatID)
+	if err == nil && updated != nil && updated.Voting != nil && allVoted(updated.Voting) {
+		b.closeTelegramPoll(session.ChatID)
+	}
+}
+
+// ballotFor finds uid's open ballot in the ranked vote of the given session.
+// When there is none it returns the notice to show the member instead.
+func (b *Bot) ballotFor(uid int64, sessionID string) (*models.BookClubSession, *models.Ballot, string) {
+	sessions, err := b.sessionRepository.GetActiveSessions(context.Background())
+	if err != nil {
+		log.Printf("cannot get active sessions for a ballot: %v", err)
+		return nil, nil, b.messages.SomethingWrong
+	}
+	for _, s := range sessions {
+		if s.ID.Hex() != sessionID {
+			continue
+		}
+		if s.Status != models.StatusVoting || s.Voting == nil || s.Voting.Mode != models.VotingRanked {
+			break
+		}
+		for _, ballot := range s.Voting.Ballots {
+			if ballot.VoterID != uid {
+				continue
+			}
+			if ballot.SubmittedAt != nil {
+				return nil, nil, b.messages.RankedBallotSubmitted
+			}
+			return s, ballot, ""
+		}
+		return nil, nil, b.messages.NotParticipantCurrentVoting
+	}
+	return nil, nil, b.messages.RankedBallotClosed
+}
+
+// ballotText renders a ballot: the instructions, or the confirmation once it
+// is submitted, followed by the ranking so far.
+func (b *Bot) ballotText(voting *models.Voting, ballot *models.Ballot) string {
+	var sb strings.Builder
+	if ballot.SubmittedAt != nil {
+		sb.WriteString(b.messages.RankedBallotSubmitted)
+	} else {
+		sb.WriteString(b.messages.RankedBallot)
+	}
+	for place, i := range ballot.Ranking {
+		c := voting.Candidates[i]
+		fmt.Fprintf(&sb, "\n%d. %s — %s", place+1, c.Title, c.Author)
+	}
+	return sb.String()
+}
+
+// ballotKeyboard lists the books not ranked yet, one per row, followed by the
+// confirm and reset buttons once something is ranked.
+func (b *Bot) ballotKeyboard(session *models.BookClubSession, voting *models.Voting, ballot *models.Ballot) tgbotapi.InlineKeyboardMarkup {
+	data := func(action string) string {
+		return fmt.Sprintf("%s:%s:%s", rankPrefix, session.ID.Hex(), action)
+	}
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, c := range voting.Candidates {
+		if ranked(ballot, i) {
+			continue
+		}
+		label := truncateString(fmt.Sprintf("%s — %s", c.Title, c.Author), 60)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, data(strconv.Itoa(i)))))
+	}
+	if len(ballot.Ranking) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(b.messages.RankedDone, data("done")),
+			tgbotapi.NewInlineKeyboardButtonData(b.messages.RankedReset, data("reset")),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// answerCallback acknowledges a button tap, optionally with a short notice.
+func (b *Bot) answerCallback(id, text string) {
+	if _, err := b.tgBot.Request(tgbotapi.NewCallback(id, text)); err != nil {
+		log.Printf("cannot answer a callback: %v", err)
+	}
+}
+
+// ranked reports whether the ballot already ranks candidate i.
+func ranked(ballot *models.Ballot, i int) bool {
+	for _, r := range ballot.Ranking {
+		if r == i {
+			return true
+		}
+	}
+	return false
+}
+
+// tallyRanked resolves a ranked vote into its winners, in the same shape as a
+// poll's. Only submitted ballots count: a ranking left unconfirmed may be one
+// the member was still changing.
+func tallyRanked(v *models.Voting) []models.Winner {
+	var rankings [][]int
+	for _, ballot := range v.Ballots {
+		if ballot.SubmittedAt != nil && len(ballot.Ranking) > 0 {
+			rankings = append(rankings, ballot.Ranking)
+		}
+	}
+	var winners []models.Winner
+	for _, i := range instantRunoff(len(v.Candidates), rankings) {
+		c := v.Candidates[i]
+		winners = append(winners, models.Winner{SubscriberID: c.SubscriberID, Title: c.Title, Author: c.Author})
+	}
+	return winners
+}
+
+// instantRunoff tallies rankings over n candidates. Each round every ballot
+// counts for its highest-ranked candidate still standing; a candidate with a
+// majority of those votes wins, otherwise the candidates with the fewest votes
+// are eliminated. It returns the winner, the candidates still level when no
+// one can be eliminated (a tie), or nothing when there are no votes.
+func instantRunoff(n int, rankings [][]int) []int {
+	eliminated := make([]bool, n)
+	for {
+		counts := make([]int, n)
+		total := 0
+		for _, r := range rankings {
+			for _, c := range r {
+				if c >= 0 && c < n && !eliminated[c] {
+					counts[c]++
+					total++
+					break
+				}
+			}
+		}
+		if total == 0 {
+			return nil
+		}
+
+		var standing []int
+		fewest := total
+		for c := range n {
+			if eliminated[c] {
+				continue
+			}
+			if 2*counts[c] > total {
+				return []int{c}
+			}
+			standing = append(standing, c)
+			fewest = min(fewest, counts[c])
+		}
+
+		var last []int
+		for _, c := range standing {
+			if counts[c] == fewest {
+				last = append(last, c)
+			}
+		}
+		if len(last) == len(standing) {
+			return standing
+		}
+		for _, c := range last {
+			eliminated[c] = true
+		}
+	}
+}
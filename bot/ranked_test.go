@@ -0,0 +1,146 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRankedVoting(t *testing.T) {
+	t.Run("ballots are tallied by instant runoff", func(t *testing.T) {
+		b, fake, sessions := rankedRound(t)
+
+		session := sessions.latest(testClubID)
+		assert.Equal(t, models.VotingRanked, session.Voting.Mode)
+		assert.Zero(t, session.Voting.TelegramPollID, "no poll is sent")
+		assert.Empty(t, fake.polls)
+		assert.Len(t, session.Voting.Candidates, 3)
+		require.Len(t, session.Voting.Ballots, 5)
+		for uid := int64(1); uid <= 5; uid++ {
+			assert.Contains(t, fake.textsTo(uid), "ballot", "every member gets a ballot")
+		}
+
+		rank(b, fake, 1, "Dune", "done")
+		rank(b, fake, 2, "Dune", "done")
+		rank(b, fake, 3, "Solaris", "done")
+		rank(b, fake, 4, "Solaris")
+		late := fake.tap(4, "Emma")
+		rank(b, fake, 4, "done")
+
+		session = sessions.latest(testClubID)
+		require.Equal(t, models.StatusVoting, session.Status)
+		ballot := session.Voting.Ballots[3]
+		assert.NotNil(t, ballot.SubmittedAt)
+		assert.Equal(t, "Solaris", session.Voting.Candidates[ballot.Ranking[0]].Title)
+		assert.Equal(t, []int64{1, 2, 3, 4}, session.Voting.VoterIDs)
+		assert.Contains(t, fake.edits[ballot.MessageID], "submitted\n1. Solaris — Lem")
+
+		// A submitted ballot cannot be changed.
+		b.serve(fake.inject(late))
+		assert.Equal(t, "submitted", fake.callbacks[len(fake.callbacks)-1].Text)
+		assert.Len(t, sessions.latest(testClubID).Voting.Ballots[3].Ranking, 1)
+
+		// Member 5 changes their mind, then puts Emma first. Emma has the fewest
+		// first choices and is eliminated, so the ballot goes to Solaris, which
+		// wins where a plurality vote would have tied.
+		rank(b, fake, 5, "Dune", "reset", "Emma", "Solaris", "done")
+
+		session = sessions.latest(testClubID)
+		assert.Equal(t, models.StatusReading, session.Status, "the vote closes once everyone has submitted")
+		require.Len(t, session.Winners, 1)
+		assert.Equal(t, "Solaris", session.Winners[0].Title)
+		assert.Equal(t, int64(2), session.Winners[0].SubscriberID)
+		assert.Equal(t, []string{"Emma", "Solaris"}, rankedTitles(session.Voting, session.Voting.Ballots[4]))
+	})
+
+	t.Run("only submitted ballots count at the deadline", func(t *testing.T) {
+		b, fake, sessions := rankedRound(t)
+		rank(b, fake, 1, "Emma")
+		rank(b, fake, 2, "Emma", "Dune")
+		rank(b, fake, 3, "Dune", "done")
+
+		session := sessions.latest(testClubID)
+		b.recoverVoting(session, session.Voting.Deadline)
+
+		session = sessions.latest(testClubID)
+		assert.Equal(t, models.StatusReading, session.Status)
+		require.Len(t, session.Winners, 1)
+		assert.Equal(t, "Dune", session.Winners[0].Title)
+
+		// The remaining ballots are closed.
+		b.serve(fake.inject(fake.tap(4, "Dune")))
+		assert.Equal(t, "closed", fake.callbacks[len(fake.callbacks)-1].Text)
+	})
+
+	t.Run("a ballot that cannot be sent is not recorded as sent", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.VotingMode = models.VotingRanked
+		b, fake, sessions := clubWithMembers(t, cfg, 1, 2, 3)
+		b.messages.RankedBallot = "ballot"
+		updates := []tgbotapi.Update{dm(1, "/start_vote")}
+		updates = append(updates, submit(1, "Dune", "Herbert")...)
+		updates = append(updates, submit(2, "Solaris", "Lem")...)
+		b.serve(fake.inject(updates...))
+		fake.unreachable = []int64{3}
+		b.serve(fake.inject(dm(3, "/skip")))
+
+		session := sessions.latest(testClubID)
+		require.Equal(t, models.StatusVoting, session.Status)
+		require.Len(t, session.Voting.Ballots, 3)
+		for _, ballot := range session.Voting.Ballots {
+			if ballot.VoterID == 3 {
+				assert.Zero(t, ballot.MessageID)
+			} else {
+				assert.NotZero(t, ballot.MessageID, "the ballot of %d is saved with its message", ballot.VoterID)
+			}
+		}
+	})
+
+	t.Run("cancel closes the ballots", func(t *testing.T) {
+		b, fake, sessions := rankedRound(t)
+		tap := fake.tap(2, "Dune")
+
+		b.serve(fake.inject(dm(1, "/cancel_vote")))
+		require.Equal(t, models.StatusCancelled, sessions.latest(testClubID).Status)
+
+		b.serve(fake.inject(tap))
+		assert.Equal(t, "closed", fake.callbacks[len(fake.callbacks)-1].Text)
+	})
+}
+
+// rankedTitles lists the titles on a ballot in the order they were ranked.
+func rankedTitles(v *models.Voting, ballot *models.Ballot) []string {
+	var titles []string
+	for _, i := range ballot.Ranking {
+		titles = append(titles, v.Candidates[i].Title)
+	}
+	return titles
+}
+
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		n        int
+		rankings [][]int
+		want     []int
+	}{
+		{name: "no ballots", n: 3},
+		{name: "majority of first choices", n: 3, rankings: [][]int{{0}, {0, 1}, {1}}, want: []int{0}},
+		{name: "the last is eliminated and transfers", n: 3,
+			rankings: [][]int{{0}, {0}, {1}, {1}, {2, 1}}, want: []int{1}},
+		{name: "every last-placed candidate is eliminated", n: 4,
+			rankings: [][]int{{0}, {0}, {1, 0}, {2, 0}, {3}}, want: []int{0}},
+		{name: "exhausted ballots drop out of the majority", n: 3,
+			rankings: [][]int{{0}, {1}, {1}, {2}, {2}}, want: []int{1, 2}},
+		{name: "a full tie stands", n: 2, rankings: [][]int{{0, 1}, {1, 0}}, want: []int{0, 1}},
+		{name: "unranked candidates are eliminated first", n: 3, rankings: [][]int{{0, 1}, {1, 0}}, want: []int{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, instantRunoff(tt.n, tt.rankings))
+		})
+	}
+}
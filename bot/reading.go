@@ -0,0 +1,416 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// minRating and maxRating bound the score a reading member can give the book.
+const (
+	minRating = 1
+	maxRating = 5
+)
+
+// handleFinished handles /finished: the member is marked as finished and asked
+// to rate the book, which starts the rating → review conversation.
+func (b *Bot) handleFinished(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	session, m, err := b.readingMemberFor(uid)
+	if err != nil || m == nil {
+		return err
+	}
+
+	if m.Status == models.ReadingFinished {
+		b.sendMessage(uid, b.messages.AlreadyFinishedReading)
+		return nil
+	}
+
+	now := time.Now().UTC()
+	m.Status = models.ReadingFinished
+	m.FinishedAt = &now
+	m.Step = models.ReviewStepRating
+	b.persistReadingMember(session.ID, m)
+	b.sendMessage(uid, b.messages.RateTheBook)
+	log.Printf("user: %d finished reading.\n", uid)
+	return nil
+}
+
+// handleAbandon handles /abandon: the member stops reading the book. An
+// abandoned member can still send /finished later if they change their mind.
+func (b *Bot) handleAbandon(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	session, m, err := b.readingMemberFor(uid)
+	if err != nil || m == nil {
+		return err
+	}
+
+	switch m.Status {
+	case models.ReadingFinished:
+		b.sendMessage(uid, b.messages.AlreadyFinishedReading)
+		return nil
+	case models.ReadingAbandoned:
+		b.sendMessage(uid, b.messages.AlreadyAbandonedReading)
+		return nil
+	}
+
+	m.Status = models.ReadingAbandoned
+	m.Step = ""
+	b.persistReadingMember(session.ID, m)
+	b.sendMessage(uid, b.messages.AbandonedReading)
+	log.Printf("user: %d abandoned reading.\n", uid)
+
+	// m points into session.Reading.Members, so session already reflects it.
+	if allMembersDone(session) {
+		b.finishReading(session.ChatID)
+	}
+	return nil
+}
+
+// handleRate handles /rate: a finished member is asked for a (new) rating.
+func (b *Bot) handleRate(update *tgbotapi.Update) error {
+	return b.restartReviewStep(update.Message.From.ID, models.ReviewStepRating, b.messages.RateTheBook)
+}
+
+// handleReview handles /review: a finished member is asked for a (new) review.
+func (b *Bot) handleReview(update *tgbotapi.Update) error {
+	return b.restartReviewStep(update.Message.From.ID, models.ReviewStepReview, b.messages.WriteBookReview)
+}
+
+// restartReviewStep moves a finished member back to one review question.
+func (b *Bot) restartReviewStep(uid int64, step, question string) error {
+	session, m, err := b.readingMemberFor(uid)
+	if err != nil || m == nil {
+		return err
+	}
+
+	if m.Status != models.ReadingFinished {
+		b.sendMessage(uid, b.messages.FinishReadingFirst)
+		return nil
+	}
+
+	m.Step = step
+	b.persistReadingMember(session.ID, m)
+	b.sendMessage(uid, question)
+	return nil
+}
+
+// handleProgress handles /progress <percent|page>: a member still reading
+// records how far they are, e.g. "/progress 40%" or "/progress 120" (a page).
+func (b *Bot) handleProgress(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	session, m, err := b.readingMemberFor(uid)
+	if err != nil || m == nil {
+		return err
+	}
+
+	if m.Status != models.ReadingInProgress {
+		b.sendMessage(uid, b.messages.ProgressOnlyWhileReading)
+		return nil
+	}
+
+	entry, ok := parseProgress(update.Message.CommandArguments())
+	if !ok {
+		b.sendMessage(uid, b.messages.ProgressUsage)
+		return nil
+	}
+	entry.At = time.Now().UTC()
+	m.Progress = append(m.Progress, entry)
+	b.persistReadingMember(session.ID, m)
+	b.sendMessage(uid, b.messages.ProgressSaved)
+	return nil
+}
+
+// readingMemberFor loads the reading session of the user's club and the member
+// for uid. When there is no reading in progress or uid is not a member, it
+// tells the user and returns a nil member.
+func (b *Bot) readingMemberFor(uid int64) (*models.BookClubSession, *models.ReadingMember, error) {
+	chatID, session, err := b.activeSessionFor(uid, readingMember(uid))
+	if err != nil || chatID == 0 {
+		return nil, nil, err
+	}
+	if session == nil || session.Status != models.StatusReading || session.Reading == nil {
+		b.sendMessage(uid, b.messages.NothingToReadNow)
+		return nil, nil, nil
+	}
+
+	m := findReadingMember(session, uid)
+	if m == nil {
+		b.sendMessage(uid, b.messages.NotReadingMember)
+		return nil, nil, nil
+	}
+	return session, m, nil
+}
+
+// handleReadingAnswer advances one member's rating → review conversation and
+// persists each step. Members who are not answering a question are reminded of
+// the available commands.
+func (b *Bot) handleReadingAnswer(session *models.BookClubSession, update *tgbotapi.Update) {
+	uid := update.Message.From.ID
+
+	m := findReadingMember(session, uid)
+	if m == nil {
+		b.sendMessage(uid, b.messages.NotReadingMember)
+		return
+	}
+
+	switch m.Step {
+	case models.ReviewStepRating:
+		rating, ok := parseRating(update.Message.Text)
+		if !ok {
+			b.sendMessage(uid, b.messages.InvalidRating)
+			return
+		}
+		m.Rating = &rating
+		// A member re-rating with /rate keeps the review they already wrote.
+		if m.Review == nil {
+			m.Step = models.ReviewStepReview
+			b.persistReadingMember(session.ID, m)
+			b.sendMessage(uid, b.messages.WriteBookReview)
+			return
+		}
+		m.Step = models.ReviewStepDone
+		b.persistReadingMember(session.ID, m)
+		b.sendMessage(uid, b.messages.ReviewSaved)
+
+	case models.ReviewStepReview:
+		review := strings.TrimSpace(update.Message.Text)
+		if review == "" {
+			b.sendMessage(uid, b.messages.WriteBookReview)
+			return
+		}
+		m.Review = &review
+		m.Step = models.ReviewStepDone
+		b.persistReadingMember(session.ID, m)
+		b.sendMessage(uid, b.messages.ReviewSaved)
+		log.Printf("user: %d reviewed the book.\n", uid)
+
+	default:
+		b.sendMessage(uid, b.messages.ReadingInProgressHint)
+		return
+	}
+
+	// The last member to finish their review ends the reading phase early.
+	if allMembersDone(session) {
+		b.finishReading(session.ChatID)
+	}
+}
+
+// publishReadingDigest posts the review digest of a finished reading phase to
+// the club's group.
+func (b *Bot) publishReadingDigest(session *models.BookClubSession) {
+	if session.ChatID == 0 {
+		log.Println("cannot publish the reading digest as the session has no club")
+		return
+	}
+	for _, text := range b.readingDigest(session.Reading, b.memberNames(session)) {
+		b.sendMessage(session.ChatID, text)
+	}
+}
+
+// maxDigestReviewLength caps each review quoted in the reading digest.
+const maxDigestReviewLength = 1000
+
+// readingDigest renders the end-of-reading summary: the average rating, how the
+// ratings are spread and every review, quoted with its author's name when known
+// and cut to maxDigestReviewLength. It comes in as many messages as it takes to
+// keep each within Telegram's limit, a review never split between two.
+func (b *Bot) readingDigest(reading *models.Reading, names map[int64]string) []string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, b.messages.ReadingDigestTitle, reading.Book.Title)
+	sb.WriteString("\n\n")
+
+	counts := make([]int, maxRating+1)
+	rated, sum := 0, 0
+	for _, m := range reading.Members {
+		if m.Rating == nil {
+			continue
+		}
+		counts[*m.Rating]++
+		rated++
+		sum += *m.Rating
+	}
+	if rated == 0 {
+		sb.WriteString(b.messages.ReadingDigestNoRatings)
+	} else {
+		fmt.Fprintf(&sb, b.messages.ReadingDigestAverage, float64(sum)/float64(rated), rated)
+		for r := maxRating; r >= minRating; r-- {
+			bar := strings.Repeat("▇", counts[r])
+			if bar != "" {
+				bar += " "
+			}
+			fmt.Fprintf(&sb, "\n%d★ %s%d", r, bar, counts[r])
+		}
+	}
+
+	var digest []string
+	header := false
+	for _, m := range reading.Members {
+		if m.Review == nil || *m.Review == "" {
+			continue
+		}
+		quote := fmt.Sprintf("«%s»", truncateText(*m.Review, maxDigestReviewLength))
+		if name := names[m.SubscriberID]; name != "" {
+			quote += "\n— " + name
+		}
+		if !header {
+			quote = b.messages.ReadingDigestReviews + "\n\n" + quote
+			header = true
+		}
+		if textLength(sb.String())+len("\n\n")+textLength(quote) > maxMessageLength {
+			digest = append(digest, sb.String())
+			sb.Reset()
+		} else {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(quote)
+	}
+	return append(digest, sb.String())
+}
+
+// memberNames resolves display names for the reading members, preferring the
+// round's participant snapshot and falling back to the subscriber records.
+func (b *Bot) memberNames(session *models.BookClubSession) map[int64]string {
+	names := make(map[int64]string)
+	for _, p := range session.Gathering.Participants {
+		names[p.SubscriberID] = displayName(p.FirstName, p.LastName, p.Nick)
+	}
+	for _, m := range session.Reading.Members {
+		if _, ok := names[m.SubscriberID]; ok {
+			continue
+		}
+		sub, err := b.subRepository.GetSubscriberById(context.Background(), m.SubscriberID)
+		if err != nil {
+			log.Printf("cannot load subscriber %d for the digest: %v", m.SubscriberID, err)
+			continue
+		}
+		if sub != nil {
+			names[m.SubscriberID] = displayName(sub.FirstName, sub.LastName, sub.Nick)
+		}
+	}
+	return names
+}
+
+// allMembersDone reports whether every reading member has either reviewed the
+// book or abandoned it. A member who sent /finished but is still answering the
+// rating or review question is not done yet.
+func allMembersDone(session *models.BookClubSession) bool {
+	if session.Reading == nil {
+		return false
+	}
+	for _, m := range session.Reading.Members {
+		if m.Status == models.ReadingAbandoned {
+			continue
+		}
+		if m.Status != models.ReadingFinished || m.Step != models.ReviewStepDone {
+			return false
+		}
+	}
+	return true
+}
+
+// notifyReadingMilestone nudges every member still reading with how far they
+// said they are and how long is left until the meeting.
+func (b *Bot) notifyReadingMilestone(session *models.BookClubSession, now time.Time) {
+	r := session.Reading
+	daysLeft := int(math.Ceil(r.Deadline.Sub(now).Hours() / 24))
+	for _, m := range r.Members {
+		if m.Status != models.ReadingInProgress {
+			continue
+		}
+		var txt string
+		switch last := latestProgress(m); {
+		case last == nil:
+			txt = fmt.Sprintf(b.messages.ReadingNudgeNoProgress, r.Book.Title, daysLeft)
+		case last.Page > 0:
+			txt = fmt.Sprintf(b.messages.ReadingNudgePage, r.Book.Title, last.Page, daysLeft)
+		default:
+			txt = fmt.Sprintf(b.messages.ReadingNudgePercent, r.Book.Title, last.Percent, daysLeft)
+		}
+		b.sendMessage(m.SubscriberID, txt)
+	}
+}
+
+// dueMilestones returns the configured milestones (percent of the reading
+// period elapsed) that have been reached but not sent yet, in ascending order.
+func dueMilestones(reading *models.Reading, milestones []int, now time.Time) []int {
+	total := reading.Deadline.Sub(reading.StartedAt)
+	if total <= 0 {
+		return nil
+	}
+	elapsed := float64(now.Sub(reading.StartedAt)) / float64(total) * 100
+
+	var due []int
+	for _, m := range milestones {
+		if float64(m) <= elapsed && !slices.Contains(reading.MilestonesSent, m) {
+			due = append(due, m)
+		}
+	}
+	slices.Sort(due)
+	return due
+}
+
+// latestProgress returns a member's most recent check-in, or nil.
+func latestProgress(m *models.ReadingMember) *models.ProgressEntry {
+	if len(m.Progress) == 0 {
+		return nil
+	}
+	return &m.Progress[len(m.Progress)-1]
+}
+
+// parseProgress parses a /progress argument: "40%" is a percentage (1–100),
+// a bare number is a page.
+func parseProgress(arg string) (models.ProgressEntry, bool) {
+	arg = strings.TrimSpace(arg)
+	if pct, ok := strings.CutSuffix(arg, "%"); ok {
+		n, err := strconv.Atoi(strings.TrimSpace(pct))
+		if err != nil || n < 1 || n > 100 {
+			return models.ProgressEntry{}, false
+		}
+		return models.ProgressEntry{Percent: n}, true
+	}
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 {
+		return models.ProgressEntry{}, false
+	}
+	return models.ProgressEntry{Page: n}, true
+}
+
+// findReadingMember returns the reading member with the given id, or nil.
+func findReadingMember(session *models.BookClubSession, id int64) *models.ReadingMember {
+	if session.Reading == nil {
+		return nil
+	}
+	for _, m := range session.Reading.Members {
+		if m.SubscriberID == id {
+			return m
+		}
+	}
+	return nil
+}
+
+// parseRating parses a whole-number rating within [minRating, maxRating].
+func parseRating(text string) (int, bool) {
+	rating, err := strconv.Atoi(strings.TrimSpace(text))
+	if err != nil || rating < minRating || rating > maxRating {
+		return 0, false
+	}
+	return rating, true
+}
+
+// persistReadingMember writes a reading member's updated state, logging on
+// failure.
+func (b *Bot) persistReadingMember(id primitive.ObjectID, m *models.ReadingMember) {
+	if err := b.sessionRepository.UpdateReadingMember(context.Background(), id, m); err != nil {
+		log.Printf("cannot update reading member %d: %v", m.SubscriberID, err)
+	}
+}
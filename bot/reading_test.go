@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"BookClubBot/message"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRating(t *testing.T) {
+	data := map[string]struct {
+		input    string
+		expected int
+		ok       bool
+	}{
+		`lowest`:            {input: "1", expected: 1, ok: true},
+		`highest`:           {input: "5", expected: 5, ok: true},
+		`surrounding space`: {input: " 4\n", expected: 4, ok: true},
+		`below range`:       {input: "0", ok: false},
+		`above range`:       {input: "6", ok: false},
+		`not a number`:      {input: "great", ok: false},
+		`fraction`:          {input: "4.5", ok: false},
+	}
+
+	for name, tt := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseRating(tt.input)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestFindReadingMember(t *testing.T) {
+	session := &models.BookClubSession{
+		Reading: &models.Reading{Members: []*models.ReadingMember{
+			{SubscriberID: 1},
+			{SubscriberID: 2},
+		}},
+	}
+
+	assert.Equal(t, int64(2), findReadingMember(session, 2).SubscriberID)
+	assert.Nil(t, findReadingMember(session, 99))
+	assert.Nil(t, findReadingMember(&models.BookClubSession{}, 1), "no reading phase yet")
+}
+
+func TestAllMembersDone(t *testing.T) {
+	withMembers := func(members ...*models.ReadingMember) *models.BookClubSession {
+		return &models.BookClubSession{Reading: &models.Reading{Members: members}}
+	}
+
+	t.Run("reviewed and abandoned", func(t *testing.T) {
+		session := withMembers(
+			&models.ReadingMember{SubscriberID: 1, Status: models.ReadingFinished, Step: models.ReviewStepDone},
+			&models.ReadingMember{SubscriberID: 2, Status: models.ReadingAbandoned},
+		)
+		assert.True(t, allMembersDone(session))
+	})
+
+	t.Run("finished but still answering the review", func(t *testing.T) {
+		session := withMembers(
+			&models.ReadingMember{SubscriberID: 1, Status: models.ReadingFinished, Step: models.ReviewStepReview},
+		)
+		assert.False(t, allMembersDone(session))
+	})
+
+	t.Run("someone still reading", func(t *testing.T) {
+		session := withMembers(
+			&models.ReadingMember{SubscriberID: 1, Status: models.ReadingFinished, Step: models.ReviewStepDone},
+			&models.ReadingMember{SubscriberID: 2, Status: models.ReadingInProgress},
+		)
+		assert.False(t, allMembersDone(session))
+	})
+
+	t.Run("no reading phase", func(t *testing.T) {
+		assert.False(t, allMembersDone(&models.BookClubSession{}))
+	})
+}
+
+func TestReadingDigest(t *testing.T) {
+	b := &Bot{messages: &message.LocalizedMessages{
+		ReadingDigestTitle:     "Digest: %s",
+		ReadingDigestAverage:   "Average %.1f (%d ratings)",
+		ReadingDigestNoRatings: "No ratings.",
+		ReadingDigestReviews:   "Reviews:",
+	}}
+	five, four, review := 5, 4, "Loved it."
+
+	t.Run("ratings and reviews", func(t *testing.T) {
+		reading := &models.Reading{
+			Book: models.Book{Title: "Dune"},
+			Members: []*models.ReadingMember{
+				{SubscriberID: 1, Rating: &five, Review: &review},
+				{SubscriberID: 2, Rating: &four},
+				{SubscriberID: 3, Rating: &five},
+				{SubscriberID: 4, Status: models.ReadingAbandoned},
+			},
+		}
+
+		got := b.readingDigest(reading, map[int64]string{1: "Alice"})
+
+		expected := "Digest: Dune\n\n" +
+			"Average 4.7 (3 ratings)\n" +
+			"5★ ▇▇ 2\n" +
+			"4★ ▇ 1\n" +
+			"3★ 0\n" +
+			"2★ 0\n" +
+			"1★ 0\n\n" +
+			"Reviews:\n\n" +
+			"«Loved it.»\n— Alice"
+		assert.Equal(t, []string{expected}, got)
+	})
+
+	t.Run("nobody rated", func(t *testing.T) {
+		reading := &models.Reading{
+			Book:    models.Book{Title: "Dune"},
+			Members: []*models.ReadingMember{{SubscriberID: 1, Status: models.ReadingInProgress}},
+		}
+
+		got := b.readingDigest(reading, nil)
+
+		assert.Equal(t, []string{"Digest: Dune\n\nNo ratings."}, got)
+	})
+
+	t.Run("long reviews are cut and spread over messages", func(t *testing.T) {
+		long := strings.Repeat("слово ", 500)
+		reading := &models.Reading{Book: models.Book{Title: "Dune"}}
+		for id := int64(1); id <= 12; id++ {
+			reading.Members = append(reading.Members, &models.ReadingMember{SubscriberID: id, Rating: &five, Review: &long})
+		}
+
+		got := b.readingDigest(reading, map[int64]string{1: "Alice"})
+
+		require.Len(t, got, 4)
+		reviews := 0
+		for _, text := range got {
+			assert.LessOrEqual(t, textLength(text), maxMessageLength)
+			reviews += strings.Count(text, "«")
+		}
+		assert.Equal(t, 12, reviews, "no review is lost or split")
+		assert.True(t, strings.HasPrefix(got[0], "Digest: Dune\n\n"))
+		assert.Contains(t, got[0], "Reviews:\n\n«слово")
+		assert.Contains(t, got[0], "…»\n— Alice")
+	})
+}
+
+func TestParseProgress(t *testing.T) {
+	data := map[string]struct {
+		input    string
+		expected models.ProgressEntry
+		ok       bool
+	}{
+		`percent`:             {input: "40%", expected: models.ProgressEntry{Percent: 40}, ok: true},
+		`percent with spaces`: {input: " 40 % ", expected: models.ProgressEntry{Percent: 40}, ok: true},
+		`page`:                {input: "120", expected: models.ProgressEntry{Page: 120}, ok: true},
+		`percent over 100`:    {input: "140%", ok: false},
+		`zero percent`:        {input: "0%", ok: false},
+		`zero page`:           {input: "0", ok: false},
+		`empty`:               {input: "", ok: false},
+		`words`:               {input: "half", ok: false},
+	}
+
+	for name, tt := range data {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := parseProgress(tt.input)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestDueMilestones(t *testing.T) {
+	start := time.Date(2026, 6, 1, 0, 0, 0, 0, time.UTC)
+	reading := &models.Reading{
+		StartedAt:      start,
+		Deadline:       start.Add(100 * time.Hour),
+		MilestonesSent: []int{25},
+	}
+	milestones := []int{75, 25, 50}
+
+	assert.Empty(t, dueMilestones(reading, milestones, start.Add(10*time.Hour)))
+	assert.Equal(t, []int{50}, dueMilestones(reading, milestones, start.Add(50*time.Hour)))
+	assert.Equal(t, []int{50, 75}, dueMilestones(reading, milestones, start.Add(90*time.Hour)))
+	assert.Empty(t, dueMilestones(&models.Reading{StartedAt: start, Deadline: start}, milestones, start),
+		"a zero-length reading period has no milestones")
+}
@@ -8,7 +8,7 @@ import (
 )
 
 // recoveryTickInterval is how often the recovery loop re-evaluates the active
-// session. A deadline may therefore fire up to one interval late, which is
+// sessions. A deadline may therefore fire up to one interval late, which is
 // irrelevant for a book club measured in days. See docs/book-club-flow.md.
 const recoveryTickInterval = 15 * time.Second
 
@@ -20,7 +20,7 @@ const recoveryTickInterval = 15 * time.Second
 // actively launching its poll.
 const wedgedVotingGrace = 2 * time.Minute
 
-// startRecoveryLoop launches the single goroutine that drives the active
+// startRecoveryLoop launches the single goroutine that drives every active
 // session's lifecycle from its persisted timestamps. It is the only mechanism
 // that advances deadlines, so resuming after a restart is identical to normal
 // operation — the first tick simply acts on whatever the stored deadlines say.
@@ -39,38 +39,47 @@ func (b *Bot) startRecoveryLoop() {
 	}()
 }
 
-// recoverTick evaluates the active session once and acts on anything due.
+// recoverTick evaluates every club's active session once and acts on anything
+// due.
 func (b *Bot) recoverTick() {
-	session, err := b.sessionRepository.GetActiveSession(context.Background())
+	sessions, err := b.sessionRepository.GetActiveSessions(context.Background())
 	if err != nil {
-		log.Printf("recovery: cannot get active session: %v", err)
-		return
-	}
-	if session == nil {
+		log.Printf("recovery: cannot get active sessions: %v", err)
 		return
 	}
 
 	now := time.Now().UTC()
-	switch session.Status {
-	case models.StatusGathering:
-		b.recoverGathering(session, now)
-	case models.StatusVoting:
-		b.recoverVoting(session, now)
+	for _, session := range sessions {
+		switch session.Status {
+		case models.StatusGathering:
+			b.recoverGathering(session, now)
+		case models.StatusVoting:
+			b.recoverVoting(session, now)
+		case models.StatusReading:
+			b.recoverReading(session, now)
+		}
 	}
 }
 
 // recoverGathering sends the due reminder and moves to voting once the deadline
 // passes (or everyone has finished/skipped).
 func (b *Bot) recoverGathering(session *models.BookClubSession, now time.Time) {
+	// A lookup cut short by a restart is run again.
+	for _, p := range session.Gathering.Participants {
+		if p.Step == models.StepLookup {
+			b.resumeLookup(session.ChatID, p.SubscriberID, p.Lookup)
+		}
+	}
+
 	if session.Gathering.NotifiedAt == nil && !now.Before(session.Gathering.NotifyAt) {
-		b.notifyGatheringDeadline(session)
+		b.notifyGatheringDeadline(session, now)
 		if err := b.sessionRepository.SetGatheringNotified(context.Background(), session.ID, now); err != nil {
 			log.Printf("recovery: cannot mark gathering notified: %v", err)
 		}
 	}
 
 	if allBooksChosen(session) || !now.Before(session.Gathering.Deadline) {
-		b.runTelegramPollFlow()
+		b.runTelegramPollFlow(session.ChatID)
 	}
 }
 
@@ -95,14 +104,39 @@ func (b *Bot) recoverVoting(session *models.BookClubSession, now time.Time) {
 	}
 
 	if session.Voting.NotifiedAt == nil && !now.Before(session.Voting.NotifyAt) {
-		b.notifyPollDeadline()
+		b.notifyPollDeadline(session.ChatID, session.Voting.Deadline, now)
 		if err := b.sessionRepository.SetVotingNotified(context.Background(), session.ID, now); err != nil {
 			log.Printf("recovery: cannot mark voting notified: %v", err)
 		}
 	}
 
-	allVoted := session.Voting.TotalParticipants > 0 && len(session.Voting.VoterIDs) >= session.Voting.TotalParticipants
-	if allVoted || !now.Before(session.Voting.Deadline) {
-		b.closeTelegramPoll()
+	if allVoted(session.Voting) || !now.Before(session.Voting.Deadline) {
+		b.closeTelegramPoll(session.ChatID)
+	}
+}
+
+// recoverReading sends the due milestone nudge and completes the session once
+// the reading deadline passes (or every member has reviewed or abandoned the
+// book).
+func (b *Bot) recoverReading(session *models.BookClubSession, now time.Time) {
+	if session.Reading == nil {
+		// StartReading writes the sub-document and the status together, so this
+		// cannot happen short of a manual edit; leave such a session alone.
+		return
+	}
+
+	// After downtime several milestones may be due at once; members get a
+	// single nudge and every due milestone is marked as sent.
+	if due := dueMilestones(session.Reading, b.cfg.ReadingMilestones, now); len(due) > 0 && now.Before(session.Reading.Deadline) {
+		b.notifyReadingMilestone(session, now)
+		for _, m := range due {
+			if err := b.sessionRepository.AddReadingMilestone(context.Background(), session.ID, m); err != nil {
+				log.Printf("recovery: cannot mark reading milestone %d: %v", m, err)
+			}
+		}
+	}
+
+	if allMembersDone(session) || !now.Before(session.Reading.Deadline) {
+		b.finishReading(session.ChatID)
 	}
 }
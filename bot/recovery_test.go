@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"BookClubBot/config"
 	"BookClubBot/internal/models"
 	"context"
 	"testing"
@@ -13,30 +14,80 @@ import (
 // fakeSessionRepo records the calls the recovery loop makes. Methods not needed
 // by a given test are no-ops.
 type fakeSessionRepo struct {
+	active        *models.BookClubSession
 	statusSet     []string
 	gatherNotify  int
 	votingNotify  int
 	votingClosed  int
 	startedVoting int
+	reading       *models.Reading
+	milestones    []int
 }
 
 func (f *fakeSessionRepo) CreateSession(context.Context, *models.BookClubSession) error {
 	return nil
 }
-func (f *fakeSessionRepo) GetActiveSession(context.Context) (*models.BookClubSession, error) {
-	return nil, nil
+func (f *fakeSessionRepo) GetActiveSession(context.Context, int64) (*models.BookClubSession, error) {
+	return f.active, nil
+}
+func (f *fakeSessionRepo) GetActiveSessions(context.Context) ([]*models.BookClubSession, error) {
+	if f.active == nil {
+		return nil, nil
+	}
+	return []*models.BookClubSession{f.active}, nil
 }
+func (f *fakeSessionRepo) AssignLegacyChat(context.Context, int64) (int64, error) { return 0, nil }
 func (f *fakeSessionRepo) UpdateParticipant(context.Context, primitive.ObjectID, *models.Participant) error {
 	return nil
 }
+func (f *fakeSessionRepo) UpdateReadingMember(context.Context, primitive.ObjectID, *models.ReadingMember) error {
+	return nil
+}
+func (f *fakeSessionRepo) SetAnswer(context.Context, primitive.ObjectID, *models.Answer) error {
+	return nil
+}
+func (f *fakeSessionRepo) AddPollResult(context.Context, primitive.ObjectID, *models.PollResult) error {
+	return nil
+}
 func (f *fakeSessionRepo) AddVoter(context.Context, primitive.ObjectID, int64) error { return nil }
+func (f *fakeSessionRepo) UpdateBallot(context.Context, primitive.ObjectID, *models.Ballot) error {
+	return nil
+}
+func (f *fakeSessionRepo) RemoveVoter(context.Context, primitive.ObjectID, int64) error { return nil }
+func (f *fakeSessionRepo) AddOutsider(context.Context, primitive.ObjectID, int64) error { return nil }
+func (f *fakeSessionRepo) AddHeatVoter(context.Context, primitive.ObjectID, int, int64) error {
+	return nil
+}
+func (f *fakeSessionRepo) RemoveHeatVoter(context.Context, primitive.ObjectID, int, int64) error {
+	return nil
+}
+func (f *fakeSessionRepo) CloseHeat(context.Context, primitive.ObjectID, int, []int, time.Time) error {
+	return nil
+}
 func (f *fakeSessionRepo) StartVoting(context.Context, primitive.ObjectID, *models.Voting) error {
 	f.startedVoting++
 	return nil
 }
+func (f *fakeSessionRepo) StartReading(_ context.Context, _ primitive.ObjectID, reading *models.Reading) error {
+	f.reading = reading
+	return nil
+}
+func (f *fakeSessionRepo) AddReadingMilestone(_ context.Context, _ primitive.ObjectID, milestone int) error {
+	f.milestones = append(f.milestones, milestone)
+	return nil
+}
 func (f *fakeSessionRepo) SetWinners(context.Context, primitive.ObjectID, []models.Winner) error {
 	return nil
 }
+func (f *fakeSessionRepo) SetTieBreak(context.Context, primitive.ObjectID, *models.TieBreak) error {
+	return nil
+}
+func (f *fakeSessionRepo) ListPastWinners(context.Context, int64) ([]*models.BookClubSession, error) {
+	return nil, nil
+}
+func (f *fakeSessionRepo) ListUncataloguedSessions(context.Context) ([]*models.BookClubSession, error) {
+	return nil, nil
+}
 func (f *fakeSessionRepo) SetStatus(_ context.Context, _ primitive.ObjectID, status string) error {
 	f.statusSet = append(f.statusSet, status)
 	return nil
@@ -53,6 +104,12 @@ func (f *fakeSessionRepo) SetVotingClosed(context.Context, primitive.ObjectID, t
 	f.votingClosed++
 	return nil
 }
+func (f *fakeSessionRepo) ExtendGathering(context.Context, primitive.ObjectID, time.Time, time.Time) error {
+	return nil
+}
+func (f *fakeSessionRepo) ExtendVoting(context.Context, primitive.ObjectID, time.Time, time.Time) error {
+	return nil
+}
 
 func TestRecoverVotingWedgedSession(t *testing.T) {
 	now := time.Now().UTC()
@@ -89,3 +146,82 @@ func TestRecoverVotingWedgedSession(t *testing.T) {
 		assert.Equal(t, 0, fake.votingClosed, "wedged session must not be closed")
 	})
 }
+
+func TestRecoverReading(t *testing.T) {
+	now := time.Now().UTC()
+
+	t.Run("before the deadline nothing happens", func(t *testing.T) {
+		session := &models.BookClubSession{
+			ID:     primitive.NewObjectID(),
+			Status: models.StatusReading,
+			Reading: &models.Reading{
+				Deadline: now.Add(time.Hour),
+				Members:  []*models.ReadingMember{{SubscriberID: 1, Status: models.ReadingInProgress}},
+			},
+		}
+		fake := &fakeSessionRepo{active: session}
+		b := &Bot{cfg: &config.AppConfig{}, sessionRepository: fake}
+
+		b.recoverReading(session, now)
+
+		assert.Empty(t, fake.statusSet)
+	})
+
+	t.Run("reached milestones are marked once", func(t *testing.T) {
+		session := &models.BookClubSession{
+			ID:     primitive.NewObjectID(),
+			Status: models.StatusReading,
+			Reading: &models.Reading{
+				StartedAt:      now.Add(-80 * time.Hour),
+				Deadline:       now.Add(20 * time.Hour), // 80% elapsed
+				MilestonesSent: []int{25},
+				// Nobody is still reading, so no nudge is sent.
+				Members: []*models.ReadingMember{{SubscriberID: 1, Status: models.ReadingFinished, Step: models.ReviewStepRating}},
+			},
+		}
+		fake := &fakeSessionRepo{active: session}
+		b := &Bot{cfg: &config.AppConfig{ReadingMilestones: []int{25, 50, 75, 90}}, sessionRepository: fake}
+
+		b.recoverReading(session, now)
+
+		assert.Equal(t, []int{50, 75}, fake.milestones)
+		assert.Empty(t, fake.statusSet)
+	})
+
+	t.Run("past the deadline the session completes", func(t *testing.T) {
+		session := &models.BookClubSession{
+			ID:     primitive.NewObjectID(),
+			Status: models.StatusReading,
+			Reading: &models.Reading{
+				Deadline: now.Add(-time.Second),
+				Members:  []*models.ReadingMember{{SubscriberID: 1, Status: models.ReadingInProgress}},
+			},
+		}
+		fake := &fakeSessionRepo{active: session}
+		b := &Bot{cfg: &config.AppConfig{}, sessionRepository: fake}
+
+		b.recoverReading(session, now)
+
+		assert.Equal(t, []string{models.StatusCompleted}, fake.statusSet)
+	})
+
+	t.Run("everyone reviewed or abandoned completes early", func(t *testing.T) {
+		session := &models.BookClubSession{
+			ID:     primitive.NewObjectID(),
+			Status: models.StatusReading,
+			Reading: &models.Reading{
+				Deadline: now.Add(time.Hour),
+				Members: []*models.ReadingMember{
+					{SubscriberID: 1, Status: models.ReadingFinished, Step: models.ReviewStepDone},
+					{SubscriberID: 2, Status: models.ReadingAbandoned},
+				},
+			},
+		}
+		fake := &fakeSessionRepo{active: session}
+		b := &Bot{cfg: &config.AppConfig{}, sessionRepository: fake}
+
+		b.recoverReading(session, now)
+
+		assert.Equal(t, []string{models.StatusCompleted}, fake.statusSet)
+	})
+}
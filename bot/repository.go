@@ -11,8 +11,20 @@ import (
 type subscriberRepo interface {
 	SaveSubscriber(ctx context.Context, subscriber *models.Subscriber) error
 	SetArchiveSubscriber(ctx context.Context, subscriberID int64, archived bool) error
-	GetAllSubscribers(ctx context.Context) ([]*models.Subscriber, error)
+	GetAllSubscribers(ctx context.Context, chatID int64) ([]*models.Subscriber, error)
 	GetSubscriberById(ctx context.Context, id int64) (*models.Subscriber, error)
+	AddClub(ctx context.Context, subscriberID, chatID int64) error
+	RemoveClub(ctx context.Context, subscriberID, chatID int64) error
+	SetCurrentClub(ctx context.Context, subscriberID, chatID int64) error
+	AssignLegacyClub(ctx context.Context, chatID int64) (int64, error)
+}
+
+type clubRepo interface {
+	SaveClub(ctx context.Context, club *models.Club) error
+	DeleteClub(ctx context.Context, chatID int64) error
+	GetClubById(ctx context.Context, chatID int64) (*models.Club, error)
+	GetAllClubs(ctx context.Context) ([]*models.Club, error)
+	SetClubAdmins(ctx context.Context, chatID int64, adminIDs []int64, syncedAt time.Time) error
 }
 
 type settingsRepo interface {
@@ -20,15 +32,38 @@ type settingsRepo interface {
 	GetGroupId(ctx context.Context) (int64, error)
 }
 
+type bookRepo interface {
+	RecordProposal(ctx context.Context, book *models.CatalogBook, proposal models.BookProposal) (primitive.ObjectID, error)
+	MarkWon(ctx context.Context, id, sessionID primitive.ObjectID, subscriberID int64) error
+}
+
 type sessionRepo interface {
 	CreateSession(ctx context.Context, session *models.BookClubSession) error
-	GetActiveSession(ctx context.Context) (*models.BookClubSession, error)
+	GetActiveSession(ctx context.Context, chatID int64) (*models.BookClubSession, error)
+	GetActiveSessions(ctx context.Context) ([]*models.BookClubSession, error)
+	ListPastWinners(ctx context.Context, chatID int64) ([]*models.BookClubSession, error)
+	AssignLegacyChat(ctx context.Context, chatID int64) (int64, error)
+	ListUncataloguedSessions(ctx context.Context) ([]*models.BookClubSession, error)
 	UpdateParticipant(ctx context.Context, id primitive.ObjectID, participant *models.Participant) error
+	UpdateReadingMember(ctx context.Context, id primitive.ObjectID, member *models.ReadingMember) error
+	SetAnswer(ctx context.Context, id primitive.ObjectID, answer *models.Answer) error
+	AddPollResult(ctx context.Context, id primitive.ObjectID, result *models.PollResult) error
 	AddVoter(ctx context.Context, id primitive.ObjectID, voterID int64) error
+	UpdateBallot(ctx context.Context, id primitive.ObjectID, ballot *models.Ballot) error
+	RemoveVoter(ctx context.Context, id primitive.ObjectID, voterID int64) error
+	AddOutsider(ctx context.Context, id primitive.ObjectID, voterID int64) error
+	AddHeatVoter(ctx context.Context, id primitive.ObjectID, heat int, voterID int64) error
+	RemoveHeatVoter(ctx context.Context, id primitive.ObjectID, heat int, voterID int64) error
+	CloseHeat(ctx context.Context, id primitive.ObjectID, heat int, advanced []int, at time.Time) error
 	StartVoting(ctx context.Context, id primitive.ObjectID, voting *models.Voting) error
+	StartReading(ctx context.Context, id primitive.ObjectID, reading *models.Reading) error
+	AddReadingMilestone(ctx context.Context, id primitive.ObjectID, milestone int) error
 	SetWinners(ctx context.Context, id primitive.ObjectID, winners []models.Winner) error
+	SetTieBreak(ctx context.Context, id primitive.ObjectID, tieBreak *models.TieBreak) error
 	SetStatus(ctx context.Context, id primitive.ObjectID, status string) error
 	SetGatheringNotified(ctx context.Context, id primitive.ObjectID, at time.Time) error
 	SetVotingNotified(ctx context.Context, id primitive.ObjectID, at time.Time) error
 	SetVotingClosed(ctx context.Context, id primitive.ObjectID, at time.Time) error
+	ExtendGathering(ctx context.Context, id primitive.ObjectID, deadline, notifyAt time.Time) error
+	ExtendVoting(ctx context.Context, id primitive.ObjectID, deadline, notifyAt time.Time) error
 }
@@ -0,0 +1,71 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// pollOptions maps poll option texts to the gathered books they were rendered
+// from, for polls started before the options were stored with their books. An
+// option no book renders to keeps only its text.
+func (b *Bot) pollOptions(session *models.BookClubSession, texts []string) []models.PollOption {
+	byText := make(map[string]*models.Participant)
+	for _, p := range session.Gathering.Participants {
+		if p.Step == models.StepDone && p.Book != nil {
+			byText[strings.TrimSpace(b.pollOptionFor(p.Book))] = p
+		}
+	}
+	options := make([]models.PollOption, len(texts))
+	for i, text := range texts {
+		options[i].Text = text
+		if p, ok := byText[strings.TrimSpace(text)]; ok {
+			options[i].SubscriberID = p.SubscriberID
+			options[i].Title = p.Book.Title
+			options[i].Author = p.Book.Author
+		}
+	}
+	return options
+}
+
+// pollResult pairs a closed poll's counts with its options, taken from the
+// poll itself when the options were not stored (a poll started before they
+// were).
+func (b *Bot) pollResult(session *models.BookClubSession, options []models.PollOption, raw, counted *tgbotapi.Poll, at time.Time) *models.PollResult {
+	if len(options) == 0 {
+		texts := make([]string, len(raw.Options))
+		for i, o := range raw.Options {
+			texts[i] = o.Text
+		}
+		options = b.pollOptions(session, texts)
+	}
+	result := &models.PollResult{
+		PollID:         raw.ID,
+		Options:        slices.Clone(options),
+		TotalVoters:    counted.TotalVoterCount,
+		RawTotalVoters: raw.TotalVoterCount,
+		ClosedAt:       at,
+	}
+	for i := range result.Options {
+		if i < len(raw.Options) {
+			result.Options[i].Votes = counted.Options[i].VoterCount
+			result.Options[i].RawVotes = raw.Options[i].VoterCount
+		}
+	}
+	return result
+}
+
+// recordPollResult archives a closed poll on the session, in storage and on
+// the loaded copy, so a next stage started from it keeps the archive. A failed
+// write is only logged: the round goes on without the record.
+func (b *Bot) recordPollResult(session *models.BookClubSession, result *models.PollResult) {
+	if err := b.sessionRepository.AddPollResult(context.Background(), session.ID, result); err != nil {
+		log.Printf("cannot record the results of poll %s: %v", result.PollID, err)
+	}
+	session.Voting.Results = append(session.Voting.Results, result)
+}
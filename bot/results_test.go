@@ -0,0 +1,60 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPollResults(t *testing.T) {
+	b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600})
+	first := fake.lastPoll()
+
+	session := sessions.latest(testClubID)
+	require.Len(t, session.Voting.Options, 2)
+	for i, o := range session.Voting.Options {
+		assert.Equal(t, first.Options[i].Text, o.Text, "options are stored in poll order")
+		assert.Contains(t, o.Text, o.Title)
+		assert.NotZero(t, o.SubscriberID)
+	}
+
+	b.serve(fake.inject(tiedVote(fake, first.ID)...))
+	runoff := fake.lastPoll()
+	b.serve(fake.inject(
+		fake.vote(1, runoff.ID, "Solaris"),
+		fake.vote(2, runoff.ID, "Solaris"),
+		fake.vote(3, runoff.ID, "Dune"),
+	))
+
+	session = sessions.latest(testClubID)
+	require.Equal(t, models.StatusReading, session.Status)
+	require.Len(t, session.Voting.Results, 2, "the tied poll and the runoff")
+
+	tied := session.Voting.Results[0]
+	assert.Equal(t, first.ID, tied.PollID)
+	assert.False(t, tied.Runoff)
+	assert.Equal(t, 3, tied.TotalVoters)
+	assert.Equal(t, []int{2, 2}, votes(tied))
+
+	final := session.Voting.Results[1]
+	assert.Equal(t, runoff.ID, final.PollID)
+	assert.True(t, final.Runoff)
+	for _, o := range final.Options {
+		if o.Title == "Solaris" {
+			assert.Equal(t, 2, o.Votes)
+			assert.Equal(t, int64(2), o.SubscriberID)
+		}
+	}
+	assert.Len(t, session.Voting.Answers, 6, "every voter's choices in both polls")
+}
+
+func votes(r *models.PollResult) []int {
+	v := make([]int, len(r.Options))
+	for i, o := range r.Options {
+		v[i] = o.Votes
+	}
+	return v
+}
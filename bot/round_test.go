@@ -0,0 +1,233 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"strings"
+	"testing"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundEndToEnd(t *testing.T) {
+	b, fake, sessions := roundBot(&config.AppConfig{
+		Admins:                []int64{1},
+		TimeToGatherBooks:     3600,
+		NotifyBeforeGathering: 600,
+		TimeForTelegramPoll:   3600,
+		NotifyBeforePoll:      600,
+		TimeForReading:        3600,
+	})
+
+	// The bot joins the group and three users join the club.
+	b.serve(fake.inject(
+		botAdded(testClubID, "Readers", testSelfID),
+		dm(1, "/subscribe"),
+		dm(2, "/subscribe"),
+		dm(3, "/subscribe"),
+	))
+	assert.Equal(t, []string{"hello club"}, fake.textsTo(testClubID))
+
+	// Gathering: two books are proposed and the third member skips, which ends
+	// the gathering early and starts the poll.
+	updates := []tgbotapi.Update{dm(1, "/start_vote")}
+	updates = append(updates, submit(1, "Dune", "Herbert")...)
+	updates = append(updates, submit(2, "Solaris", "Lem")...)
+	updates = append(updates, dm(3, "/skip"))
+	b.serve(fake.inject(updates...))
+
+	assert.Contains(t, fake.textsTo(3), "suggest a book")
+	require.Len(t, fake.media, 1, "the gathered books are shown before the poll")
+	assert.Len(t, fake.media[0].Media, 2)
+	poll := fake.lastPoll()
+	require.NotNil(t, poll)
+	require.Len(t, poll.Options, 2)
+
+	session := sessions.latest(testClubID)
+	require.NotNil(t, session)
+	assert.Equal(t, models.StatusVoting, session.Status)
+	require.NotNil(t, session.Voting)
+	assert.Equal(t, poll.ID, session.Voting.PollID)
+	assert.Equal(t, 3, session.Voting.TotalParticipants)
+
+	// Voting: once every member has voted the poll closes and reading starts.
+	b.serve(fake.inject(
+		fake.vote(1, poll.ID, "Dune"),
+		fake.vote(2, poll.ID, "Dune", "Solaris"),
+		fake.vote(3, poll.ID, "Dune"),
+	))
+
+	assert.True(t, poll.IsClosed)
+	session = sessions.latest(testClubID)
+	assert.Equal(t, models.StatusReading, session.Status)
+	require.Len(t, session.Winners, 1)
+	assert.Equal(t, "Dune", session.Winners[0].Title)
+	require.NotNil(t, session.Reading)
+	assert.Equal(t, "About Dune", session.Reading.Book.Description)
+	assert.Len(t, session.Reading.Members, 3)
+
+	group := fake.textsTo(testClubID)
+	require.Len(t, group, 3)
+	assert.Contains(t, group[1], "winner")
+	assert.True(t, strings.HasPrefix(group[2], "reading Dune until "))
+
+	// Reading: two members review the book and the third gives up, which ends
+	// the round with a digest in the group.
+	b.serve(fake.inject(
+		dm(1, "/finished"), dm(1, "5"), dm(1, "Loved it"),
+		dm(2, "/progress 50%"),
+		dm(2, "/finished"), dm(2, "4"), dm(2, "Long but good"),
+		dm(3, "/abandon"),
+	))
+
+	session = sessions.latest(testClubID)
+	assert.Equal(t, models.StatusCompleted, session.Status)
+	m := findReadingMember(session, 2)
+	require.NotNil(t, m)
+	require.NotNil(t, m.Rating)
+	assert.Equal(t, 4, *m.Rating)
+	assert.Len(t, m.Progress, 1)
+
+	group = fake.textsTo(testClubID)
+	require.Len(t, group, 4)
+	digest := group[3]
+	assert.Contains(t, digest, "digest of Dune")
+	assert.Contains(t, digest, "average 4.5 from 2")
+	assert.Contains(t, digest, "«Loved it»")
+	assert.Contains(t, digest, "«Long but good»")
+}
+
+func TestRoundDrivenByDeadlines(t *testing.T) {
+	// Zero-length phases: every deadline has passed by the next recovery tick.
+	b, fake, sessions := roundBot(&config.AppConfig{Admins: []int64{1}})
+
+	updates := []tgbotapi.Update{
+		botAdded(testClubID, "Readers", testSelfID),
+		dm(1, "/subscribe"),
+		dm(2, "/subscribe"),
+		dm(3, "/subscribe"),
+		dm(1, "/start_vote"),
+	}
+	updates = append(updates, submit(1, "Dune", "Herbert")...)
+	updates = append(updates, submit(2, "Solaris", "Lem")...)
+	b.serve(fake.inject(updates...))
+	assert.Equal(t, models.StatusGathering, sessions.latest(testClubID).Status, "user 3 has not answered yet")
+
+	// Gathering deadline: user 3 is reminded and the poll starts without them.
+	b.recoverTick()
+	poll := fake.lastPoll()
+	require.NotNil(t, poll)
+	assert.Equal(t, models.StatusVoting, sessions.latest(testClubID).Status)
+
+	// Voting deadline: the poll closes with the votes cast so far.
+	b.serve(fake.inject(fake.vote(3, poll.ID, "Solaris")))
+	b.recoverTick()
+	session := sessions.latest(testClubID)
+	assert.Equal(t, models.StatusReading, session.Status)
+	assert.Equal(t, "Solaris", session.Reading.Book.Title)
+
+	// Reading deadline: the round completes with an empty digest.
+	b.recoverTick()
+	assert.Equal(t, models.StatusCompleted, sessions.latest(testClubID).Status)
+	group := fake.textsTo(testClubID)
+	assert.Contains(t, group[len(group)-1], "no ratings")
+}
+
+func TestMaxChoices(t *testing.T) {
+	b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600, MaxChoices: 1})
+	b.messages.TooManyChoices = "picked %d of %d"
+	poll := fake.lastPoll()
+	assert.True(t, strings.HasPrefix(poll.Question, "choose up to 1"))
+	assert.False(t, poll.AllowsMultipleAnswers)
+
+	b.serve(fake.inject(
+		fake.vote(1, poll.ID, "Dune", "Solaris"),
+		fake.vote(2, poll.ID, "Solaris"),
+		fake.vote(3, poll.ID, "Solaris"),
+	))
+
+	session := sessions.latest(testClubID)
+	require.Equal(t, models.StatusVoting, session.Status, "member 1 has not voted yet")
+	assert.Contains(t, fake.textsTo(1), "picked 2 of 1")
+	assert.ElementsMatch(t, []int64{2, 3}, session.Voting.VoterIDs)
+	require.Len(t, session.Voting.Answers, 3)
+	assert.Equal(t, []int{0, 1}, session.Voting.Answers[0].OptionIDs, "the answer is kept")
+
+	// Member 1 revotes within the limit, which completes the vote.
+	b.serve(fake.inject(fake.vote(1, poll.ID, "Dune")))
+
+	session = sessions.latest(testClubID)
+	assert.Equal(t, models.StatusReading, session.Status)
+	require.Len(t, session.Voting.Answers, 3, "the revote replaces the answer")
+	assert.Len(t, session.Voting.Answers[0].OptionIDs, 1)
+}
+
+func TestRevoteOverMaxChoices(t *testing.T) {
+	b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600, MaxChoices: 1})
+	b.messages.TooManyChoices = "picked %d of %d"
+	poll := fake.lastPoll()
+
+	// Member 2's valid vote is replaced by one over the limit.
+	b.serve(fake.inject(
+		fake.vote(1, poll.ID, "Dune"),
+		fake.vote(2, poll.ID, "Solaris"),
+		fake.vote(2, poll.ID, "Dune", "Solaris"),
+		fake.vote(3, poll.ID, "Dune"),
+	))
+
+	session := sessions.latest(testClubID)
+	require.Equal(t, models.StatusVoting, session.Status, "member 2's vote no longer counts, so the poll stays open")
+	assert.ElementsMatch(t, []int64{1, 3}, session.Voting.VoterIDs)
+	assert.Equal(t, "picked 2 of 1", lastText(fake, 2))
+
+	b.serve(fake.inject(fake.vote(2, poll.ID, "Solaris")))
+	assert.True(t, poll.IsClosed, "the revote within the limit completes the vote")
+}
+
+func TestRetractVote(t *testing.T) {
+	b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600})
+	poll := fake.lastPoll()
+
+	b.serve(fake.inject(
+		fake.vote(1, poll.ID, "Dune"),
+		fake.vote(2, poll.ID, "Solaris"),
+		fake.retract(1, poll.ID),
+		fake.vote(3, poll.ID, "Dune"),
+	))
+
+	session := sessions.latest(testClubID)
+	require.Equal(t, models.StatusVoting, session.Status, "member 1 is undecided, so the poll stays open")
+	assert.ElementsMatch(t, []int64{2, 3}, session.Voting.VoterIDs)
+	assert.Empty(t, session.Voting.Answers[0].OptionIDs, "the retraction is recorded")
+
+	b.serve(fake.inject(fake.vote(1, poll.ID, "Solaris")))
+
+	session = sessions.latest(testClubID)
+	assert.Equal(t, models.StatusReading, session.Status)
+	require.Len(t, session.Winners, 1)
+	assert.Equal(t, "Solaris", session.Winners[0].Title)
+}
+
+func TestLongTitleWins(t *testing.T) {
+	cfg := testConfig()
+	b, fake, sessions := clubWithMembers(t, cfg, 1, 2)
+	long := strings.Repeat("Очень длинное название ", 10)
+
+	updates := []tgbotapi.Update{dm(1, "/start_vote")}
+	updates = append(updates, submit(1, long, "Автор")...)
+	updates = append(updates, submit(2, "Dune", "Herbert")...)
+	b.serve(fake.inject(updates...))
+
+	poll := fake.lastPoll()
+	require.NotNil(t, poll)
+	for _, o := range poll.Options {
+		assert.LessOrEqual(t, len([]rune(o.Text)), maxPollOptionLength)
+	}
+	b.serve(fake.inject(fake.vote(1, poll.ID, "Очень"), fake.vote(2, poll.ID, "Очень")))
+
+	session := sessions.latest(testClubID)
+	require.Equal(t, models.StatusReading, session.Status)
+	assert.Equal(t, strings.TrimSpace(long), session.Winners[0].Title, "the winner keeps its full title")
+}
@@ -4,7 +4,10 @@ import (
 	"BookClubBot/config"
 	"BookClubBot/internal/models"
 	"BookClubBot/message"
+	"context"
+	"errors"
 	"testing"
+	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 	"github.com/stretchr/testify/assert"
@@ -19,6 +22,32 @@ func testBot() *Bot {
 	}
 }
 
+// fakeSubscriberRepo serves a fixed subscriber list.
+type fakeSubscriberRepo struct {
+	subs []*models.Subscriber
+	err  error
+}
+
+func (f *fakeSubscriberRepo) SaveSubscriber(context.Context, *models.Subscriber) error { return nil }
+func (f *fakeSubscriberRepo) SetArchiveSubscriber(context.Context, int64, bool) error  { return nil }
+func (f *fakeSubscriberRepo) GetAllSubscribers(context.Context, int64) ([]*models.Subscriber, error) {
+	return f.subs, f.err
+}
+func (f *fakeSubscriberRepo) GetSubscriberById(_ context.Context, id int64) (*models.Subscriber, error) {
+	for _, s := range f.subs {
+		if s.ID == id {
+			return s, nil
+		}
+	}
+	return nil, nil
+}
+func (f *fakeSubscriberRepo) AddClub(context.Context, int64, int64) error        { return nil }
+func (f *fakeSubscriberRepo) RemoveClub(context.Context, int64, int64) error     { return nil }
+func (f *fakeSubscriberRepo) SetCurrentClub(context.Context, int64, int64) error { return nil }
+func (f *fakeSubscriberRepo) AssignLegacyClub(context.Context, int64) (int64, error) {
+	return 0, nil
+}
+
 func sessionWith(participants ...*models.Participant) *models.BookClubSession {
 	return &models.BookClubSession{
 		Gathering: models.Gathering{Participants: participants},
@@ -35,16 +64,6 @@ func TestFindParticipant(t *testing.T) {
 	assert.Nil(t, findParticipant(session, 99))
 }
 
-func TestIsBookAlreadyProposed(t *testing.T) {
-	session := sessionWith(
-		&models.Participant{SubscriberID: 1, Book: &models.Book{Title: "Dune"}},
-		&models.Participant{SubscriberID: 2, Step: models.StepBook}, // no book yet
-	)
-
-	assert.True(t, isBookAlreadyProposed(session, "Dune"))
-	assert.False(t, isBookAlreadyProposed(session, "Neuromancer"))
-}
-
 func TestAllBooksChosen(t *testing.T) {
 	t.Run("all done or skipped", func(t *testing.T) {
 		session := sessionWith(
@@ -65,13 +84,14 @@ func TestAllBooksChosen(t *testing.T) {
 
 func TestRunTelegramPollNotEnoughBooks(t *testing.T) {
 	b := testBot()
-	b.cfg = &config.AppConfig{GroupId: 1}
+	b.cfg = &config.AppConfig{}
 
 	// Only one finished book — too few for a poll.
 	session := sessionWith(
 		&models.Participant{SubscriberID: 1, Step: models.StepDone, Book: &models.Book{Title: "Dune", Author: "Herbert"}},
 		&models.Participant{SubscriberID: 2, Step: models.StepSkipped},
 	)
+	session.ChatID = 1
 
 	err := b.runTelegramPoll(session)
 	assert.ErrorIs(t, err, errNotEnoughBooks)
@@ -86,7 +106,7 @@ func TestExtractBooks(t *testing.T) {
 	)
 
 	books := b.extractBooks(session)
-	assert.Equal(t, []string{"Book: Dune. Author: Herbert\n"}, books)
+	assert.Equal(t, []models.PollOption{{Text: "Book: Dune. Author: Herbert\n", SubscriberID: 1, Title: "Dune", Author: "Herbert"}}, books)
 }
 
 func TestWinnersFromPoll(t *testing.T) {
@@ -123,6 +143,26 @@ func TestWinnersFromPoll(t *testing.T) {
 		assert.Empty(t, winners)
 	})
 
+	t.Run("options are resolved by index", func(t *testing.T) {
+		// Two books that render the same option, and an option text Telegram
+		// changed: neither matters once the options are stored.
+		twin := &models.Book{Title: "Dune", Author: "Herbert"}
+		session := sessionWith(
+			&models.Participant{SubscriberID: 1, Step: models.StepDone, Book: dune},
+			&models.Participant{SubscriberID: 3, Step: models.StepDone, Book: twin},
+		)
+		session.Voting = &models.Voting{Options: []models.PollOption{
+			{Text: b.pollOptionFor(dune), SubscriberID: 1, Title: "Dune", Author: "Herbert"},
+			{Text: b.pollOptionFor(twin), SubscriberID: 3, Title: "Dune", Author: "Herbert"},
+		}}
+		poll := &tgbotapi.Poll{Options: []tgbotapi.PollOption{
+			{Text: "Book: Dune", VoterCount: 1},
+			{Text: "Book: Dune", VoterCount: 2},
+		}}
+		winners := b.winnersFromPoll(session, poll)
+		assert.Equal(t, []models.Winner{{SubscriberID: 3, Title: "Dune", Author: "Herbert"}}, winners)
+	})
+
 	t.Run("zero votes yields no winners", func(t *testing.T) {
 		poll := &tgbotapi.Poll{Options: []tgbotapi.PollOption{
 			{Text: b.pollOptionFor(dune), VoterCount: 0},
@@ -132,3 +172,44 @@ func TestWinnersFromPoll(t *testing.T) {
 		assert.Empty(t, winners)
 	})
 }
+
+func TestNewReading(t *testing.T) {
+	now := time.Now().UTC()
+	dune := &models.Book{Title: "Dune", Author: "Herbert", Description: "Spice.", PhotoID: "photo"}
+	session := sessionWith(
+		&models.Participant{SubscriberID: 1, Step: models.StepDone, Book: dune},
+		&models.Participant{SubscriberID: 2, Step: models.StepSkipped},
+	)
+	winner := models.Winner{SubscriberID: 1, Title: "Dune", Author: "Herbert"}
+
+	t.Run("members are the active subscribers", func(t *testing.T) {
+		b := testBot()
+		b.cfg = &config.AppConfig{TimeForReading: 3600}
+		b.subRepository = &fakeSubscriberRepo{subs: []*models.Subscriber{{ID: 1}, {ID: 2}, {ID: 3}}}
+
+		reading := b.newReading(session, winner, now)
+
+		assert.Equal(t, *dune, reading.Book, "the full submission is copied, not just title/author")
+		assert.Equal(t, int64(1), reading.SubscriberID)
+		assert.Equal(t, now.Add(time.Hour), reading.Deadline)
+		assert.Len(t, reading.Members, 3)
+		for _, m := range reading.Members {
+			assert.Equal(t, models.ReadingInProgress, m.Status)
+			assert.Equal(t, now, m.StartedAt)
+		}
+	})
+
+	t.Run("falls back to participants when subscribers cannot be loaded", func(t *testing.T) {
+		b := testBot()
+		b.cfg = &config.AppConfig{TimeForReading: 3600}
+		b.subRepository = &fakeSubscriberRepo{err: errors.New("boom")}
+
+		reading := b.newReading(session, winner, now)
+
+		ids := []int64{}
+		for _, m := range reading.Members {
+			ids = append(ids, m.SubscriberID)
+		}
+		assert.ElementsMatch(t, []int64{1, 2}, ids)
+	})
+}
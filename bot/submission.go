@@ -0,0 +1,226 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"fmt"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleBack handles /back: a participant still answering the submission
+// questions is asked the previous one again. Going back to the title starts
+// the book over. A submitted book is changed with /edit instead; /back while
+// editing cancels the edit.
+func (b *Bot) handleBack(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	session, p, err := b.submissionFor(uid)
+	if err != nil || p == nil {
+		return err
+	}
+
+	switch i := b.questionIndex(p.Step); {
+	case p.Step == models.StepBook:
+		b.sendMessage(uid, b.messages.NothingToGoBack)
+	case p.Step == models.StepDone && p.Editing == "":
+		b.sendMessage(uid, b.messages.BookSubmittedUseEdit)
+	case p.Step == models.StepDone:
+		p.Editing, p.PendingTitle = "", ""
+		b.persistParticipant(session.ID, p)
+		b.sendMessage(uid, b.messages.EditCancelled)
+	case i <= 1:
+		p.Book, p.Lookup = nil, ""
+		p.Step = models.StepBook
+		b.persistParticipant(session.ID, p)
+		b.sendMessage(uid, b.messages.AskBookTitle)
+	default:
+		q := b.questionnaire()[i-1]
+		p.Step = stepFor(q.Field)
+		b.persistParticipant(session.ID, p)
+		b.askQuestion(uid, q)
+	}
+	return nil
+}
+
+// handleMyBook handles /my_book: the participant is shown their book as it
+// stands, draft or submitted.
+func (b *Bot) handleMyBook(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	_, p, err := b.submissionFor(uid)
+	if err != nil || p == nil {
+		return err
+	}
+	if p.Book == nil {
+		b.sendMessage(uid, b.messages.NoBookYet)
+		return nil
+	}
+
+	text := b.myBookText(p.Book)
+	if attrs := b.bookAttributes(p.Book); len(attrs) > 0 {
+		text += "\n" + strings.Join(attrs, "\n")
+	}
+	if p.Step != models.StepDone {
+		text += "\n\n" + b.messages.MyBookDraft
+	}
+	b.sendMessage(uid, text)
+	return nil
+}
+
+// myBookText renders a participant's book, with a dash for what is missing.
+func (b *Bot) myBookText(bk *models.Book) string {
+	orDash := func(s string) string {
+		if s = strings.TrimSpace(s); s == "" {
+			return "—"
+		}
+		return s
+	}
+	pages, cover := "", ""
+	if bk.Pages > 0 {
+		pages = strconv.Itoa(bk.Pages)
+	}
+	if bk.PhotoID != "" || bk.CoverURL != "" {
+		cover = "✅"
+	}
+	return fmt.Sprintf(b.messages.MyBook, orDash(bk.Title), orDash(bk.Author), orDash(pages), orDash(bk.Description), orDash(cover))
+}
+
+// handleEdit handles /edit <field> [new value] once the book is submitted; the
+// fields are the questionnaire's. With a value the field is changed at once;
+// without one the participant is asked for it (a photo always is), and the
+// book stays in the round meanwhile.
+func (b *Bot) handleEdit(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	session, p, err := b.submissionFor(uid)
+	if err != nil || p == nil {
+		return err
+	}
+	if p.Step != models.StepDone || p.Book == nil {
+		b.sendMessage(uid, b.messages.EditFinishFirst)
+		return nil
+	}
+
+	args := strings.TrimSpace(update.Message.CommandArguments())
+	field, value, _ := strings.Cut(args, " ")
+	field = strings.ToLower(field)
+	value = strings.TrimSpace(value)
+
+	q, ok := b.question(field)
+	if !ok {
+		b.sendEditUsage(uid)
+		return nil
+	}
+
+	if value != "" && q.Type != config.QuestionPhoto {
+		if b.applyEdit(session, p, q, value, nil) {
+			b.persistParticipant(session.ID, p)
+			b.sendMessage(uid, b.messages.BookUpdated)
+		}
+		return nil
+	}
+	p.Editing = field
+	b.persistParticipant(session.ID, p)
+	b.askQuestion(uid, q)
+	return nil
+}
+
+// handleEditAnswer takes the answer to the /edit question the participant was
+// asked. A question dropped from the questionnaire since (by a restart with
+// another config) takes no answer: the edit is called off and the participant
+// is shown what can be edited.
+func (b *Bot) handleEditAnswer(session *models.BookClubSession, p *models.Participant, update *tgbotapi.Update) {
+	q, ok := b.question(p.Editing)
+	if !ok {
+		p.Editing, p.PendingTitle = "", ""
+		b.persistParticipant(session.ID, p)
+		b.sendEditUsage(p.SubscriberID)
+		return
+	}
+	if !b.applyEdit(session, p, q, update.Message.Text, update.Message.Photo) {
+		return
+	}
+	p.Editing = ""
+	b.persistParticipant(session.ID, p)
+	b.sendMessage(p.SubscriberID, b.messages.BookUpdated)
+}
+
+// sendEditUsage tells uid how to use /edit, with the fields they may edit.
+func (b *Bot) sendEditUsage(uid int64) {
+	fields := make([]string, 0, len(b.questionnaire()))
+	for _, q := range b.questionnaire() {
+		fields = append(fields, q.Field)
+	}
+	b.sendMessage(uid, fmt.Sprintf(b.messages.EditUsage, strings.Join(fields, ", ")))
+}
+
+// applyEdit validates a new answer to one question and stores it. A title the
+// club has read is refused as past_winner_policy says; one likely duplicating
+// another participant's book is held back in p.PendingTitle while the
+// participant is shown that book, as when submitting. An optional answer
+// skipped clears the field. A photo is only cleared with an explicit skip, as any
+// other message would skip it while submitting.
+func (b *Bot) applyEdit(session *models.BookClubSession, p *models.Participant, q config.Question, text string, photos []tgbotapi.PhotoSize) bool {
+	if q.Type == config.QuestionPhoto && len(photos) == 0 && strings.TrimSpace(text) != skipAnswer {
+		b.askQuestion(p.SubscriberID, q)
+		return false
+	}
+	value, ok := b.readAnswer(p.SubscriberID, q, text, photos)
+	if !ok {
+		return false
+	}
+	if q.Field == models.BookTitle && value != p.Book.Title && !b.mayPropose(session, p, value) {
+		return false
+	}
+	if q.Field == models.BookTitle && b.findDuplicate(session, value, p.SubscriberID) != nil {
+		p.Editing, p.PendingTitle = q.Field, value
+		b.persistParticipant(session.ID, p)
+		b.sendDuplicateNotice(session, p)
+		return false
+	}
+	setBookField(p.Book, q.Field, value)
+	p.PendingTitle = ""
+	return true
+}
+
+// handleWithdraw handles /withdraw: the participant's book, draft or
+// submitted, is taken out of the round. Unlike /skip they stay in the
+// gathering and may propose another book.
+func (b *Bot) handleWithdraw(update *tgbotapi.Update) error {
+	uid := update.Message.From.ID
+	session, p, err := b.submissionFor(uid)
+	if err != nil || p == nil {
+		return err
+	}
+	if p.Book == nil {
+		b.sendMessage(uid, b.messages.NoBookYet)
+		return nil
+	}
+
+	p.Book, p.Lookup, p.Editing, p.PendingTitle = nil, "", "", ""
+	p.Step = models.StepBook
+	p.SubmittedAt = nil
+	b.persistParticipant(session.ID, p)
+	b.sendMessage(uid, b.messages.BookWithdrawn)
+	return nil
+}
+
+// submissionFor finds the gathering uid takes part in, for the commands that
+// change a submission. When there is none the user has been told why.
+func (b *Bot) submissionFor(uid int64) (*models.BookClubSession, *models.Participant, error) {
+	chatID, session, err := b.activeSessionFor(uid, gatheringParticipant(uid))
+	if err != nil || chatID == 0 {
+		return nil, nil, err
+	}
+	if session == nil || session.Status != models.StatusGathering {
+		b.sendMessage(uid, b.messages.VotingNotStartedOrEnded)
+		return nil, nil, nil
+	}
+
+	p := findParticipant(session, uid)
+	if p == nil || p.Step == models.StepSkipped {
+		b.sendMessage(uid, b.messages.NotParticipantCurrentVoting)
+		return nil, nil, nil
+	}
+	return session, p, nil
+}
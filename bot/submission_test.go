@@ -0,0 +1,162 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBack(t *testing.T) {
+	b, fake, sessions := gatheringRound(t)
+	participant := func() *models.Participant { return findParticipant(sessions.latest(testClubID), 1) }
+
+	b.serve(fake.inject(dm(1, "/back")))
+	assert.Equal(t, "nowhere to go", lastText(fake, 1))
+
+	b.serve(fake.inject(dm(1, "Dune"), dm(1, "Herbert"), dm(1, "/back")))
+	assert.Equal(t, models.StepAuthor, participant().Step)
+	assert.Equal(t, "author?", lastText(fake, 1))
+
+	// The author is answered again and the conversation goes on from there.
+	b.serve(fake.inject(dm(1, "Frank Herbert"), dm(1, "Spice."), dm(1, "/back"), dm(1, "/back")))
+	assert.Equal(t, models.StepAuthor, participant().Step)
+	b.serve(fake.inject(dm(1, "F. Herbert")))
+	assert.Equal(t, models.StepImage, participant().Step, "the description is kept")
+
+	b.serve(fake.inject(dm(1, "/back"), dm(1, "/back"), dm(1, "/back")))
+	p := participant()
+	assert.Equal(t, models.StepBook, p.Step)
+	assert.Nil(t, p.Book, "going back to the title starts over")
+	assert.Equal(t, "title?", lastText(fake, 1))
+
+	b.serve(fake.inject(submit(1, "Solaris", "Lem")...))
+	b.serve(fake.inject(dm(1, "/back")))
+	assert.Equal(t, "use edit", lastText(fake, 1))
+	assert.Equal(t, models.StepDone, participant().Step)
+}
+
+func TestMyBook(t *testing.T) {
+	b, fake, _ := gatheringRound(t)
+
+	b.serve(fake.inject(dm(1, "/my_book")))
+	assert.Equal(t, "no book", lastText(fake, 1))
+
+	b.serve(fake.inject(dm(1, "Dune"), dm(1, "Herbert"), dm(1, "/my_book")))
+	assert.Equal(t, "Dune|Herbert|—|—|—\n\ndraft", lastText(fake, 1))
+
+	b.serve(fake.inject(dm(1, "Spice."), photo(1, "cover"), dm(1, "/my_book")))
+	assert.Equal(t, "Dune|Herbert|—|Spice.|✅", lastText(fake, 1))
+}
+
+func TestEdit(t *testing.T) {
+	t.Run("a field is changed in place or asked for", func(t *testing.T) {
+		b, fake, sessions := gatheringRound(t)
+		b.serve(fake.inject(dm(1, "Dune"), dm(1, "/edit author Frank")))
+		assert.Equal(t, "finish first", lastText(fake, 1))
+
+		b.serve(fake.inject(dm(1, "Herbert"), dm(1, "Spice."), dm(1, "no cover")))
+		b.serve(fake.inject(dm(1, "/edit author Frank Herbert")))
+		p := findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, "Frank Herbert", p.Book.Author)
+		assert.Equal(t, "updated", lastText(fake, 1))
+
+		b.serve(fake.inject(dm(1, "/edit description")))
+		p = findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, models.BookDescription, p.Editing)
+		assert.Equal(t, models.StepDone, p.Step, "the book stays in the round while it is edited")
+		b.serve(fake.inject(dm(1, "Desert planet.")))
+		p = findParticipant(sessions.latest(testClubID), 1)
+		assert.Equal(t, "Desert planet.", p.Book.Description)
+		assert.Empty(t, p.Editing)
+
+		b.serve(fake.inject(dm(1, "/edit cover"), dm(1, "not a photo")))
+		assert.Equal(t, "cover?", lastText(fake, 1))
+		b.serve(fake.inject(photo(1, "dune-cover")))
+		assert.Equal(t, "dune-cover", findParticipant(sessions.latest(testClubID), 1).Book.PhotoID)
+
+		b.serve(fake.inject(dm(1, "/edit title"), dm(1, "/back")))
+		assert.Equal(t, "edit cancelled", lastText(fake, 1))
+		assert.Empty(t, findParticipant(sessions.latest(testClubID), 1).Editing)
+
+		b.serve(fake.inject(dm(1, "/edit pages 600")))
+		assert.Equal(t, "edit title, author, description, cover", lastText(fake, 1))
+	})
+
+	t.Run("a title proposed by someone else is held back", func(t *testing.T) {
+		b, fake, sessions := gatheringRound(t)
+		b.messages.DuplicateFound = "dup of %s by %s"
+		b.serve(fake.inject(submit(1, "Dune", "Herbert")...))
+		b.serve(fake.inject(dm(2, "Solaris"), dm(2, "Lem")))
+
+		b.serve(fake.inject(dm(1, "/edit title Solaris")))
+		assert.Equal(t, "dup of Solaris by Lem", lastText(fake, 1))
+		assert.Equal(t, "Dune", findParticipant(sessions.latest(testClubID), 1).Book.Title)
+		b.serve(fake.inject(dm(1, "/edit title Dune")))
+		assert.Equal(t, "updated", lastText(fake, 1), "a book keeps its own title")
+		b.serve(fake.inject(dm(1, "/edit title Dune Messiah")))
+		assert.Equal(t, "Dune Messiah", findParticipant(sessions.latest(testClubID), 1).Book.Title)
+	})
+
+	t.Run("a held-back title is withdrawn with the book", func(t *testing.T) {
+		b, fake, sessions := gatheringRound(t)
+		b.messages.DuplicateFound = "dup of %s by %s"
+		b.serve(fake.inject(submit(1, "Dune", "Herbert")...))
+		b.serve(fake.inject(dm(2, "Solaris"), dm(2, "Lem")))
+		b.serve(fake.inject(dm(1, "/edit title Solaris")))
+		require.Equal(t, "Solaris", findParticipant(sessions.latest(testClubID), 1).PendingTitle)
+
+		b.serve(fake.inject(dm(1, "/withdraw")))
+		p := findParticipant(sessions.latest(testClubID), 1)
+		assert.Nil(t, p.Book)
+		assert.Empty(t, p.Editing)
+		assert.Empty(t, p.PendingTitle)
+	})
+
+	t.Run("a question dropped from the questionnaire takes no answer", func(t *testing.T) {
+		b, fake, sessions := gatheringRound(t)
+		b.serve(fake.inject(submit(1, "Dune", "Herbert")...))
+		session := sessions.latest(testClubID)
+		p := findParticipant(session, 1)
+		p.Editing = "publisher" // asked under an earlier config
+		require.NoError(t, sessions.UpdateParticipant(context.Background(), session.ID, p))
+
+		b.serve(fake.inject(dm(1, "Ace Books")))
+		assert.Equal(t, "edit title, author, description, cover", lastText(fake, 1))
+		assert.NotContains(t, fake.textsTo(1), "updated")
+		p = findParticipant(sessions.latest(testClubID), 1)
+		assert.Empty(t, p.Editing)
+		assert.Equal(t, &models.Book{Title: "Dune", Author: "Herbert", Description: "About Dune"}, p.Book)
+	})
+}
+
+func TestWithdraw(t *testing.T) {
+	b, fake, sessions := gatheringRound(t)
+	b.serve(fake.inject(dm(1, "/withdraw")))
+	assert.Equal(t, "no book", lastText(fake, 1))
+
+	b.serve(fake.inject(submit(1, "Dune", "Herbert")...))
+	b.serve(fake.inject(dm(1, "/withdraw")))
+	p := findParticipant(sessions.latest(testClubID), 1)
+	assert.Equal(t, models.StepBook, p.Step)
+	assert.Nil(t, p.Book)
+	assert.Nil(t, p.SubmittedAt)
+	assert.Equal(t, "withdrawn", lastText(fake, 1))
+
+	// Member 1 proposes again; once both are done the vote starts without Dune.
+	b.serve(fake.inject(submit(1, "Emma", "Austen")...))
+	b.serve(fake.inject(submit(2, "Solaris", "Lem")...))
+	session := sessions.latest(testClubID)
+	require.Equal(t, models.StatusVoting, session.Status)
+	for _, o := range fake.lastPoll().Options {
+		assert.NotContains(t, o.Text, "Dune")
+	}
+
+	// Nothing can be changed once gathering is over.
+	b.serve(fake.inject(dm(1, "/withdraw"), dm(2, "/edit author Stanisław Lem")))
+	assert.Equal(t, "not gathering", lastText(fake, 1))
+	assert.Equal(t, "not gathering", lastText(fake, 2))
+	assert.Equal(t, models.StepDone, findParticipant(sessions.latest(testClubID), 1).Step)
+}
@@ -0,0 +1,116 @@
+package bot
+
+import (
+	"BookClubBot/internal/models"
+	"cmp"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+)
+
+// startRunoff sends a runoff poll between the tied books and makes it the
+// session's current poll, with its own deadline. It runs under b.mu, after the
+// tied poll has been stopped.
+func (b *Bot) startRunoff(session *models.BookClubSession, tied []models.Winner) error {
+	options := make([]models.PollOption, len(tied))
+	titles := make([]string, len(tied))
+	for i, w := range tied {
+		options[i] = models.PollOption{
+			Text:         b.pollOptionFor(&models.Book{Title: w.Title, Author: w.Author}),
+			SubscriberID: w.SubscriberID,
+			Title:        w.Title,
+			Author:       w.Author,
+		}
+		titles[i] = w.Title
+	}
+
+	next := *session.Voting
+	next.TelegramPollID, next.PollID, next.ClosedAt = 0, "", nil
+	next.Runoff = true
+	if err := b.startVotingStage(session, &next, options); err != nil {
+		return err
+	}
+	if err := b.sessionRepository.StartVoting(context.Background(), session.ID, &next); err != nil {
+		return err
+	}
+	tieBreak := &models.TieBreak{Method: models.TieBreakRunoff, Tied: tied}
+	if err := b.sessionRepository.SetTieBreak(context.Background(), session.ID, tieBreak); err != nil {
+		log.Printf("cannot record the runoff: %v", err)
+	}
+	b.sendMessage(session.ChatID, fmt.Sprintf(b.messages.RunoffStarted, strings.Join(titles, ", ")))
+	return nil
+}
+
+// breakTie resolves a tie without another poll: by the proposer who has waited
+// longest when so configured, otherwise by a draw seeded from the session. It
+// returns the single winner and how the tie was broken.
+func (b *Bot) breakTie(session *models.BookClubSession, tied []models.Winner) (models.Winner, *models.TieBreak) {
+	if b.cfg.TieBreak == models.TieBreakLongestWaiting {
+		past, err := b.sessionRepository.ListPastWinners(context.Background(), session.ChatID)
+		if err == nil {
+			return longestWaiting(session, tied, past), &models.TieBreak{Method: models.TieBreakLongestWaiting, Tied: tied}
+		}
+		log.Printf("cannot load past sessions, drawing lots instead: %v", err)
+	}
+	seed := drawSeed(session)
+	return drawLot(tied, seed), &models.TieBreak{Method: models.TieBreakRandom, Tied: tied, Seed: seed}
+}
+
+// drawSeed derives the draw's seed from the session ID, so a draw can be
+// repeated from the stored session.
+func drawSeed(session *models.BookClubSession) int64 {
+	h := fnv.New64a()
+	h.Write(session.ID[:])
+	return int64(h.Sum64())
+}
+
+// drawLot picks one of the tied books at random. The books are ordered by
+// proposer first, so the result depends only on the seed and the tied set.
+func drawLot(tied []models.Winner, seed int64) models.Winner {
+	sorted := slices.Clone(tied)
+	slices.SortFunc(sorted, func(a, b models.Winner) int { return cmp.Compare(a.SubscriberID, b.SubscriberID) })
+	return sorted[rand.New(rand.NewSource(seed)).Intn(len(sorted))]
+}
+
+// longestWaiting picks the tied book whose proposer has gone longest without a
+// winning book in the club's past rounds: one who has never won beats one who
+// has, and otherwise the older last win wins. Remaining ties go to whoever
+// submitted their book first this round.
+func longestWaiting(session *models.BookClubSession, tied []models.Winner, past []*models.BookClubSession) models.Winner {
+	lastWin := make(map[int64]time.Time)
+	for _, s := range past {
+		for _, w := range s.Winners {
+			if at, ok := lastWin[w.SubscriberID]; !ok || s.CreatedAt.After(at) {
+				lastWin[w.SubscriberID] = s.CreatedAt
+			}
+		}
+	}
+	submitted := func(id int64) time.Time {
+		if p := findParticipant(session, id); p != nil && p.SubmittedAt != nil {
+			return *p.SubmittedAt
+		}
+		return time.Time{}
+	}
+
+	sorted := slices.Clone(tied)
+	slices.SortStableFunc(sorted, func(a, b models.Winner) int {
+		aWon, aOK := lastWin[a.SubscriberID]
+		bWon, bOK := lastWin[b.SubscriberID]
+		switch {
+		case aOK != bOK:
+			if !aOK {
+				return -1
+			}
+			return 1
+		case aOK && !aWon.Equal(bWon):
+			return aWon.Compare(bWon)
+		}
+		return submitted(a.SubscriberID).Compare(submitted(b.SubscriberID))
+	})
+	return sorted[0]
+}
@@ -0,0 +1,147 @@
+package bot
+
+import (
+	"BookClubBot/config"
+	"BookClubBot/internal/models"
+	"context"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tiedVote has members 1–3 of votingRound split evenly between the two books.
+func tiedVote(fake *fakeMessenger, pollID string) []tgbotapi.Update {
+	return []tgbotapi.Update{
+		fake.vote(1, pollID, "Dune"),
+		fake.vote(2, pollID, "Solaris"),
+		fake.vote(3, pollID, "Dune", "Solaris"),
+	}
+}
+
+func TestTieBreak(t *testing.T) {
+	t.Run("runoff poll", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600, TimeForRunoff: 600})
+		b.messages.RunoffStarted = "runoff: %s"
+		first := fake.lastPoll()
+
+		b.serve(fake.inject(tiedVote(fake, first.ID)...))
+
+		session := sessions.latest(testClubID)
+		require.Equal(t, models.StatusVoting, session.Status, "the round continues with a runoff")
+		assert.True(t, first.IsClosed)
+		assert.True(t, session.Voting.Runoff)
+		assert.Equal(t, 10*time.Minute, session.Voting.Deadline.Sub(session.Voting.StartedAt))
+		require.NotNil(t, session.TieBreak)
+		assert.Equal(t, models.TieBreakRunoff, session.TieBreak.Method)
+		assert.Len(t, session.TieBreak.Tied, 2)
+		assert.Empty(t, session.Winners)
+
+		runoff := fake.lastPoll()
+		assert.NotEqual(t, first.ID, runoff.ID)
+		assert.Equal(t, runoff.ID, session.Voting.PollID)
+		assert.Len(t, runoff.Options, 2)
+		assert.False(t, runoff.AllowsMultipleAnswers)
+		assert.Contains(t, fake.textsTo(testClubID)[len(fake.textsTo(testClubID))-1], "runoff: ")
+
+		b.serve(fake.inject(
+			fake.vote(1, runoff.ID, "Solaris"),
+			fake.vote(2, runoff.ID, "Solaris"),
+			fake.vote(3, runoff.ID, "Dune"),
+		))
+
+		session = sessions.latest(testClubID)
+		assert.Equal(t, models.StatusReading, session.Status)
+		require.Len(t, session.Winners, 1)
+		assert.Equal(t, "Solaris", session.Winners[0].Title)
+	})
+
+	t.Run("a tied runoff is drawn", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600})
+		b.serve(fake.inject(tiedVote(fake, fake.lastPoll().ID)...))
+		runoff := fake.lastPoll()
+		b.serve(fake.inject(fake.vote(1, runoff.ID, "Dune"), fake.vote(2, runoff.ID, "Solaris")))
+
+		session := sessions.latest(testClubID)
+		b.recoverVoting(session, session.Voting.Deadline)
+
+		session = sessions.latest(testClubID)
+		assert.Equal(t, models.StatusReading, session.Status)
+		require.Len(t, session.Winners, 1)
+		require.NotNil(t, session.TieBreak)
+		assert.Equal(t, models.TieBreakRandom, session.TieBreak.Method)
+		assert.Equal(t, drawSeed(session), session.TieBreak.Seed)
+		assert.Equal(t, drawLot(session.TieBreak.Tied, session.TieBreak.Seed), session.Winners[0], "the draw can be repeated")
+	})
+
+	t.Run("random draw", func(t *testing.T) {
+		b, fake, sessions := votingRound(t, &config.AppConfig{TimeForTelegramPoll: 3600, TieBreak: models.TieBreakRandom})
+		b.messages.TieBrokenByDraw = "tie between %s"
+		polls := len(fake.polls)
+
+		b.serve(fake.inject(tiedVote(fake, fake.lastPoll().ID)...))
+
+		session := sessions.latest(testClubID)
+		assert.Equal(t, models.StatusReading, session.Status)
+		assert.Len(t, fake.polls, polls, "no runoff poll")
+		require.Len(t, session.Winners, 1)
+		require.NotNil(t, session.TieBreak)
+		assert.Equal(t, models.TieBreakRandom, session.TieBreak.Method)
+		group := fake.textsTo(testClubID)
+		assert.Contains(t, group[len(group)-2], "tie between ", "the tie break is announced with the winner")
+		assert.Contains(t, group[len(group)-2], session.Winners[0].Title)
+	})
+
+	t.Run("longest waiting proposer", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.TieBreak = models.TieBreakLongestWaiting
+		b, fake, sessions := clubWithMembers(t, cfg, 1, 2, 3)
+
+		// A past round won by member 1.
+		past := &models.BookClubSession{ChatID: testClubID, Status: models.StatusCompleted, Winners: []models.Winner{{SubscriberID: 1, Title: "Emma"}}}
+		require.NoError(t, sessions.CreateSession(context.Background(), past))
+
+		updates := []tgbotapi.Update{dm(1, "/start_vote")}
+		updates = append(updates, submit(1, "Dune", "Herbert")...)
+		updates = append(updates, submit(2, "Solaris", "Lem")...)
+		updates = append(updates, dm(3, "/skip"))
+		b.serve(fake.inject(updates...))
+		b.serve(fake.inject(tiedVote(fake, fake.lastPoll().ID)...))
+
+		session := sessions.latest(testClubID)
+		assert.Equal(t, models.StatusReading, session.Status)
+		require.Len(t, session.Winners, 1)
+		assert.Equal(t, "Solaris", session.Winners[0].Title, "member 2 has never won")
+		assert.Equal(t, models.TieBreakLongestWaiting, session.TieBreak.Method)
+	})
+}
+
+func TestDrawLot(t *testing.T) {
+	tied := []models.Winner{{SubscriberID: 3, Title: "C"}, {SubscriberID: 1, Title: "A"}, {SubscriberID: 2, Title: "B"}}
+	reordered := []models.Winner{tied[1], tied[2], tied[0]}
+	for seed := int64(0); seed < 20; seed++ {
+		assert.Equal(t, drawLot(tied, seed), drawLot(reordered, seed), "the order of the tie does not matter")
+	}
+}
+
+func TestLongestWaiting(t *testing.T) {
+	now := time.Now().UTC()
+	early, late := now.Add(-time.Hour), now
+	session := sessionWith(
+		&models.Participant{SubscriberID: 1, SubmittedAt: &late},
+		&models.Participant{SubscriberID: 2, SubmittedAt: &early},
+		&models.Participant{SubscriberID: 3, SubmittedAt: &early},
+	)
+	past := []*models.BookClubSession{
+		{CreatedAt: now.AddDate(0, -1, 0), Winners: []models.Winner{{SubscriberID: 2}}},
+		{CreatedAt: now.AddDate(0, -6, 0), Winners: []models.Winner{{SubscriberID: 3}}},
+		{CreatedAt: now.AddDate(0, -9, 0), Winners: []models.Winner{{SubscriberID: 2}}},
+	}
+	w := func(id int64) models.Winner { return models.Winner{SubscriberID: id} }
+
+	assert.Equal(t, w(3), longestWaiting(session, []models.Winner{w(2), w(3)}, past), "member 3 last won longer ago")
+	assert.Equal(t, w(1), longestWaiting(session, []models.Winner{w(2), w(1)}, past), "member 1 has never won")
+	assert.Equal(t, w(2), longestWaiting(session, []models.Winner{w(1), w(2)}, nil), "first to submit")
+}
@@ -24,6 +24,12 @@ func main() {
 		log.Fatal(err)
 	}
 
+	for _, q := range cfg.Questionnaire {
+		if msg.Get(q.Message) == "" {
+			log.Fatalf("questionnaire: no message %q for field %q", q.Message, q.Field)
+		}
+	}
+
 	db, err := repository.InitMongoDB(cfg.MongoURI, cfg.DBName)
 	if err != nil {
 		log.Fatalf("error during initialisation of mongodb : '%v'", err)
@@ -34,6 +40,13 @@ func main() {
 		log.Fatal(err)
 	}
 
+	clubRepository, err := repository.NewClubRepository(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Settings now only hold the group ID of a pre-multi-club deployment, read
+	// once at startup to migrate it into the clubs collection.
 	settingsRepository, err := repository.NewSettingsRepository(db)
 
 	if err != nil {
@@ -41,7 +54,7 @@ func main() {
 	}
 
 	// Session persistence layer. Its indexes — notably the unique "one active
-	// session" index — must exist before sessions are written, so create them
+	// session per club" index — must exist before sessions are written, so create them
 	// at startup.
 	sessionRepository, err := repository.NewSessionRepository(db)
 	if err != nil {
@@ -51,6 +64,16 @@ func main() {
 		log.Fatalf("error ensuring session indexes: '%v'", err)
 	}
 
-	b := bot.NewBot(cfg, msg, subRepository, settingsRepository, sessionRepository)
+	// The book catalog shared by every club; its unique (titleKey, authorKey)
+	// index keeps one document per book.
+	bookRepository, err := repository.NewBookRepository(db)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := bookRepository.EnsureIndexes(context.Background()); err != nil {
+		log.Fatalf("error ensuring book indexes: '%v'", err)
+	}
+
+	b := bot.NewBot(cfg, msg, subRepository, clubRepository, settingsRepository, sessionRepository, bookRepository)
 	b.Run()
 }
@@ -1,10 +1,15 @@
 package config
 
 import (
+	"BookClubBot/internal/models"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -12,16 +17,32 @@ import (
 const folder = "./config"
 
 type AppConfig struct {
-	GroupId               int64
-	TimeToGatherBooks     int `json:"time_to_gather_books"`    // seconds
-	NotifyBeforeGathering int `json:"notify_before_gathering"` // seconds
-	TimeForTelegramPoll   int `json:"time_for_telegram_poll"`  // seconds
-	NotifyBeforePoll      int `json:"notify_before_poll"`      //seconds
-	LongPollingTimeout    int `json:"long_polling_timeout"`    // seconds
+	TimeToGatherBooks     int   `json:"time_to_gather_books"`    // seconds
+	NotifyBeforeGathering int   `json:"notify_before_gathering"` // seconds
+	TimeForTelegramPoll   int   `json:"time_for_telegram_poll"`  // seconds
+	NotifyBeforePoll      int   `json:"notify_before_poll"`      //seconds
+	TimeForRunoff         int   `json:"time_for_runoff"`         // seconds; 0 uses the round's voting duration
+	MaxChoices            int   `json:"max_choices"`             // books a voter may pick in a poll; 0 means 2
+	TimeForReading        int   `json:"time_for_reading"`        // seconds
+	ReadingMilestones     []int `json:"reading_milestones"`      // percent of the reading period elapsed
+	LongPollingTimeout    int   `json:"long_polling_timeout"`    // seconds
 	TKey                  string
-	MongoURI              string `json:"mongo_uri"`
-	DBName                string `json:"db_name"`
-	DebugMode             bool   `json:"debug_mode"`
+	MongoURI              string  `json:"mongo_uri"`
+	DBName                string  `json:"db_name"`
+	DebugMode             bool    `json:"debug_mode"`
+	Admins                []int64 `json:"admins"`              // user IDs allowed to run organizer commands in every club
+	SyncChatAdmins        bool    `json:"sync_chat_admins"`    // each group's chat administrators organize that club
+	TieBreak              string  `json:"tie_break"`           // "runoff" (default), "random" or "longest_waiting"
+	VotingMode            string  `json:"voting_mode"`         // "poll" (default) or "ranked"
+	VoterEligibility      string  `json:"voter_eligibility"`   // "subscribers" (default), "participants" or "anyone"
+	MetadataURL           string  `json:"metadata_url"`        // an Open Library–compatible API; empty disables book lookups
+	CoversURL             string  `json:"covers_url"`          // its covers server
+	DuplicateThreshold    float64 `json:"duplicate_threshold"` // title similarity (0..1) marking a likely duplicate; 0 means 0.85
+	PastWinnerPolicy      string  `json:"past_winner_policy"`  // "reject" (default), "warn", "after_months" or "allow"
+	PastWinnerMonths      int     `json:"past_winner_months"`  // months a past winner is blocked with "after_months"; 0 means 12
+	// Questionnaire is the book submission conversation, in order; empty means
+	// DefaultQuestionnaire.
+	Questionnaire []Question `json:"questionnaire"`
 }
 
 func LoadConfig() (*AppConfig, error) {
@@ -43,9 +64,49 @@ func LoadConfig() (*AppConfig, error) {
 		cfg.MongoURI = mongoURL
 	}
 
+	// ADMINS, comma-separated user IDs, adds to the JSON value, so a deployment
+	// names its organizers without committing them.
+	if admins := os.Getenv("ADMINS"); admins != "" {
+		ids, err := parseAdmins(admins)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Admins = append(cfg.Admins, ids...)
+	}
+	if err := checkAdmins(cfg); err != nil {
+		return nil, err
+	}
+
 	return cfg, nil
 }
 
+// parseAdmins reads a comma-separated list of user IDs.
+func parseAdmins(s string) ([]int64, error) {
+	var ids []int64
+	for _, field := range strings.Split(s, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("ADMINS: %q is not a user ID", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// checkAdmins rejects a config with no one to organize a round: no admins and
+// no chat administrators synced. With the sync on but no admins, a club whose
+// sync fails has no organizer, so that is logged as a warning.
+func checkAdmins(cfg *AppConfig) error {
+	if len(cfg.Admins) > 0 {
+		return nil
+	}
+	if !cfg.SyncChatAdmins {
+		return fmt.Errorf("admins: none listed and sync_chat_admins is off, so no one can start a round")
+	}
+	log.Printf("WARNING: no admins listed; only the groups' chat administrators can start a round")
+	return nil
+}
+
 func determineEnv() string {
 	env := os.Getenv("APP_ENV")
 	if env == "" {
@@ -76,6 +137,51 @@ func parsreAppConfig(r io.Reader) (*AppConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("Cannot unmarshal data to AppConfig during parsing App config")
 	}
+	if err := validateQuestionnaire(res.Questionnaire); err != nil {
+		return nil, err
+	}
+	if err := validateChoices(&res); err != nil {
+		return nil, err
+	}
+	if err := validateLimits(&res); err != nil {
+		return nil, err
+	}
 
 	return &res, nil
 }
+
+// validateChoices rejects a setting that takes one of a few values but has
+// another; an unset one takes its default.
+func validateChoices(cfg *AppConfig) error {
+	for _, s := range []struct {
+		key, value string
+		allowed    []string
+	}{
+		{"tie_break", cfg.TieBreak, []string{models.TieBreakRunoff, models.TieBreakRandom, models.TieBreakLongestWaiting}},
+		{"voting_mode", cfg.VotingMode, []string{models.VotingPoll, models.VotingRanked}},
+		{"voter_eligibility", cfg.VoterEligibility, []string{models.EligibleSubscribers, models.EligibleParticipants, models.EligibleAnyone}},
+		{"past_winner_policy", cfg.PastWinnerPolicy, []string{models.PastWinnerReject, models.PastWinnerWarn, models.PastWinnerAfterMonths, models.PastWinnerAllow}},
+	} {
+		if s.value != "" && !slices.Contains(s.allowed, s.value) {
+			return fmt.Errorf("%s: unknown value %q, want one of %s", s.key, s.value, strings.Join(s.allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// validateLimits rejects a number outside the range its setting allows; an
+// unset (zero) one takes its default.
+func validateLimits(cfg *AppConfig) error {
+	if cfg.MaxChoices < 0 {
+		return fmt.Errorf("max_choices: %d is negative", cfg.MaxChoices)
+	}
+	if cfg.DuplicateThreshold < 0 || cfg.DuplicateThreshold > 1 {
+		return fmt.Errorf("duplicate_threshold: %g is outside (0, 1]", cfg.DuplicateThreshold)
+	}
+	for _, m := range cfg.ReadingMilestones {
+		if m < 0 || m > 100 {
+			return fmt.Errorf("reading_milestones: %d is outside 0..100", m)
+		}
+	}
+	return nil
+}
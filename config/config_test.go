@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseChoices(t *testing.T) {
+	cfg, err := parsreAppConfig(strings.NewReader(`{"tie_break": "random"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "random", cfg.TieBreak)
+
+	_, err = parsreAppConfig(strings.NewReader(`{"voting_mode": "ranked"}`))
+	assert.NoError(t, err, "voting_mode")
+
+	_, err = parsreAppConfig(strings.NewReader(`{"voter_eligibility": "anyone"}`))
+	assert.NoError(t, err, "voter_eligibility")
+
+	_, err = parsreAppConfig(strings.NewReader(`{"past_winner_policy": "after_months"}`))
+	assert.NoError(t, err, "past_winner_policy")
+
+	_, err = parsreAppConfig(strings.NewReader(`{}`))
+	assert.NoError(t, err, "unset choices take their defaults")
+
+	for _, bad := range []string{
+		`{"tie_break": "coin"}`,
+		`{"voting_mode": "Ranked"}`,
+		`{"voter_eligibility": "members"}`,
+		`{"past_winner_policy": "never"}`,
+	} {
+		_, err := parsreAppConfig(strings.NewReader(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestAdmins(t *testing.T) {
+	ids, err := parseAdmins("101, 202")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{101, 202}, ids)
+	_, err = parseAdmins("101,@organizer")
+	assert.Error(t, err)
+
+	assert.NoError(t, checkAdmins(&AppConfig{Admins: []int64{101}}))
+	assert.NoError(t, checkAdmins(&AppConfig{SyncChatAdmins: true}))
+	assert.Error(t, checkAdmins(&AppConfig{}), "no one could start a round")
+}
+
+func TestParseLimits(t *testing.T) {
+	_, err := parsreAppConfig(strings.NewReader(`{"max_choices": 3}`))
+	assert.NoError(t, err)
+	_, err = parsreAppConfig(strings.NewReader(`{"duplicate_threshold": 1}`))
+	assert.NoError(t, err)
+	_, err = parsreAppConfig(strings.NewReader(`{"reading_milestones": [0, 50, 100]}`))
+	assert.NoError(t, err)
+
+	for _, bad := range []string{
+		`{"max_choices": -1}`,
+		`{"duplicate_threshold": 1.5}`,
+		`{"duplicate_threshold": -0.5}`,
+		`{"reading_milestones": [50, 120]}`,
+	} {
+		_, err := parsreAppConfig(strings.NewReader(bad))
+		assert.Error(t, err, bad)
+	}
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"BookClubBot/internal/models"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Question types of the submission questionnaire.
+const (
+	QuestionText   = "text"
+	QuestionNumber = "number"
+	QuestionPhoto  = "photo"
+	QuestionURL    = "url"
+	QuestionChoice = "choice"
+)
+
+// Question is one question of the book submission questionnaire. Its answer
+// fills the book field of the same name (see models.BookTitle and friends) or,
+// for any other field, an entry of the book's attributes.
+//
+// Validation depends on the type: a regular expression a text or url answer
+// must match, or a "min..max" range for a number, either end optional.
+type Question struct {
+	Field      string   `json:"field"`
+	Type       string   `json:"type"`
+	Required   bool     `json:"required"`
+	Validation string   `json:"validation,omitempty"`
+	Choices    []string `json:"choices,omitempty"` // the answers a choice question accepts
+	Message    string   `json:"message"`           // key of the question's text in the messages file
+}
+
+// DefaultQuestionnaire asks what the bot has always asked: title, author,
+// description and an optional cover.
+var DefaultQuestionnaire = []Question{
+	{Field: models.BookTitle, Type: QuestionText, Required: true, Message: "ask_book_title"},
+	{Field: models.BookAuthor, Type: QuestionText, Required: true, Message: "who_is_author"},
+	{Field: models.BookDescription, Type: QuestionText, Required: true, Message: "write_book_description"},
+	{Field: models.BookCover, Type: QuestionPhoto, Message: "attach_cover_photo"},
+}
+
+// bookFieldTypes are the types the book's own fields take.
+var bookFieldTypes = map[string]string{
+	models.BookTitle:       QuestionText,
+	models.BookAuthor:      QuestionText,
+	models.BookDescription: QuestionText,
+	models.BookCover:       QuestionPhoto,
+	models.BookPages:       QuestionNumber,
+}
+
+// reservedFields are names a question cannot take, as they are the steps the
+// submission conversation is in besides its questions.
+var reservedFields = []string{models.StepBook, models.StepLookup, models.StepConfirm, models.StepDuplicate, models.StepImage, models.StepDone, models.StepSkipped}
+
+// Range parses a number question's "min..max" validation. A missing end is
+// nil.
+func (q Question) Range() (min, max *int, err error) {
+	if q.Validation == "" {
+		return nil, nil, nil
+	}
+	lo, hi, ok := strings.Cut(q.Validation, "..")
+	if !ok {
+		return nil, nil, fmt.Errorf("range %q is not min..max", q.Validation)
+	}
+	parse := func(s string) (*int, error) {
+		if s = strings.TrimSpace(s); s == "" {
+			return nil, nil
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("range %q: %w", q.Validation, err)
+		}
+		return &n, nil
+	}
+	if min, err = parse(lo); err != nil {
+		return nil, nil, err
+	}
+	if max, err = parse(hi); err != nil {
+		return nil, nil, err
+	}
+	return min, max, nil
+}
+
+// validateQuestionnaire checks a questionnaire from the config. The title
+// comes first, as the gathering invitation asks for it.
+func validateQuestionnaire(questions []Question) error {
+	if len(questions) == 0 {
+		return nil
+	}
+	if questions[0].Field != models.BookTitle || !questions[0].Required {
+		return fmt.Errorf("questionnaire: the first question must be the required %q", models.BookTitle)
+	}
+	seen := make(map[string]bool)
+	for _, q := range questions {
+		switch {
+		case q.Field == "":
+			return fmt.Errorf("questionnaire: a question has no field")
+		case seen[q.Field]:
+			return fmt.Errorf("questionnaire: field %q is asked twice", q.Field)
+		case q.Message == "":
+			return fmt.Errorf("questionnaire: field %q has no message", q.Field)
+		}
+		seen[q.Field] = true
+		for _, r := range reservedFields {
+			if q.Field == r {
+				return fmt.Errorf("questionnaire: field %q is reserved", q.Field)
+			}
+		}
+		if t, ok := bookFieldTypes[q.Field]; ok && q.Type != t {
+			return fmt.Errorf("questionnaire: field %q must be of type %q", q.Field, t)
+		}
+
+		switch q.Type {
+		case QuestionText, QuestionURL:
+			if _, err := regexp.Compile(q.Validation); err != nil {
+				return fmt.Errorf("questionnaire: field %q: %w", q.Field, err)
+			}
+		case QuestionNumber:
+			if _, _, err := q.Range(); err != nil {
+				return fmt.Errorf("questionnaire: field %q: %w", q.Field, err)
+			}
+		case QuestionChoice:
+			if len(q.Choices) == 0 {
+				return fmt.Errorf("questionnaire: choice field %q has no choices", q.Field)
+			}
+		case QuestionPhoto:
+		default:
+			return fmt.Errorf("questionnaire: field %q has unknown type %q", q.Field, q.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQuestionnaire(t *testing.T) {
+	parse := func(questionnaire string) (*AppConfig, error) {
+		return parsreAppConfig(strings.NewReader(`{"questionnaire": ` + questionnaire + `}`))
+	}
+
+	cfg, err := parse(`[
+		{"field": "title", "type": "text", "required": true, "message": "ask_book_title"},
+		{"field": "pages", "type": "number", "validation": "1..", "message": "ask_page_count"},
+		{"field": "genre", "type": "choice", "choices": ["a", "b"], "message": "ask_genre"}
+	]`)
+	require.NoError(t, err)
+	require.Len(t, cfg.Questionnaire, 3)
+	min, max, err := cfg.Questionnaire[1].Range()
+	require.NoError(t, err)
+	assert.Equal(t, 1, *min)
+	assert.Nil(t, max)
+
+	for name, questionnaire := range map[string]string{
+		"title not first":    `[{"field": "author", "type": "text", "required": true, "message": "m"}]`,
+		"optional title":     `[{"field": "title", "type": "text", "message": "m"}]`,
+		"asked twice":        `[{"field": "title", "type": "text", "required": true, "message": "m"}, {"field": "title", "type": "text", "message": "m"}]`,
+		"reserved field":     `[{"field": "title", "type": "text", "required": true, "message": "m"}, {"field": "done", "type": "text", "message": "m"}]`,
+		"wrong book type":    `[{"field": "title", "type": "text", "required": true, "message": "m"}, {"field": "cover", "type": "text", "message": "m"}]`,
+		"unknown type":       `[{"field": "title", "type": "text", "required": true, "message": "m"}, {"field": "x", "type": "date", "message": "m"}]`,
+		"choice, no choices": `[{"field": "title", "type": "text", "required": true, "message": "m"}, {"field": "x", "type": "choice", "message": "m"}]`,
+		"bad regexp":         `[{"field": "title", "type": "text", "required": true, "message": "m", "validation": "("}]`,
+		"bad range":          `[{"field": "title", "type": "text", "required": true, "message": "m"}, {"field": "x", "type": "number", "validation": "5", "message": "m"}]`,
+		"no message":         `[{"field": "title", "type": "text", "required": true}]`,
+	} {
+		_, err := parse(questionnaire)
+		assert.Error(t, err, name)
+	}
+}
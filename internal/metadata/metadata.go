@@ -0,0 +1,68 @@
+// Package metadata looks books up in external catalogs, so a member proposing a
+// book can confirm what the catalog knows instead of typing every detail.
+package metadata
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrNotFound is returned when a catalog has no book matching a query.
+var ErrNotFound = errors.New("book not found")
+
+// Book is what a catalog knows about a book. Any field but Title may be empty.
+type Book struct {
+	Title       string
+	Author      string
+	Description string
+	Pages       int
+	CoverURL    string
+}
+
+// Provider looks up a book by an ISBN or a free-text query, usually its title.
+// It returns ErrNotFound when nothing matches.
+type Provider interface {
+	LookupISBN(ctx context.Context, isbn string) (*Book, error)
+	Search(ctx context.Context, query string) (*Book, error)
+}
+
+// NormalizeISBN strips the hyphens and spaces from an ISBN-10 or ISBN-13 and
+// reports whether what is left is a valid one, check digit included.
+func NormalizeISBN(s string) (string, bool) {
+	isbn := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(s)))
+	switch len(isbn) {
+	case 10:
+		sum := 0
+		for i, r := range isbn {
+			d := int(r - '0')
+			if r == 'X' && i == 9 {
+				d = 10
+			} else if d < 0 || d > 9 {
+				return "", false
+			}
+			sum += (10 - i) * d
+		}
+		if sum%11 != 0 {
+			return "", false
+		}
+		return isbn, true
+	case 13:
+		sum := 0
+		for i, r := range isbn {
+			d := int(r - '0')
+			if d < 0 || d > 9 {
+				return "", false
+			}
+			if i%2 == 1 {
+				d *= 3
+			}
+			sum += d
+		}
+		if sum%10 != 0 {
+			return "", false
+		}
+		return isbn, true
+	}
+	return "", false
+}
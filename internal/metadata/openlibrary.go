@@ -0,0 +1,123 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OpenLibrary is a Provider backed by the Open Library API, or any server
+// speaking its search and works endpoints.
+type OpenLibrary struct {
+	baseURL   string // e.g. https://openlibrary.org
+	coversURL string // e.g. https://covers.openlibrary.org
+	client    *http.Client
+}
+
+func NewOpenLibrary(baseURL, coversURL string) *OpenLibrary {
+	return &OpenLibrary{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		coversURL: strings.TrimRight(coversURL, "/"),
+		client:    &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// searchResponse is the part of /search.json the bot reads.
+type searchResponse struct {
+	Docs []struct {
+		Key        string   `json:"key"` // the work, e.g. /works/OL45804W
+		Title      string   `json:"title"`
+		AuthorName []string `json:"author_name"`
+		Pages      int      `json:"number_of_pages_median"`
+		CoverID    int      `json:"cover_i"`
+	} `json:"docs"`
+}
+
+// work is the part of a work record the bot reads. Its description is either a
+// string or a {"type", "value"} text object.
+type work struct {
+	Description json.RawMessage `json:"description"`
+}
+
+// LookupISBN finds the book an ISBN belongs to.
+func (o *OpenLibrary) LookupISBN(ctx context.Context, isbn string) (*Book, error) {
+	return o.search(ctx, url.Values{"isbn": {isbn}})
+}
+
+// Search finds the best match for a free-text query.
+func (o *OpenLibrary) Search(ctx context.Context, query string) (*Book, error) {
+	return o.search(ctx, url.Values{"q": {query}})
+}
+
+// search takes the first search hit and fills in its description from the
+// work record.
+func (o *OpenLibrary) search(ctx context.Context, params url.Values) (*Book, error) {
+	params.Set("limit", "1")
+	params.Set("fields", "key,title,author_name,number_of_pages_median,cover_i")
+	var res searchResponse
+	if err := o.get(ctx, "/search.json?"+params.Encode(), &res); err != nil {
+		return nil, err
+	}
+	if len(res.Docs) == 0 || res.Docs[0].Title == "" {
+		return nil, ErrNotFound
+	}
+	doc := res.Docs[0]
+
+	book := &Book{Title: doc.Title, Author: strings.Join(doc.AuthorName, ", "), Pages: doc.Pages}
+	if doc.CoverID > 0 {
+		book.CoverURL = fmt.Sprintf("%s/b/id/%d-L.jpg", o.coversURL, doc.CoverID)
+	}
+	if strings.HasPrefix(doc.Key, "/works/") {
+		var w work
+		if err := o.get(ctx, doc.Key+".json", &w); err != nil && err != ErrNotFound {
+			return nil, err
+		}
+		book.Description = description(w.Description)
+	}
+	return book, nil
+}
+
+// description reads a work's description in either of its shapes.
+func description(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return strings.TrimSpace(s)
+	}
+	var text struct {
+		Value string `json:"value"`
+	}
+	if json.Unmarshal(raw, &text) == nil {
+		return strings.TrimSpace(text.Value)
+	}
+	return ""
+}
+
+// get fetches path from the API and decodes its JSON into v. A 404 is
+// ErrNotFound.
+func (o *OpenLibrary) get(ctx context.Context, path string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("open library: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("open library: GET %s: %s", path, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("open library: decode %s: %w", path, err)
+	}
+	return nil
+}
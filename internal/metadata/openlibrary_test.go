@@ -0,0 +1,115 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubOpenLibrary serves canned Open Library responses and records the search
+// queries it gets.
+func stubOpenLibrary(t *testing.T, routes map[string]string) (*OpenLibrary, *[]string) {
+	t.Helper()
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/search.json" {
+			queries = append(queries, r.URL.RawQuery)
+		}
+		body, ok := routes[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if body == "" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return NewOpenLibrary(srv.URL+"/", "https://covers.example"), &queries
+}
+
+const duneSearch = `{"numFound": 1, "docs": [{"key": "/works/OL893415W", "title": "Dune",
+	"author_name": ["Frank Herbert"], "number_of_pages_median": 604, "cover_i": 11481354}]}`
+
+func TestOpenLibrary(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("an ISBN is looked up with its work's description", func(t *testing.T) {
+		ol, queries := stubOpenLibrary(t, map[string]string{
+			"/search.json":          duneSearch,
+			"/works/OL893415W.json": `{"title": "Dune", "description": {"type": "/type/text", "value": "Desert planet. "}}`,
+		})
+		book, err := ol.LookupISBN(ctx, "9780441172719")
+		require.NoError(t, err)
+		assert.Equal(t, &Book{
+			Title:       "Dune",
+			Author:      "Frank Herbert",
+			Description: "Desert planet.",
+			Pages:       604,
+			CoverURL:    "https://covers.example/b/id/11481354-L.jpg",
+		}, book)
+		require.Len(t, *queries, 1)
+		assert.Contains(t, (*queries)[0], "isbn=9780441172719")
+	})
+
+	t.Run("a title is searched; a plain description and no cover are fine", func(t *testing.T) {
+		ol, queries := stubOpenLibrary(t, map[string]string{
+			"/search.json":     `{"docs": [{"key": "/works/OL1W", "title": "Солярис", "author_name": ["Станислав Лем", "Someone"]}]}`,
+			"/works/OL1W.json": `{"description": "Океан."}`,
+		})
+		book, err := ol.Search(ctx, "Солярис")
+		require.NoError(t, err)
+		assert.Equal(t, &Book{Title: "Солярис", Author: "Станислав Лем, Someone", Description: "Океан."}, book)
+		assert.Contains(t, (*queries)[0], "q=%D0%A1")
+	})
+
+	t.Run("a missing work leaves the description empty", func(t *testing.T) {
+		ol, _ := stubOpenLibrary(t, map[string]string{"/search.json": duneSearch})
+		book, err := ol.Search(ctx, "dune")
+		require.NoError(t, err)
+		assert.Empty(t, book.Description)
+	})
+
+	t.Run("no hits", func(t *testing.T) {
+		ol, _ := stubOpenLibrary(t, map[string]string{"/search.json": `{"numFound": 0, "docs": []}`})
+		_, err := ol.Search(ctx, "nothing")
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+
+	t.Run("server errors are reported", func(t *testing.T) {
+		ol, _ := stubOpenLibrary(t, map[string]string{"/search.json": ""})
+		_, err := ol.Search(ctx, "dune")
+		assert.Error(t, err)
+		assert.NotErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestNormalizeISBN(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"978-0-441-17271-9", "9780441172719", true},
+		{"0 441 17271 7", "0441172717", true},
+		{"080442957X", "080442957X", true},
+		{"080442957x", "080442957X", true},
+		{"9780441172710", "", false}, // wrong check digit
+		{"Dune", "", false},
+		{"1984", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := NormalizeISBN(tt.in)
+		assert.Equal(t, tt.ok, ok, tt.in)
+		if tt.ok {
+			assert.Equal(t, tt.want, got, tt.in)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LinkResolver reads a book from a link to its page on a site it knows.
+type LinkResolver interface {
+	// Resolves reports whether link points at a book page the resolver can read.
+	Resolves(link string) bool
+	// Resolve fetches the page and reads the book from it. It returns
+	// ErrNotFound when the page describes no book.
+	Resolve(ctx context.Context, link string) (*Book, error)
+}
+
+// bookSites maps the sites whose book pages PageReader reads to the path
+// their book pages start with.
+var bookSites = map[string]string{
+	"goodreads.com": "/book/show/",
+	"livelib.ru":    "/book/",
+}
+
+// maxPageSize bounds how much of a book page is read.
+const maxPageSize = 4 << 20
+
+// PageReader is a LinkResolver for Goodreads and LiveLib book pages. It reads
+// the schema.org Book in the page's JSON-LD and fills in what that lacks from
+// its OpenGraph tags.
+type PageReader struct {
+	client *http.Client
+}
+
+func NewPageReader() *PageReader {
+	return &PageReader{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (r *PageReader) Resolves(link string) bool {
+	u, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for site, prefix := range bookSites {
+		if (host == site || strings.HasSuffix(host, "."+site)) && strings.HasPrefix(u.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *PageReader) Resolve(ctx context.Context, link string) (*Book, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSpace(link), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; BookClubBot)")
+	req.Header.Set("Accept", "text/html")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("book page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("book page: GET %s: %s", link, resp.Status)
+	}
+	page, err := io.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, fmt.Errorf("book page: read %s: %w", link, err)
+	}
+	return parseBookPage(string(page))
+}
+
+var (
+	metaTag   = regexp.MustCompile(`(?is)<meta\s[^>]*>`)
+	attribute = regexp.MustCompile(`(?is)([a-z:-]+)\s*=\s*(?:"([^"]*)"|'([^']*)')`)
+	jsonLD    = regexp.MustCompile(`(?is)<script[^>]*type\s*=\s*["']application/ld\+json["'][^>]*>(.*?)</script>`)
+)
+
+// parseBookPage reads a book from an HTML page: from a schema.org Book in its
+// JSON-LD first, then from its OpenGraph (and books:) meta tags.
+func parseBookPage(page string) (*Book, error) {
+	book := &Book{}
+	for _, m := range jsonLD.FindAllStringSubmatch(page, -1) {
+		if found := findSchemaBook(m[1]); found != nil {
+			book = found
+			break
+		}
+	}
+
+	meta := metaTags(page)
+	if book.Title == "" && (meta["og:type"] == "book" || meta["og:type"] == "books.book") {
+		book.Title = meta["og:title"]
+	}
+	if book.Title == "" {
+		return nil, ErrNotFound
+	}
+	if book.Author == "" {
+		book.Author = meta["book:author"]
+	}
+	if book.Description == "" {
+		book.Description = meta["og:description"]
+	}
+	if book.CoverURL == "" {
+		book.CoverURL = meta["og:image"]
+	}
+	if book.Pages == 0 {
+		book.Pages, _ = strconv.Atoi(meta["books:page_count"])
+	}
+	return book, nil
+}
+
+// metaTags collects the content of a page's <meta property|name=... content=...>
+// tags, the first of each name winning.
+func metaTags(page string) map[string]string {
+	tags := make(map[string]string)
+	for _, tag := range metaTag.FindAllString(page, -1) {
+		attrs := make(map[string]string)
+		for _, a := range attribute.FindAllStringSubmatch(tag, -1) {
+			attrs[strings.ToLower(a[1])] = a[2] + a[3]
+		}
+		name := attrs["property"]
+		if name == "" {
+			name = attrs["name"]
+		}
+		name = strings.ToLower(name)
+		if _, seen := tags[name]; name != "" && !seen {
+			tags[name] = strings.TrimSpace(html.UnescapeString(attrs["content"]))
+		}
+	}
+	return tags
+}
+
+// schemaThing is the part of a schema.org node the reader looks at.
+type schemaThing struct {
+	Type          json.RawMessage `json:"@type"`
+	Graph         []schemaThing   `json:"@graph"`
+	Name          string          `json:"name"`
+	Author        json.RawMessage `json:"author"`
+	Description   string          `json:"description"`
+	Image         json.RawMessage `json:"image"`
+	NumberOfPages json.RawMessage `json:"numberOfPages"`
+}
+
+// findSchemaBook returns the first schema.org Book in a JSON-LD block, which
+// holds a node, an array of nodes or an @graph.
+func findSchemaBook(block string) *Book {
+	var things []schemaThing
+	if err := json.Unmarshal([]byte(block), &things); err != nil {
+		var thing schemaThing
+		if err := json.Unmarshal([]byte(block), &thing); err != nil {
+			return nil
+		}
+		things = []schemaThing{thing}
+	}
+	for _, t := range things {
+		things = append(things, t.Graph...)
+	}
+	for _, t := range things {
+		if !isType(t.Type, "Book") || t.Name == "" {
+			continue
+		}
+		book := &Book{
+			Title:       clean(t.Name),
+			Author:      strings.Join(names(t.Author), ", "),
+			Description: clean(t.Description),
+		}
+		if images := names(t.Image); len(images) > 0 {
+			book.CoverURL = images[0]
+		}
+		var pages json.Number
+		if json.Unmarshal(bytesTrimQuotes(t.NumberOfPages), &pages) == nil {
+			n, _ := pages.Int64()
+			book.Pages = int(n)
+		}
+		return book
+	}
+	return nil
+}
+
+// isType reports whether a @type, a string or an array of them, is want.
+func isType(raw json.RawMessage, want string) bool {
+	var types []string
+	if json.Unmarshal(raw, &types) != nil {
+		var t string
+		if json.Unmarshal(raw, &t) != nil {
+			return false
+		}
+		types = []string{t}
+	}
+	for _, t := range types {
+		if t == want {
+			return true
+		}
+	}
+	return false
+}
+
+// names reads a schema.org value that is a string, a node with a name (or a
+// url, for an image), or an array of either.
+func names(raw json.RawMessage) []string {
+	var items []json.RawMessage
+	if json.Unmarshal(raw, &items) != nil {
+		items = []json.RawMessage{raw}
+	}
+	var out []string
+	for _, item := range items {
+		var s string
+		if json.Unmarshal(item, &s) != nil {
+			var node struct {
+				Name string `json:"name"`
+				URL  string `json:"url"`
+			}
+			if json.Unmarshal(item, &node) != nil {
+				continue
+			}
+			s = node.Name
+			if s == "" {
+				s = node.URL
+			}
+		}
+		if s = clean(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// bytesTrimQuotes lets a number written as a JSON string, as some sites do,
+// decode as a number.
+func bytesTrimQuotes(raw json.RawMessage) json.RawMessage {
+	return json.RawMessage(strings.Trim(string(raw), `"`))
+}
+
+// clean unescapes the HTML entities JSON-LD strings often carry and trims them.
+func clean(s string) string {
+	return strings.TrimSpace(html.UnescapeString(s))
+}
@@ -0,0 +1,90 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fixture(t *testing.T, name string) string {
+	t.Helper()
+	page, err := os.ReadFile("testdata/" + name)
+	require.NoError(t, err)
+	return string(page)
+}
+
+func TestParseBookPage(t *testing.T) {
+	t.Run("goodreads: JSON-LD, with the description from OpenGraph", func(t *testing.T) {
+		book, err := parseBookPage(fixture(t, "goodreads_dune.html"))
+		require.NoError(t, err)
+		assert.Equal(t, &Book{
+			Title:       "Dune (Dune, #1)",
+			Author:      "Frank Herbert",
+			Description: "Set on the desert planet Arrakis, Dune is the story of the boy Paul Atreides, heir to a noble family tasked with ruling an inhospitable world where the only thing of value is the “spice” melange.",
+			Pages:       658,
+			CoverURL:    "https://images-na.ssl-images-amazon.com/images/S/compressed.photo.goodreads.com/books/1555447414i/44767458.jpg",
+		}, book)
+	})
+
+	t.Run("livelib: the Book among other JSON-LD nodes", func(t *testing.T) {
+		book, err := parseBookPage(fixture(t, "livelib_solaris.html"))
+		require.NoError(t, err)
+		assert.Equal(t, &Book{
+			Title:       "Солярис",
+			Author:      "Станислав Лем",
+			Description: "Планета Солярис покрыта живым Океаном — и люди на станции встречаются с тем, что скрывали даже от себя.",
+			Pages:       288,
+			CoverURL:    "https://s1.livelib.ru/boocover/1000569387/o/d3b5/Stanislav_Lem__Solyaris.jpeg",
+		}, book)
+	})
+
+	t.Run("OpenGraph only", func(t *testing.T) {
+		book, err := parseBookPage(`<head><meta property="og:type" content="book"><meta content="Emma" property="og:title">
+			<meta property="book:author" content="Jane Austen"><meta property="og:image" content="https://covers.example/emma.jpg"></head>`)
+		require.NoError(t, err)
+		assert.Equal(t, &Book{Title: "Emma", Author: "Jane Austen", CoverURL: "https://covers.example/emma.jpg"}, book)
+	})
+
+	t.Run("a page about no book", func(t *testing.T) {
+		_, err := parseBookPage(`<head><meta property="og:type" content="website"><meta property="og:title" content="Goodreads"></head>`)
+		assert.ErrorIs(t, err, ErrNotFound)
+	})
+}
+
+func TestPageReader(t *testing.T) {
+	r := NewPageReader()
+	for link, want := range map[string]bool{
+		"https://www.goodreads.com/book/show/44767458-dune":      true,
+		"https://goodreads.com/book/show/44767458":               true,
+		"  https://www.livelib.ru/book/1000569387-solyaris  ":    true,
+		"https://www.goodreads.com/author/show/58.Frank_Herbert": false,
+		"https://notgoodreads.com/book/show/1":                   false,
+		"https://www.livelib.ru.example.com/book/1":              false,
+		"Dune":                            false,
+		"ftp://goodreads.com/book/show/1": false,
+	} {
+		assert.Equal(t, want, r.Resolves(link), link)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		assert.NotEmpty(t, req.Header.Get("User-Agent"))
+		if req.URL.Path != "/book/1000569387" {
+			http.NotFound(w, req)
+			return
+		}
+		w.Write([]byte(fixture(t, "livelib_solaris.html")))
+	}))
+	defer srv.Close()
+
+	book, err := r.Resolve(context.Background(), srv.URL+"/book/1000569387")
+	require.NoError(t, err)
+	assert.Equal(t, "Солярис", book.Title)
+
+	_, err = r.Resolve(context.Background(), srv.URL+"/book/1")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -13,10 +13,29 @@ type Subscriber struct {
 	Nick      string    `bson:"nick"`
 	Archived  bool      `bson:"archived"`
 	JoinedAt  time.Time `bson:"joinedAt"`
+	// ClubIDs are the chat IDs of the clubs the subscriber belongs to. A user
+	// can be a member of several clubs served by the same bot.
+	ClubIDs []int64 `bson:"clubIds"`
+	// CurrentClubID is the club picked with /club. It routes the user's DMs
+	// when they belong to several clubs and the bot cannot tell which one a
+	// message is about.
+	CurrentClubID int64 `bson:"currentClubId,omitempty"`
 }
 
-// Session statuses. The first three are "active" — at most one session may be
-// in any active status at a time (see SessionRepository.EnsureIndexes).
+// Club is one book club: a Telegram group the bot has been added to. Its chat
+// ID is the key every subscriber and session of the club refers to.
+type Club struct {
+	ChatID  int64     `bson:"_id"`
+	Title   string    `bson:"title"`
+	AddedAt time.Time `bson:"addedAt"`
+	// AdminIDs are the club's organizers as last synced from the group's chat
+	// administrators. Admins seeded from config are not stored here.
+	AdminIDs       []int64    `bson:"adminIds,omitempty"`
+	AdminsSyncedAt *time.Time `bson:"adminsSyncedAt,omitempty"`
+}
+
+// Session statuses. The first three are "active" — at most one session per club
+// may be in any active status at a time (see SessionRepository.EnsureIndexes).
 const (
 	StatusGathering = "gathering"
 	StatusVoting    = "voting"
@@ -28,6 +47,9 @@ const (
 // Participant submission steps during book gathering.
 const (
 	StepBook        = "book"
+	StepLookup      = "lookup"    // the title answer is being looked up in the catalog or read from a link
+	StepConfirm     = "confirm"   // a catalog match awaits the participant's confirmation
+	StepDuplicate   = "duplicate" // the book looks like another participant's; awaits the participant's answer
 	StepAuthor      = "author"
 	StepDescription = "description"
 	StepImage       = "image"
@@ -35,13 +57,31 @@ const (
 	StepSkipped     = "skipped"
 )
 
-// Reading member statuses (step 3, reserved for future use).
+// Reading member statuses (step 3).
 const (
 	ReadingInProgress = "reading"
 	ReadingFinished   = "finished"
 	ReadingAbandoned  = "abandoned"
 )
 
+// Reading member review steps. A member with an empty step is still reading;
+// /finished, /rate and /review move them into the rating or review question.
+const (
+	ReviewStepRating = "rating"
+	ReviewStepReview = "review"
+	ReviewStepDone   = "done"
+)
+
+// Book fields a submission question can fill; the answer to any other
+// question goes to Book.Attributes under the question's field.
+const (
+	BookTitle       = "title"
+	BookAuthor      = "author"
+	BookDescription = "description"
+	BookCover       = "cover"
+	BookPages       = "pages"
+)
+
 // Book is a single book submission (partial while a participant is still
 // answering questions, complete once their step reaches StepDone).
 type Book struct {
@@ -49,19 +89,31 @@ type Book struct {
 	Author      string `bson:"author"`
 	Description string `bson:"description"`
 	PhotoID     string `bson:"photoId"`
+	Pages       int    `bson:"pages,omitempty"`    // from the catalog
+	CoverURL    string `bson:"coverUrl,omitempty"` // from the catalog; PhotoID takes precedence
+	// Attributes holds the answers to the questionnaire's own questions, e.g.
+	// a genre, by field.
+	Attributes map[string]string `bson:"attributes,omitempty"`
 }
 
 // Participant holds one subscriber's in-progress conversation state during book
 // gathering, so a restart resumes them exactly where they left off.
 type Participant struct {
-	SubscriberID int64      `bson:"subscriberId"`
-	FirstName    string     `bson:"firstName"`
-	LastName     string     `bson:"lastName"`
-	Nick         string     `bson:"nick"`
-	Step         string     `bson:"step"`
-	Book         *Book      `bson:"book"`
-	InvitedAt    time.Time  `bson:"invitedAt"`
-	SubmittedAt  *time.Time `bson:"submittedAt"`
+	SubscriberID int64  `bson:"subscriberId"`
+	FirstName    string `bson:"firstName"`
+	LastName     string `bson:"lastName"`
+	Nick         string `bson:"nick"`
+	Step         string `bson:"step"`
+	Book         *Book  `bson:"book"`
+	Lookup       string `bson:"lookup,omitempty"`  // what is looked up in the catalog, while at StepLookup or StepConfirm
+	Editing      string `bson:"editing,omitempty"` // the field /edit asked for, while at StepDone
+	// PendingTitle is an edited title held back as likely another
+	// participant's book, until the participant answers the duplicate notice.
+	PendingTitle string `bson:"pendingTitle,omitempty"`
+	// BookID is the book's CatalogBook, set when the gathering closes.
+	BookID      primitive.ObjectID `bson:"bookId,omitempty"`
+	InvitedAt   time.Time          `bson:"invitedAt"`
+	SubmittedAt *time.Time         `bson:"submittedAt"`
 }
 
 // Gathering is the book-collection phase (step 1).
@@ -72,46 +124,211 @@ type Gathering struct {
 	Participants []*Participant `bson:"participants"`
 }
 
-// Voting is the Telegram poll phase (step 2).
+// Voting modes, chosen by the voting_mode config.
+const (
+	VotingPoll   = "poll"   // a native Telegram poll in the group (approval voting)
+	VotingRanked = "ranked" // members rank the books in DM; instant-runoff tally
+)
+
+// Voter eligibility rules, chosen by the voter_eligibility config.
+const (
+	EligibleSubscribers  = "subscribers"  // the club's members when voting starts
+	EligibleParticipants = "participants" // only members who submitted a book
+	EligibleAnyone       = "anyone"       // anyone in the group
+)
+
+// Candidate is a book standing in a ranked-choice vote. Ballots refer to
+// candidates by their index in Voting.Candidates.
+type Candidate struct {
+	SubscriberID int64  `bson:"subscriberId"` // who proposed the book
+	Title        string `bson:"title"`
+	Author       string `bson:"author"`
+}
+
+// Ballot is one member's ranking in a ranked-choice vote. The ballot is a DM
+// with an inline keyboard: each tap appends a candidate to the ranking until
+// the member confirms it.
+type Ballot struct {
+	VoterID     int64      `bson:"voterId"`
+	MessageID   int        `bson:"messageId"` // the DM holding the ballot keyboard
+	Ranking     []int      `bson:"ranking"`   // candidate indexes, most preferred first
+	SubmittedAt *time.Time `bson:"submittedAt"`
+}
+
+// Voting is the voting phase (step 2): a Telegram poll, or ranked ballots in DM.
 type Voting struct {
+	Mode              string     `bson:"mode,omitempty"` // VotingPoll when empty
 	TelegramPollID    int        `bson:"telegramPollId"`
+	PollID            string     `bson:"pollId"` // Telegram poll ID, matched against PollAnswer.PollID
 	Deadline          time.Time  `bson:"deadline"`
 	NotifyAt          time.Time  `bson:"notifyAt"`
 	NotifiedAt        *time.Time `bson:"notifiedAt"`
 	TotalParticipants int        `bson:"totalParticipants"`
 	VoterIDs          []int64    `bson:"voterIds"`
-	StartedAt         time.Time  `bson:"startedAt"`
-	ClosedAt          *time.Time `bson:"closedAt"`
+	// Eligibility is the rule the eligible voters were chosen by, and
+	// EligibleIDs the voters it chose when voting started. Answers from anyone
+	// else are recorded in OutsiderIDs and not counted. With EligibleAnyone, or
+	// in a session from before eligibility was stored, everyone is eligible.
+	Eligibility string     `bson:"eligibility,omitempty"`
+	EligibleIDs []int64    `bson:"eligibleIds,omitempty"`
+	OutsiderIDs []int64    `bson:"outsiderIds,omitempty"`
+	StartedAt   time.Time  `bson:"startedAt"`
+	ClosedAt    *time.Time `bson:"closedAt"`
+	// Options maps the current poll's options, in poll order, to the books
+	// they stand for; winners are resolved through it by option index.
+	Options []PollOption `bson:"options,omitempty"`
+	// Results archives every closed poll of the round with its final counts:
+	// heats, the final poll and any runoff.
+	Results []*PollResult `bson:"results,omitempty"`
+	// Answers holds each voter's latest answer to each of the round's polls,
+	// including heats and runoffs, as the polls are non-anonymous.
+	Answers []*Answer `bson:"answers,omitempty"`
+	// Candidates and Ballots are set only in ranked mode, which has no poll.
+	Candidates []Candidate `bson:"candidates,omitempty"`
+	Ballots    []*Ballot   `bson:"ballots,omitempty"`
+	// Runoff marks the current poll as a runoff between the books tied in the
+	// previous one.
+	Runoff bool `bson:"runoff,omitempty"`
+	// Heats are the preliminary polls run when more books were gathered than
+	// fit in one Telegram poll. While the latest stage of heats is running the
+	// final poll has not been sent yet (TelegramPollID is 0); deadlines and
+	// reminders above belong to whichever stage is current.
+	Heats []*Heat `bson:"heats,omitempty"`
 }
 
-// Winner is a winning book. A round can have several winners on a tie.
-type Winner struct {
+// PollOption is one option of a poll and the book behind it. Votes is filled in
+// when the poll closes.
+type PollOption struct {
+	Text         string `bson:"text"`
 	SubscriberID int64  `bson:"subscriberId"`
 	Title        string `bson:"title"`
 	Author       string `bson:"author"`
+	Votes        int    `bson:"votes"`
+	// RawVotes is the option's count as Telegram reported it, before the
+	// answers that do not count were taken out; set in poll results only.
+	RawVotes int `bson:"rawVotes,omitempty"`
+}
+
+// PollResult is a closed poll with the votes each option received, as tallied:
+// answers over the choice limit, and those of voters not eligible, are not
+// counted. Telegram's own counts are kept alongside.
+type PollResult struct {
+	PollID         string       `bson:"pollId"`
+	TelegramPollID int          `bson:"telegramPollId"`
+	Stage          int          `bson:"stage,omitempty"` // heat stage; 0 for the final poll
+	Runoff         bool         `bson:"runoff,omitempty"`
+	Options        []PollOption `bson:"options"`
+	TotalVoters    int          `bson:"totalVoters"`
+	RawTotalVoters int          `bson:"rawTotalVoters,omitempty"` // as Telegram counted them
+	ClosedAt       time.Time    `bson:"closedAt"`
+}
+
+// Answer is the options one voter picked in one poll. An answer with more
+// options than the club allows is kept, but left out of the tally.
+type Answer struct {
+	PollID     string    `bson:"pollId"`
+	VoterID    int64     `bson:"voterId"`
+	OptionIDs  []int     `bson:"optionIds"`
+	AnsweredAt time.Time `bson:"answeredAt"`
+}
+
+// Heat is one preliminary poll of up to ten books. When it closes its leaders
+// go through to the next stage: more heats, or the final poll.
+type Heat struct {
+	Stage          int      `bson:"stage"` // 1 for the first round of heats
+	TelegramPollID int      `bson:"telegramPollId"`
+	PollID         string   `bson:"pollId"`
+	Options        []string `bson:"options"`
+	// Books maps Options, by index, to the books they stand for.
+	Books    []PollOption `bson:"books,omitempty"`
+	VoterIDs []int64      `bson:"voterIds"`
+	// Advanced holds the indexes of the options going through, leaders first;
+	// set on close.
+	Advanced []int      `bson:"advancedIndexes"`
+	ClosedAt *time.Time `bson:"closedAt"`
+}
+
+// Winner is a winning book. A round can have several winners on a tie.
+type Winner struct {
+	SubscriberID int64              `bson:"subscriberId"`
+	Title        string             `bson:"title"`
+	Author       string             `bson:"author"`
+	BookID       primitive.ObjectID `bson:"bookId,omitempty"` // the book's CatalogBook
+}
+
+// Tie-break methods, chosen by the tie_break config.
+const (
+	TieBreakRunoff         = "runoff"          // a runoff poll between the tied books
+	TieBreakRandom         = "random"          // a draw seeded from the session
+	TieBreakLongestWaiting = "longest_waiting" // the proposer who has waited longest for a win
+)
+
+// Past winner policies, chosen by the past_winner_policy config: what happens
+// when a member proposes a book the club has read.
+const (
+	PastWinnerReject      = "reject"       // the book is refused
+	PastWinnerWarn        = "warn"         // the member is warned and the book stands
+	PastWinnerAfterMonths = "after_months" // refused until past_winner_months after the reading
+	PastWinnerAllow       = "allow"        // no check
+)
+
+// TieBreak records how a tie in the poll was resolved.
+type TieBreak struct {
+	Method string   `bson:"method"`
+	Tied   []Winner `bson:"tied"`
+	Seed   int64    `bson:"seed,omitempty"` // the random draw's seed
 }
 
 // ReadingMember is one subscriber's progress and review of the winning book
-// (step 3, reserved for future use).
+// (step 3).
 type ReadingMember struct {
 	SubscriberID int64      `bson:"subscriberId"`
 	Status       string     `bson:"status"`
+	Step         string     `bson:"step"`
 	Rating       *int       `bson:"rating"`
 	Review       *string    `bson:"review"`
 	StartedAt    time.Time  `bson:"startedAt"`
 	FinishedAt   *time.Time `bson:"finishedAt"`
+	// Progress is the member's /progress check-in history, oldest first.
+	Progress []ProgressEntry `bson:"progress"`
 }
 
-// Reading is the post-vote reading phase (step 3, reserved for future use).
+// ProgressEntry is one /progress check-in. A member reports either a percentage
+// or a page number, so exactly one of Percent and Page is set.
+type ProgressEntry struct {
+	Percent int       `bson:"percent,omitempty"`
+	Page    int       `bson:"page,omitempty"`
+	At      time.Time `bson:"at"`
+}
+
+// Reading is the post-vote reading phase (step 3). It starts when the poll
+// closes with a single winner and ends at the deadline.
 type Reading struct {
-	Book    Book             `bson:"book"`
-	Members []*ReadingMember `bson:"members"`
+	Book         Book             `bson:"book"`
+	SubscriberID int64            `bson:"subscriberId"` // who proposed the book
+	Deadline     time.Time        `bson:"deadline"`
+	StartedAt    time.Time        `bson:"startedAt"`
+	Members      []*ReadingMember `bson:"members"`
+	// MilestonesSent lists the reading milestones (percent of the reading
+	// period elapsed) whose nudge has been sent, so each fires exactly once.
+	MilestonesSent []int `bson:"milestonesSent"`
+}
+
+// RoundSettings are a round's phase durations in seconds, fixed when the round
+// starts: the /start_vote overrides, or else the config defaults. Sessions
+// created before they were stored have zero values and use the config.
+type RoundSettings struct {
+	GatherSeconds int `bson:"gatherSeconds"`
+	VoteSeconds   int `bson:"voteSeconds"`
 }
 
 // BookClubSession is one complete round: gathering → voting → reading.
 type BookClubSession struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ChatID    int64              `bson:"chatId"` // the club (group chat) running the round
 	Name      string             `bson:"name"`
+	Theme     string             `bson:"theme,omitempty"` // optional theme the books should fit
+	Settings  RoundSettings      `bson:"settings"`
 	Status    string             `bson:"status"`
 	CreatedBy int64              `bson:"createdBy"`
 	CreatedAt time.Time          `bson:"createdAt"`
@@ -119,11 +336,12 @@ type BookClubSession struct {
 	Gathering Gathering          `bson:"gathering"`
 	Voting    *Voting            `bson:"voting"`
 	Winners   []Winner           `bson:"winners"`
+	TieBreak  *TieBreak          `bson:"tieBreak,omitempty"`
 	Reading   *Reading           `bson:"reading"`
 
 	// ActiveLock is present only while the session is in an active status. A
-	// unique partial index on its existence guarantees at most one active
-	// session at a time. It is never read by application code; SessionRepository
+	// unique partial index on (chatId, activeLock) guarantees at most one active
+	// session per club at a time. It is never read by application code; SessionRepository
 	// sets and unsets it as the status changes.
 	ActiveLock *bool `bson:"activeLock,omitempty"`
 }
@@ -143,3 +361,31 @@ func IsActiveStatus(status string) bool {
 func (s *BookClubSession) IsActive() bool {
 	return IsActiveStatus(s.Status)
 }
+
+// CatalogBook is a book of the shared catalog (the books collection): one
+// document per book, whichever rounds and clubs proposed it, keyed by its
+// normalized title and author. Sessions reference it by ID from
+// Participant.BookID and Winner.BookID, and keep their own copy of the book.
+type CatalogBook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Title     string             `bson:"title"`  // as first proposed
+	Author    string             `bson:"author"` // as first proposed
+	TitleKey  string             `bson:"titleKey"`
+	AuthorKey string             `bson:"authorKey"`
+	// Proposals is the book's history, oldest first: every round it was
+	// proposed in, by whom, and whether it won.
+	Proposals     []BookProposal `bson:"proposals"`
+	ProposedCount int            `bson:"proposedCount"`
+	WonCount      int            `bson:"wonCount"`
+	CreatedAt     time.Time      `bson:"createdAt"`
+	UpdatedAt     time.Time      `bson:"updatedAt"`
+}
+
+// BookProposal is one round a catalog book was proposed in.
+type BookProposal struct {
+	SessionID    primitive.ObjectID `bson:"sessionId"`
+	ChatID       int64              `bson:"chatId"`
+	SubscriberID int64              `bson:"subscriberId"`
+	ProposedAt   time.Time          `bson:"proposedAt"`
+	Won          bool               `bson:"won"`
+}
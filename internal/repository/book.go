@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const books_collection = "books"
+
+// BookRepository stores the book catalog shared by every club and round: one
+// document per book with the history of its proposals.
+type BookRepository struct {
+	db *mongo.Database
+}
+
+func NewBookRepository(db *mongo.Database) (*BookRepository, error) {
+	if db == nil {
+		return nil, ErrNilDatabase
+	}
+	return &BookRepository{
+		db: db,
+	}, nil
+}
+
+// EnsureIndexes creates the indexes the book collection relies on:
+//   - a unique index on (titleKey, authorKey), so a book has one document;
+//   - an index on proposals.sessionId, to find the books of a round.
+func (r *BookRepository) EnsureIndexes(ctx context.Context) error {
+	collection := r.db.Collection(books_collection)
+	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "titleKey", Value: 1}, {Key: "authorKey", Value: 1}},
+			Options: options.Index().SetName("uniq_book_keys").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "proposals.sessionId", Value: 1}},
+			Options: options.Index().SetName("proposals_session"),
+		},
+	})
+	return err
+}
+
+// RecordProposal adds a proposal to the history of the book with book's title
+// and author keys and returns the book's ID. The book is created, with book's
+// title and author, on its first proposal. A proposal of the same session and
+// proposer is recorded once, however often it is passed in.
+func (r *BookRepository) RecordProposal(ctx context.Context, book *models.CatalogBook, proposal models.BookProposal) (primitive.ObjectID, error) {
+	collection := r.db.Collection(books_collection)
+	now := time.Now().UTC()
+
+	filter := bson.M{"titleKey": book.TitleKey, "authorKey": book.AuthorKey}
+	upsert := bson.M{
+		"$setOnInsert": bson.M{
+			"title":         book.Title,
+			"author":        book.Author,
+			"proposals":     []models.BookProposal{},
+			"proposedCount": 0,
+			"wonCount":      0,
+			"createdAt":     now,
+		},
+		"$set": bson.M{"updatedAt": now},
+	}
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After).
+		SetProjection(bson.M{"_id": 1})
+
+	var doc struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := collection.FindOneAndUpdate(ctx, filter, upsert, opts).Decode(&doc); err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	filter = bson.M{
+		"_id": doc.ID,
+		"proposals": bson.M{"$not": bson.M{"$elemMatch": bson.M{
+			"sessionId":    proposal.SessionID,
+			"subscriberId": proposal.SubscriberID,
+		}}},
+	}
+	update := bson.M{
+		"$push": bson.M{"proposals": proposal},
+		"$inc":  bson.M{"proposedCount": 1},
+	}
+	if _, err := collection.UpdateOne(ctx, filter, update); err != nil {
+		return primitive.NilObjectID, err
+	}
+	return doc.ID, nil
+}
+
+// MarkWon marks the book's proposal in the given session, by the given
+// proposer, as a winner. Returns ErrNotFound if the book has no such proposal
+// or it is already marked.
+func (r *BookRepository) MarkWon(ctx context.Context, id, sessionID primitive.ObjectID, subscriberID int64) error {
+	collection := r.db.Collection(books_collection)
+	filter := bson.M{
+		"_id": id,
+		"proposals": bson.M{"$elemMatch": bson.M{
+			"sessionId":    sessionID,
+			"subscriberId": subscriberID,
+			"won":          false,
+		}},
+	}
+	update := bson.M{
+		"$set": bson.M{"proposals.$.won": true, "updatedAt": time.Now().UTC()},
+		"$inc": bson.M{"wonCount": 1},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetBook returns the book with the given ID, or (nil, nil) if none.
+func (r *BookRepository) GetBook(ctx context.Context, id primitive.ObjectID) (*models.CatalogBook, error) {
+	collection := r.db.Collection(books_collection)
+
+	var book models.CatalogBook
+	if err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&book); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &book, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"BookClubBot/internal/models"
+	mongo_helpers "BookClubBot/internal/repository/testing"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewBookRepository(t *testing.T) {
+	t.Run("with nil database", func(t *testing.T) {
+		repo, err := NewBookRepository(nil)
+		assert.Error(t, err)
+		assert.Equal(t, ErrNilDatabase, err)
+		assert.Nil(t, repo)
+	})
+
+	t.Run("with valid database", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("Skipping integration test")
+		}
+
+		db, clear := mongo_helpers.CreateTestMongoDB(t)
+		defer clear()
+
+		repo, err := NewBookRepository(db)
+		assert.NoError(t, err)
+		assert.NotNil(t, repo)
+	})
+}
+
+func TestRecordProposal(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanBook(clear, mongoDB)
+	repo, err := NewBookRepository(mongoDB)
+	require.NoError(t, err)
+	ctx := testCtx(t)
+	require.NoError(t, repo.EnsureIndexes(ctx))
+
+	first, second := primitive.NewObjectID(), primitive.NewObjectID()
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	book := &models.CatalogBook{Title: "Dune", Author: "Frank Herbert", TitleKey: "dune", AuthorKey: "frank herbert"}
+
+	id, err := repo.RecordProposal(ctx, book, models.BookProposal{SessionID: first, ChatID: testClubID, SubscriberID: 1, ProposedAt: at})
+	require.NoError(t, err)
+	// The same proposal again is not counted twice.
+	again, err := repo.RecordProposal(ctx, book, models.BookProposal{SessionID: first, ChatID: testClubID, SubscriberID: 1, ProposedAt: at})
+	require.NoError(t, err)
+	assert.Equal(t, id, again)
+
+	// A later round proposes the same book under another spelling of its title:
+	// the keys match, so it is the same document and keeps its first title.
+	renamed := &models.CatalogBook{Title: "DUNE", Author: "Herbert", TitleKey: "dune", AuthorKey: "frank herbert"}
+	later, err := repo.RecordProposal(ctx, renamed, models.BookProposal{SessionID: second, ChatID: testClubID, SubscriberID: 2, ProposedAt: at})
+	require.NoError(t, err)
+	assert.Equal(t, id, later)
+
+	require.NoError(t, repo.MarkWon(ctx, id, second, 2))
+	assert.ErrorIs(t, repo.MarkWon(ctx, id, second, 2), ErrNotFound, "a win is marked once")
+	assert.ErrorIs(t, repo.MarkWon(ctx, id, primitive.NewObjectID(), 2), ErrNotFound)
+
+	got, err := repo.GetBook(ctx, id)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, "Dune", got.Title)
+	assert.Equal(t, 2, got.ProposedCount)
+	assert.Equal(t, 1, got.WonCount)
+	require.Len(t, got.Proposals, 2)
+	assert.Equal(t, int64(1), got.Proposals[0].SubscriberID)
+	assert.False(t, got.Proposals[0].Won)
+	assert.True(t, got.Proposals[1].Won)
+	assert.Equal(t, at, got.Proposals[1].ProposedAt.UTC())
+
+	missing, err := repo.GetBook(ctx, primitive.NewObjectID())
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func cleanBook(clear func(), mongoDB *mongo.Database) {
+	clear()
+	mongo_helpers.DropCollection(mongoDB, books_collection)
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"BookClubBot/internal/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const clubs_collection = "clubs"
+
+// ClubRepository stores the clubs (group chats) the bot serves. It replaces the
+// single groupId kept by SettingsRepository, which is only read to migrate
+// data written before the bot supported several clubs.
+type ClubRepository struct {
+	db *mongo.Database
+}
+
+func NewClubRepository(db *mongo.Database) (*ClubRepository, error) {
+	if db == nil {
+		return nil, ErrNilDatabase
+	}
+	return &ClubRepository{
+		db: db,
+	}, nil
+}
+
+// SaveClub upserts a club by its chat ID.
+func (c *ClubRepository) SaveClub(ctx context.Context, club *models.Club) error {
+	collection := c.db.Collection(clubs_collection)
+	opts := options.Update().SetUpsert(true)
+	filter := bson.M{"_id": club.ChatID}
+	update := bson.M{"$set": club}
+
+	_, err := collection.UpdateOne(ctx, filter, update, opts)
+	return err
+}
+
+// DeleteClub removes a club. Returns ErrNotFound if it does not exist.
+func (c *ClubRepository) DeleteClub(ctx context.Context, chatID int64) error {
+	collection := c.db.Collection(clubs_collection)
+	res, err := collection.DeleteOne(ctx, bson.M{"_id": chatID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetClubAdmins replaces the club's synced admin list. Returns ErrNotFound if
+// the club does not exist.
+func (c *ClubRepository) SetClubAdmins(ctx context.Context, chatID int64, adminIDs []int64, syncedAt time.Time) error {
+	// Store an empty array (not BSON null) for a group without human admins.
+	if adminIDs == nil {
+		adminIDs = []int64{}
+	}
+
+	collection := c.db.Collection(clubs_collection)
+	filter := bson.M{"_id": chatID}
+	update := bson.M{"$set": bson.M{"adminIds": adminIDs, "adminsSyncedAt": syncedAt}}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetClubById returns the club with the given chat ID, or (nil, nil) if none.
+func (c *ClubRepository) GetClubById(ctx context.Context, chatID int64) (*models.Club, error) {
+	collection := c.db.Collection(clubs_collection)
+
+	var club models.Club
+	if err := collection.FindOne(ctx, bson.M{"_id": chatID}).Decode(&club); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &club, nil
+}
+
+// GetAllClubs returns every club, oldest first, so numbered club lists shown
+// to users stay stable.
+func (c *ClubRepository) GetAllClubs(ctx context.Context) ([]*models.Club, error) {
+	collection := c.db.Collection(clubs_collection)
+	opts := options.Find().SetSort(bson.D{{Key: "addedAt", Value: 1}})
+
+	cursor, err := collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var clubs []*models.Club
+	if err := cursor.All(ctx, &clubs); err != nil {
+		return nil, err
+	}
+	return clubs, nil
+}
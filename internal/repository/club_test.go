@@ -0,0 +1,137 @@
+package repository
+
+import (
+	"BookClubBot/internal/models"
+	mongo_helpers "BookClubBot/internal/repository/testing"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestNewClubRepository(t *testing.T) {
+	t.Run("with nil database", func(t *testing.T) {
+		repo, err := NewClubRepository(nil)
+		assert.Error(t, err)
+		assert.Equal(t, ErrNilDatabase, err)
+		assert.Nil(t, repo)
+	})
+
+	t.Run("with valid database", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("Skipping integration test")
+		}
+
+		db, clear := mongo_helpers.CreateTestMongoDB(t)
+		defer clear()
+
+		repo, err := NewClubRepository(db)
+		assert.NoError(t, err)
+		assert.NotNil(t, repo)
+	})
+}
+
+func TestSaveAndGetClub(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanClub(clear, mongoDB)
+	repo, err := NewClubRepository(mongoDB)
+	require.NoError(t, err)
+	ctx := testCtx(t)
+
+	club, err := repo.GetClubById(ctx, -100)
+	assert.NoError(t, err)
+	assert.Nil(t, club)
+
+	addedAt := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, repo.SaveClub(ctx, &models.Club{ChatID: -100, Title: "Sci-fi", AddedAt: addedAt}))
+	// Saving again updates in place (e.g. the group was renamed).
+	require.NoError(t, repo.SaveClub(ctx, &models.Club{ChatID: -100, Title: "Sci-fi & Fantasy", AddedAt: addedAt}))
+
+	club, err = repo.GetClubById(ctx, -100)
+	require.NoError(t, err)
+	require.NotNil(t, club)
+	assert.Equal(t, "Sci-fi & Fantasy", club.Title)
+	assert.Equal(t, addedAt, club.AddedAt.UTC())
+}
+
+func TestGetAllClubs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanClub(clear, mongoDB)
+	repo, err := NewClubRepository(mongoDB)
+	require.NoError(t, err)
+	ctx := testCtx(t)
+
+	now := time.Now().UTC()
+	require.NoError(t, repo.SaveClub(ctx, &models.Club{ChatID: -2, Title: "Newer", AddedAt: now}))
+	require.NoError(t, repo.SaveClub(ctx, &models.Club{ChatID: -1, Title: "Older", AddedAt: now.Add(-time.Hour)}))
+
+	clubs, err := repo.GetAllClubs(ctx)
+	require.NoError(t, err)
+	require.Len(t, clubs, 2)
+	assert.Equal(t, "Older", clubs[0].Title, "oldest first")
+	assert.Equal(t, "Newer", clubs[1].Title)
+}
+
+func TestDeleteClub(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanClub(clear, mongoDB)
+	repo, err := NewClubRepository(mongoDB)
+	require.NoError(t, err)
+	ctx := testCtx(t)
+
+	require.NoError(t, repo.SaveClub(ctx, &models.Club{ChatID: -100, Title: "Sci-fi"}))
+	require.NoError(t, repo.DeleteClub(ctx, -100))
+
+	club, err := repo.GetClubById(ctx, -100)
+	assert.NoError(t, err)
+	assert.Nil(t, club)
+
+	assert.ErrorIs(t, repo.DeleteClub(ctx, -100), ErrNotFound)
+}
+
+func TestSetClubAdmins(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanClub(clear, mongoDB)
+	repo, err := NewClubRepository(mongoDB)
+	require.NoError(t, err)
+	ctx := testCtx(t)
+
+	require.NoError(t, repo.SaveClub(ctx, &models.Club{ChatID: -100, Title: "Sci-fi"}))
+	syncedAt := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, repo.SetClubAdmins(ctx, -100, []int64{1, 2}, syncedAt))
+
+	// Re-saving the club (the bot re-added, the group renamed) keeps its admins.
+	require.NoError(t, repo.SaveClub(ctx, &models.Club{ChatID: -100, Title: "Sci-fi & Fantasy"}))
+
+	club, err := repo.GetClubById(ctx, -100)
+	require.NoError(t, err)
+	require.NotNil(t, club)
+	assert.Equal(t, []int64{1, 2}, club.AdminIDs)
+	require.NotNil(t, club.AdminsSyncedAt)
+	assert.Equal(t, syncedAt, club.AdminsSyncedAt.UTC())
+
+	assert.ErrorIs(t, repo.SetClubAdmins(ctx, -200, nil, syncedAt), ErrNotFound)
+}
+
+func cleanClub(clear func(), mongoDB *mongo.Database) {
+	clear()
+	mongo_helpers.DropCollection(mongoDB, clubs_collection)
+}
@@ -5,6 +5,6 @@ import "errors"
 // ErrNotFound is returned when a requested document does not exist.
 var ErrNotFound = errors.New("not found")
 
-// ErrActiveSessionExists is returned when creating a session while another
-// active (gathering/voting/reading) session already exists.
+// ErrActiveSessionExists is returned when creating a session while the club
+// already has another active (gathering/voting/reading) session.
 var ErrActiveSessionExists = errors.New("an active session already exists")
@@ -4,6 +4,7 @@ import (
 	"BookClubBot/internal/models"
 	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,9 +28,13 @@ func NewSessionRepository(db *mongo.Database) (*SessionRepository, error) {
 	}, nil
 }
 
+// legacyActiveIndex is the pre-multi-club "one active session overall" index.
+// EnsureIndexes drops it, as it would stop two clubs running rounds at once.
+const legacyActiveIndex = "uniq_active_session"
+
 // EnsureIndexes creates the indexes the session collection relies on:
-//   - a unique partial index on activeLock that allows at most one active
-//     session at a time (only active sessions carry the field);
+//   - a unique partial index on (chatId, activeLock) that allows at most one
+//     active session per club at a time (only active sessions carry the lock);
 //   - a descending createdAt index for history listing.
 //
 // MongoDB 6.0 does not support $in inside partialFilterExpression, so the
@@ -37,11 +42,14 @@ func NewSessionRepository(db *mongo.Database) (*SessionRepository, error) {
 // rather than a status set.
 func (s *SessionRepository) EnsureIndexes(ctx context.Context) error {
 	collection := s.db.Collection(sessions_collection)
+	if _, err := collection.Indexes().DropOne(ctx, legacyActiveIndex); err != nil && !isIndexNotFound(err) {
+		return err
+	}
 	_, err := collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
 		{
-			Keys: bson.D{{Key: "activeLock", Value: 1}},
+			Keys: bson.D{{Key: "chatId", Value: 1}, {Key: "activeLock", Value: 1}},
 			Options: options.Index().
-				SetName("uniq_active_session").
+				SetName("uniq_active_session_per_chat").
 				SetUnique(true).
 				SetPartialFilterExpression(bson.M{"activeLock": bson.M{"$exists": true}}),
 		},
@@ -56,7 +64,7 @@ func (s *SessionRepository) EnsureIndexes(ctx context.Context) error {
 // CreateSession inserts a new session. createdAt/updatedAt are stamped here, and
 // activeLock is set when the session starts in an active status so the unique
 // index is enforced. The generated ID is written back onto the session.
-// Returns ErrActiveSessionExists if another active session already exists.
+// Returns ErrActiveSessionExists if the club already has an active session.
 func (s *SessionRepository) CreateSession(ctx context.Context, session *models.BookClubSession) error {
 	now := time.Now().UTC()
 	session.CreatedAt = now
@@ -74,11 +82,11 @@ func (s *SessionRepository) CreateSession(ctx context.Context, session *models.B
 	return nil
 }
 
-// GetActiveSession returns the single active session, or (nil, nil) if there is
-// none.
-func (s *SessionRepository) GetActiveSession(ctx context.Context) (*models.BookClubSession, error) {
+// GetActiveSession returns the club's single active session, or (nil, nil) if
+// there is none.
+func (s *SessionRepository) GetActiveSession(ctx context.Context, chatID int64) (*models.BookClubSession, error) {
 	collection := s.db.Collection(sessions_collection)
-	filter := bson.M{"activeLock": bson.M{"$exists": true}}
+	filter := bson.M{"chatId": chatID, "activeLock": bson.M{"$exists": true}}
 
 	var session models.BookClubSession
 	if err := collection.FindOne(ctx, filter).Decode(&session); err != nil {
@@ -90,6 +98,23 @@ func (s *SessionRepository) GetActiveSession(ctx context.Context) (*models.BookC
 	return &session, nil
 }
 
+// GetActiveSessions returns the active session of every club, for the recovery
+// loop and for routing updates that do not name a club.
+func (s *SessionRepository) GetActiveSessions(ctx context.Context) ([]*models.BookClubSession, error) {
+	collection := s.db.Collection(sessions_collection)
+	cursor, err := collection.Find(ctx, bson.M{"activeLock": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*models.BookClubSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
 // GetSessionById returns the session with the given id, or (nil, nil) if none.
 func (s *SessionRepository) GetSessionById(ctx context.Context, id primitive.ObjectID) (*models.BookClubSession, error) {
 	collection := s.db.Collection(sessions_collection)
@@ -127,6 +152,117 @@ func (s *SessionRepository) UpdateParticipant(ctx context.Context, id primitive.
 	return nil
 }
 
+// UpdateReadingMember replaces the reading member matching
+// member.SubscriberID. Returns ErrNotFound if no such member exists.
+func (s *SessionRepository) UpdateReadingMember(ctx context.Context, id primitive.ObjectID, member *models.ReadingMember) error {
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{
+		"_id":                          id,
+		"reading.members.subscriberId": member.SubscriberID,
+	}
+	update := bson.M{"$set": bson.M{
+		"reading.members.$": member,
+		"updatedAt":         time.Now().UTC(),
+	}}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateBallot replaces the ranked-choice ballot matching ballot.VoterID.
+// Returns ErrNotFound if the voter has no ballot.
+func (s *SessionRepository) UpdateBallot(ctx context.Context, id primitive.ObjectID, ballot *models.Ballot) error {
+	if ballot.Ranking == nil {
+		ballot.Ranking = []int{}
+	}
+
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{
+		"_id":                    id,
+		"voting.ballots.voterId": ballot.VoterID,
+	}
+	update := bson.M{"$set": bson.M{
+		"voting.ballots.$": ballot,
+		"updatedAt":        time.Now().UTC(),
+	}}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetAnswer stores a voter's answer to a poll, replacing their previous answer
+// to the same poll. It returns ErrNotFound if voting has not started.
+func (s *SessionRepository) SetAnswer(ctx context.Context, id primitive.ObjectID, answer *models.Answer) error {
+	if answer.OptionIDs == nil {
+		answer.OptionIDs = []int{}
+	}
+
+	collection := s.db.Collection(sessions_collection)
+	now := time.Now().UTC()
+	filter := bson.M{
+		"_id":            id,
+		"voting.answers": bson.M{"$elemMatch": bson.M{"pollId": answer.PollID, "voterId": answer.VoterID}},
+	}
+	update := bson.M{"$set": bson.M{
+		"voting.answers.$": answer,
+		"updatedAt":        now,
+	}}
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	// First answer of this voter to this poll.
+	filter = bson.M{"_id": id, "voting": bson.M{"$ne": nil}}
+	update = bson.M{
+		"$push": bson.M{"voting.answers": answer},
+		"$set":  bson.M{"updatedAt": now},
+	}
+	res, err = collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddPollResult archives a closed poll of the round. It returns ErrNotFound if
+// voting has not started.
+func (s *SessionRepository) AddPollResult(ctx context.Context, id primitive.ObjectID, result *models.PollResult) error {
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{"_id": id, "voting": bson.M{"$ne": nil}}
+	update := bson.M{
+		"$push": bson.M{"voting.results": result},
+		"$set":  bson.M{"updatedAt": time.Now().UTC()},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // AddVoter records that a subscriber has voted (idempotent via $addToSet).
 // It requires voting to have started; if the session has no voting sub-document
 // yet, it returns ErrNotFound rather than a raw "$addToSet on null" write error.
@@ -148,19 +284,138 @@ func (s *SessionRepository) AddVoter(ctx context.Context, id primitive.ObjectID,
 	return nil
 }
 
+// AddOutsider records that someone who is not an eligible voter answered the
+// poll (idempotent via $addToSet). It returns ErrNotFound if voting has not
+// started.
+func (s *SessionRepository) AddOutsider(ctx context.Context, id primitive.ObjectID, voterID int64) error {
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{"_id": id, "voting": bson.M{"$ne": nil}}
+	update := bson.M{
+		"$addToSet": bson.M{"voting.outsiderIds": voterID},
+		"$set":      bson.M{"updatedAt": time.Now().UTC()},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveVoter takes back a subscriber's vote, e.g. when they retract it in the
+// poll. It returns ErrNotFound if voting has not started.
+func (s *SessionRepository) RemoveVoter(ctx context.Context, id primitive.ObjectID, voterID int64) error {
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{"_id": id, "voting": bson.M{"$ne": nil}}
+	update := bson.M{
+		"$pull": bson.M{"voting.voterIds": voterID},
+		"$set":  bson.M{"updatedAt": time.Now().UTC()},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddHeatVoter records that a subscriber has voted in a preliminary poll
+// (idempotent via $addToSet). It returns ErrNotFound if the session has no such
+// heat.
+func (s *SessionRepository) AddHeatVoter(ctx context.Context, id primitive.ObjectID, heat int, voterID int64) error {
+	collection := s.db.Collection(sessions_collection)
+	field := fmt.Sprintf("voting.heats.%d", heat)
+	filter := bson.M{"_id": id, field: bson.M{"$exists": true}}
+	update := bson.M{
+		"$addToSet": bson.M{field + ".voterIds": voterID},
+		"$set":      bson.M{"updatedAt": time.Now().UTC()},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveHeatVoter takes back a subscriber's vote in a preliminary poll. It
+// returns ErrNotFound if the session has no such heat.
+func (s *SessionRepository) RemoveHeatVoter(ctx context.Context, id primitive.ObjectID, heat int, voterID int64) error {
+	collection := s.db.Collection(sessions_collection)
+	field := fmt.Sprintf("voting.heats.%d", heat)
+	filter := bson.M{"_id": id, field: bson.M{"$exists": true}}
+	update := bson.M{
+		"$pull": bson.M{field + ".voterIds": voterID},
+		"$set":  bson.M{"updatedAt": time.Now().UTC()},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CloseHeat stores the indexes of the options a preliminary poll sent through
+// and stamps when it was closed.
+func (s *SessionRepository) CloseHeat(ctx context.Context, id primitive.ObjectID, heat int, advanced []int, at time.Time) error {
+	collection := s.db.Collection(sessions_collection)
+	field := fmt.Sprintf("voting.heats.%d", heat)
+	filter := bson.M{"_id": id, field: bson.M{"$exists": true}}
+	update := bson.M{"$set": bson.M{
+		field + ".advancedIndexes": advanced,
+		field + ".closedAt":        at.UTC(),
+		"updatedAt":                time.Now().UTC(),
+	}}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // StartVoting attaches the voting sub-document and moves the session into the
 // voting status. It (re)asserts activeLock so the "active status ⟺ activeLock
 // present" invariant holds locally, rather than relying on the lock already
 // being there; setting it on the same document is a no-op, and the unique index
-// surfaces ErrActiveSessionExists if another session is somehow active.
+// surfaces ErrActiveSessionExists if another session of the club is somehow active.
+// The session must already be voting (runTelegramPollFlow claims the status
+// before it sends the poll): one cancelled meanwhile is left alone and
+// ErrNotFound returned, so a cancelled round cannot come back to life.
 func (s *SessionRepository) StartVoting(ctx context.Context, id primitive.ObjectID, voting *models.Voting) error {
-	// Store an empty array (not BSON null) so later $addToSet on voterIds works.
+	// Store empty arrays (not BSON null) so later $addToSet on voterIds works.
 	if voting.VoterIDs == nil {
 		voting.VoterIDs = []int64{}
 	}
+	for _, h := range voting.Heats {
+		if h.VoterIDs == nil {
+			h.VoterIDs = []int64{}
+		}
+	}
+	for _, b := range voting.Ballots {
+		if b.Ranking == nil {
+			b.Ranking = []int{}
+		}
+	}
 
 	collection := s.db.Collection(sessions_collection)
-	filter := bson.M{"_id": id}
+	filter := bson.M{"_id": id, "status": models.StatusVoting}
 	update := bson.M{"$set": bson.M{
 		"voting":     voting,
 		"status":     models.StatusVoting,
@@ -178,6 +433,60 @@ func (s *SessionRepository) StartVoting(ctx context.Context, id primitive.Object
 	return nil
 }
 
+// StartReading attaches the reading sub-document and moves the session into the
+// reading status. Like StartVoting it (re)asserts activeLock, since reading is
+// still an active status, and requires the session to still be voting: it
+// returns ErrNotFound for a session cancelled meanwhile.
+func (s *SessionRepository) StartReading(ctx context.Context, id primitive.ObjectID, reading *models.Reading) error {
+	// Store empty arrays (not BSON null) so positional updates on members and
+	// $addToSet on milestonesSent work.
+	if reading.Members == nil {
+		reading.Members = []*models.ReadingMember{}
+	}
+	if reading.MilestonesSent == nil {
+		reading.MilestonesSent = []int{}
+	}
+
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{"_id": id, "status": models.StatusVoting}
+	update := bson.M{"$set": bson.M{
+		"reading":    reading,
+		"status":     models.StatusReading,
+		"activeLock": true,
+		"updatedAt":  time.Now().UTC(),
+	}}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return mapActiveLockConflict(err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AddReadingMilestone records that the nudge for a reading milestone has been
+// sent (idempotent via $addToSet). Like AddVoter it requires the reading
+// sub-document to exist and returns ErrNotFound otherwise.
+func (s *SessionRepository) AddReadingMilestone(ctx context.Context, id primitive.ObjectID, milestone int) error {
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{"_id": id, "reading": bson.M{"$ne": nil}}
+	update := bson.M{
+		"$addToSet": bson.M{"reading.milestonesSent": milestone},
+		"$set":      bson.M{"updatedAt": time.Now().UTC()},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // SetWinners stores the winning book(s) of a session.
 func (s *SessionRepository) SetWinners(ctx context.Context, id primitive.ObjectID, winners []models.Winner) error {
 	collection := s.db.Collection(sessions_collection)
@@ -197,6 +506,11 @@ func (s *SessionRepository) SetWinners(ctx context.Context, id primitive.ObjectI
 	return nil
 }
 
+// SetTieBreak records how a tie in the session's poll was resolved.
+func (s *SessionRepository) SetTieBreak(ctx context.Context, id primitive.ObjectID, tieBreak *models.TieBreak) error {
+	return s.setField(ctx, id, "tieBreak", tieBreak)
+}
+
 // SetStatus transitions a session to a new status. Moving to a terminal status
 // (completed/cancelled) releases the active lock so a new session can start;
 // moving to an active status (re)asserts it.
@@ -237,16 +551,30 @@ func (s *SessionRepository) SetVotingClosed(ctx context.Context, id primitive.Ob
 	return s.setField(ctx, id, "voting.closedAt", at.UTC())
 }
 
-// ListPastSessions returns completed sessions, newest first, up to limit
-// (limit <= 0 means no limit).
-func (s *SessionRepository) ListPastSessions(ctx context.Context, limit int64) ([]*models.BookClubSession, error) {
+// ExtendGathering moves the gathering deadline and reminder time and clears
+// notifiedAt, so the reminder is sent again before the new deadline. Returns
+// ErrNotFound unless the session is still gathering.
+func (s *SessionRepository) ExtendGathering(ctx context.Context, id primitive.ObjectID, deadline, notifyAt time.Time) error {
+	return s.extendPhase(ctx, id, "gathering", models.StatusGathering, deadline, notifyAt)
+}
+
+// ExtendVoting moves the voting deadline and reminder time and clears
+// notifiedAt, so the reminder is sent again before the new deadline. Returns
+// ErrNotFound unless the session is still voting.
+func (s *SessionRepository) ExtendVoting(ctx context.Context, id primitive.ObjectID, deadline, notifyAt time.Time) error {
+	return s.extendPhase(ctx, id, "voting", models.StatusVoting, deadline, notifyAt)
+}
+
+// ListPastSessions returns a club's completed sessions, newest first, up to
+// limit (limit <= 0 means no limit).
+func (s *SessionRepository) ListPastSessions(ctx context.Context, chatID int64, limit int64) ([]*models.BookClubSession, error) {
 	collection := s.db.Collection(sessions_collection)
 	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}})
 	if limit > 0 {
 		opts.SetLimit(limit)
 	}
 
-	cursor, err := collection.Find(ctx, bson.M{"status": models.StatusCompleted}, opts)
+	cursor, err := collection.Find(ctx, bson.M{"chatId": chatID, "status": models.StatusCompleted}, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -259,6 +587,70 @@ func (s *SessionRepository) ListPastSessions(ctx context.Context, limit int64) (
 	return sessions, nil
 }
 
+// ListPastWinners returns a club's completed sessions, newest first, with only
+// their winners, createdAt and reading.startedAt loaded: what checking a book
+// against the club's history needs, without every gathered book and ballot.
+func (s *SessionRepository) ListPastWinners(ctx context.Context, chatID int64) ([]*models.BookClubSession, error) {
+	collection := s.db.Collection(sessions_collection)
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}}).
+		SetProjection(bson.M{"winners": 1, "createdAt": 1, "reading.startedAt": 1})
+
+	cursor, err := collection.Find(ctx, bson.M{"chatId": chatID, "status": models.StatusCompleted}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*models.BookClubSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// ListUncataloguedSessions returns the sessions past gathering (voting,
+// reading or completed) with a submitted book that has no book catalog
+// reference yet, oldest first. They are what the catalog backfill records.
+func (s *SessionRepository) ListUncataloguedSessions(ctx context.Context) ([]*models.BookClubSession, error) {
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{
+		"status": bson.M{"$in": []string{models.StatusVoting, models.StatusReading, models.StatusCompleted}},
+		"gathering.participants": bson.M{"$elemMatch": bson.M{
+			"step":   models.StepDone,
+			"book":   bson.M{"$ne": nil},
+			"bookId": bson.M{"$exists": false},
+		}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}})
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var sessions []*models.BookClubSession
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// AssignLegacyChat moves every session that predates multi-club support (no
+// chatId field) to the given club. It returns how many were updated.
+func (s *SessionRepository) AssignLegacyChat(ctx context.Context, chatID int64) (int64, error) {
+	collection := s.db.Collection(sessions_collection)
+	filter := bson.M{"chatId": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"chatId": chatID}}
+
+	res, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
+
 func (s *SessionRepository) setField(ctx context.Context, id primitive.ObjectID, field string, value any) error {
 	collection := s.db.Collection(sessions_collection)
 	update := bson.M{"$set": bson.M{
@@ -276,6 +668,28 @@ func (s *SessionRepository) setField(ctx context.Context, id primitive.ObjectID,
 	return nil
 }
 
+// extendPhase moves a phase's deadline while the session is in status, so a
+// round that has moved on, completed or been cancelled meanwhile is left as
+// it is.
+func (s *SessionRepository) extendPhase(ctx context.Context, id primitive.ObjectID, phase, status string, deadline, notifyAt time.Time) error {
+	collection := s.db.Collection(sessions_collection)
+	update := bson.M{"$set": bson.M{
+		phase + ".deadline":   deadline.UTC(),
+		phase + ".notifyAt":   notifyAt.UTC(),
+		phase + ".notifiedAt": nil,
+		"updatedAt":           time.Now().UTC(),
+	}}
+
+	res, err := collection.UpdateOne(ctx, bson.M{"_id": id, "status": status}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // mapActiveLockConflict translates a duplicate-key error from the unique
 // activeLock index into ErrActiveSessionExists, so every write that could
 // collide with an existing active session reports the condition the same way.
@@ -286,6 +700,16 @@ func mapActiveLockConflict(err error) error {
 	return err
 }
 
+// isIndexNotFound reports whether dropping an index failed only because the
+// index (or the whole collection) does not exist.
+func isIndexNotFound(err error) bool {
+	var cmdErr mongo.CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	return cmdErr.Code == 27 || cmdErr.Code == 26 // IndexNotFound, NamespaceNotFound
+}
+
 // activeLock returns a pointer to true when active, or nil so the field is
 // omitted from the document (omitempty) and excluded from the unique index.
 func activeLock(active bool) *bool {
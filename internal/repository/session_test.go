@@ -9,6 +9,8 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
@@ -50,6 +52,11 @@ func TestCreateSession_EnforcesSingleActive(t *testing.T) {
 	err := repo.CreateSession(ctx, second)
 	assert.ErrorIs(t, err, ErrActiveSessionExists)
 
+	// Another club runs its own round independently.
+	other := newGatheringSession(200)
+	other.ChatID = testClubID - 1
+	assert.NoError(t, repo.CreateSession(ctx, other))
+
 	// Once the first is completed (lock released), a new one may start.
 	require.NoError(t, repo.SetStatus(ctx, first.ID, models.StatusCompleted))
 	third := newGatheringSession(300)
@@ -67,26 +74,92 @@ func TestGetActiveSession(t *testing.T) {
 	ctx := testCtx(t)
 
 	// No active session yet.
-	active, err := repo.GetActiveSession(ctx)
+	active, err := repo.GetActiveSession(ctx, testClubID)
 	assert.NoError(t, err)
 	assert.Nil(t, active)
 
 	session := newGatheringSession(100)
 	require.NoError(t, repo.CreateSession(ctx, session))
 
-	active, err = repo.GetActiveSession(ctx)
+	active, err = repo.GetActiveSession(ctx, testClubID)
 	assert.NoError(t, err)
 	require.NotNil(t, active)
 	assert.Equal(t, session.ID, active.ID)
 	assert.Equal(t, models.StatusGathering, active.Status)
 
+	// Another club's session is not this club's active one.
+	other, err := repo.GetActiveSession(ctx, testClubID-1)
+	assert.NoError(t, err)
+	assert.Nil(t, other)
+
 	// Completing it makes GetActiveSession return nil again.
 	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusCompleted))
-	active, err = repo.GetActiveSession(ctx)
+	active, err = repo.GetActiveSession(ctx, testClubID)
 	assert.NoError(t, err)
 	assert.Nil(t, active)
 }
 
+func TestGetActiveSessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	first := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, first))
+	second := newGatheringSession(200)
+	second.ChatID = testClubID - 1
+	require.NoError(t, repo.CreateSession(ctx, second))
+	done := newGatheringSession(300)
+	done.ChatID = testClubID - 2
+	require.NoError(t, repo.CreateSession(ctx, done))
+	require.NoError(t, repo.SetStatus(ctx, done.ID, models.StatusCompleted))
+
+	active, err := repo.GetActiveSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, active, 2)
+	ids := []primitive.ObjectID{active[0].ID, active[1].ID}
+	assert.ElementsMatch(t, []primitive.ObjectID{first.ID, second.ID}, ids)
+}
+
+func TestAssignLegacyChat(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	// A pre-multi-club session has no chatId field at all.
+	_, err := mongoDB.Collection(sessions_collection).InsertOne(ctx, bson.M{
+		"name":   "Legacy",
+		"status": models.StatusCompleted,
+	})
+	require.NoError(t, err)
+	current := newGatheringSession(100)
+	current.ChatID = testClubID - 1
+	require.NoError(t, repo.CreateSession(ctx, current))
+
+	n, err := repo.AssignLegacyChat(ctx, testClubID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	past, err := repo.ListPastSessions(ctx, testClubID, 0)
+	require.NoError(t, err)
+	require.Len(t, past, 1)
+	assert.Equal(t, "Legacy", past[0].Name)
+
+	stored, err := repo.GetSessionById(ctx, current.ID)
+	require.NoError(t, err)
+	assert.Equal(t, testClubID-1, stored.ChatID, "sessions with a club are left alone")
+}
+
 func TestUpdateParticipant(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
@@ -142,6 +215,7 @@ func TestAddVoter_Idempotent(t *testing.T) {
 
 	session := newGatheringSession(100)
 	require.NoError(t, repo.CreateSession(ctx, session))
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
 	require.NoError(t, repo.StartVoting(ctx, session.ID, newVoting()))
 
 	require.NoError(t, repo.AddVoter(ctx, session.ID, 100))
@@ -154,6 +228,128 @@ func TestAddVoter_Idempotent(t *testing.T) {
 	assert.ElementsMatch(t, []int64{100, 200}, stored.Voting.VoterIDs)
 }
 
+func TestSetAnswer(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	assert.ErrorIs(t, repo.SetAnswer(ctx, session.ID, &models.Answer{PollID: "p", VoterID: 100}), ErrNotFound)
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, newVoting()))
+
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, repo.SetAnswer(ctx, session.ID, &models.Answer{PollID: "p", VoterID: 100, OptionIDs: []int{0, 1, 2}, AnsweredAt: at}))
+	require.NoError(t, repo.SetAnswer(ctx, session.ID, &models.Answer{PollID: "p", VoterID: 200, OptionIDs: []int{1}, AnsweredAt: at}))
+	require.NoError(t, repo.SetAnswer(ctx, session.ID, &models.Answer{PollID: "heat", VoterID: 100, OptionIDs: []int{3}, AnsweredAt: at}))
+	require.NoError(t, repo.SetAnswer(ctx, session.ID, &models.Answer{PollID: "p", VoterID: 100, OptionIDs: []int{2}, AnsweredAt: at}))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Voting.Answers, 3, "a revote replaces the answer to the same poll")
+	assert.Equal(t, []int{2}, stored.Voting.Answers[0].OptionIDs)
+	assert.Equal(t, []int{1}, stored.Voting.Answers[1].OptionIDs)
+	assert.Equal(t, "heat", stored.Voting.Answers[2].PollID)
+	assert.Equal(t, at, stored.Voting.Answers[0].AnsweredAt.UTC())
+}
+
+func TestAddPollResult(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	result := &models.PollResult{
+		PollID:         "p",
+		TelegramPollID: 7,
+		Options:        []models.PollOption{{Text: "a", SubscriberID: 100, Title: "a", Votes: 2}, {Text: "b", Votes: 1}},
+		TotalVoters:    3,
+		ClosedAt:       time.Now().UTC().Truncate(time.Millisecond),
+	}
+	assert.ErrorIs(t, repo.AddPollResult(ctx, session.ID, result), ErrNotFound)
+
+	voting := newVoting()
+	voting.Options = []models.PollOption{{Text: "a", SubscriberID: 100, Title: "a"}, {Text: "b"}}
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, voting))
+	require.NoError(t, repo.AddPollResult(ctx, session.ID, result))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, voting.Options, stored.Voting.Options)
+	require.Len(t, stored.Voting.Results, 1)
+	assert.Equal(t, result.Options, stored.Voting.Results[0].Options)
+	assert.Equal(t, result.ClosedAt, stored.Voting.Results[0].ClosedAt.UTC())
+}
+
+func TestRemoveVoter(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	assert.ErrorIs(t, repo.RemoveVoter(ctx, session.ID, 100), ErrNotFound)
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, newVoting()))
+
+	require.NoError(t, repo.AddVoter(ctx, session.ID, 100))
+	require.NoError(t, repo.AddVoter(ctx, session.ID, 200))
+	require.NoError(t, repo.RemoveVoter(ctx, session.ID, 100))
+	require.NoError(t, repo.RemoveVoter(ctx, session.ID, 300), "removing a non-voter is a no-op")
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{200}, stored.Voting.VoterIDs)
+}
+
+func TestAddOutsider(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	assert.ErrorIs(t, repo.AddOutsider(ctx, session.ID, 500), ErrNotFound)
+
+	voting := newVoting()
+	voting.Eligibility = models.EligibleParticipants
+	voting.EligibleIDs = []int64{100}
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, voting))
+	require.NoError(t, repo.AddOutsider(ctx, session.ID, 500))
+	require.NoError(t, repo.AddOutsider(ctx, session.ID, 500))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.EligibleParticipants, stored.Voting.Eligibility)
+	assert.Equal(t, []int64{100}, stored.Voting.EligibleIDs)
+	assert.Equal(t, []int64{500}, stored.Voting.OutsiderIDs)
+	assert.Empty(t, stored.Voting.VoterIDs)
+}
+
 func TestAddVoter_BeforeVotingStarts(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
@@ -185,6 +381,7 @@ func TestStartVotingAndSetWinners(t *testing.T) {
 	session := newGatheringSession(100)
 	require.NoError(t, repo.CreateSession(ctx, session))
 
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
 	require.NoError(t, repo.StartVoting(ctx, session.ID, newVoting()))
 	stored, err := repo.GetSessionById(ctx, session.ID)
 	require.NoError(t, err)
@@ -193,7 +390,7 @@ func TestStartVotingAndSetWinners(t *testing.T) {
 	assert.Equal(t, 42, stored.Voting.TelegramPollID)
 
 	// Session is still active during voting.
-	active, err := repo.GetActiveSession(ctx)
+	active, err := repo.GetActiveSession(ctx, testClubID)
 	require.NoError(t, err)
 	require.NotNil(t, active)
 	assert.Equal(t, session.ID, active.ID)
@@ -204,6 +401,253 @@ func TestStartVotingAndSetWinners(t *testing.T) {
 	require.NoError(t, err)
 	require.Len(t, stored.Winners, 1)
 	assert.Equal(t, "The Pragmatic Programmer", stored.Winners[0].Title)
+	assert.Nil(t, stored.TieBreak)
+
+	tieBreak := &models.TieBreak{Method: models.TieBreakRandom, Tied: append(winners, models.Winner{SubscriberID: 200, Title: "Refactoring"}), Seed: 7}
+	require.NoError(t, repo.SetTieBreak(ctx, session.ID, tieBreak))
+	stored, err = repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, tieBreak, stored.TieBreak)
+}
+
+func TestHeats(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	voting := newVoting()
+	voting.TelegramPollID = 0
+	voting.Heats = []*models.Heat{
+		{Stage: 1, TelegramPollID: 1, PollID: "h1", Options: []string{"a", "b"}},
+		{Stage: 1, TelegramPollID: 2, PollID: "h2", Options: []string{"c", "d"}},
+	}
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, voting))
+
+	require.NoError(t, repo.AddHeatVoter(ctx, session.ID, 1, 100))
+	require.NoError(t, repo.AddHeatVoter(ctx, session.ID, 1, 100))
+	require.NoError(t, repo.AddHeatVoter(ctx, session.ID, 1, 200))
+	require.NoError(t, repo.RemoveHeatVoter(ctx, session.ID, 1, 200))
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, repo.CloseHeat(ctx, session.ID, 0, []int{1}, at))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	require.Len(t, stored.Voting.Heats, 2)
+	assert.Empty(t, stored.Voting.Heats[0].VoterIDs)
+	assert.Equal(t, []int64{100}, stored.Voting.Heats[1].VoterIDs, "voting twice counts once")
+	assert.Equal(t, []int{1}, stored.Voting.Heats[0].Advanced)
+	require.NotNil(t, stored.Voting.Heats[0].ClosedAt)
+	assert.Equal(t, at, stored.Voting.Heats[0].ClosedAt.UTC())
+	assert.Nil(t, stored.Voting.Heats[1].ClosedAt)
+
+	assert.ErrorIs(t, repo.AddHeatVoter(ctx, session.ID, 2, 100), ErrNotFound)
+	assert.ErrorIs(t, repo.CloseHeat(ctx, session.ID, 2, nil, at), ErrNotFound)
+	assert.ErrorIs(t, repo.RemoveHeatVoter(ctx, session.ID, 2, 100), ErrNotFound)
+}
+
+func TestUpdateBallot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	voting := newVoting()
+	voting.Mode = models.VotingRanked
+	voting.TelegramPollID = 0
+	voting.Candidates = []models.Candidate{{SubscriberID: 100, Title: "a"}, {SubscriberID: 200, Title: "b"}}
+	voting.Ballots = []*models.Ballot{{VoterID: 100, MessageID: 1}, {VoterID: 200, MessageID: 2}}
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, voting))
+
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, repo.UpdateBallot(ctx, session.ID, &models.Ballot{VoterID: 200, MessageID: 2, Ranking: []int{1, 0}, SubmittedAt: &at}))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.VotingRanked, stored.Voting.Mode)
+	assert.Len(t, stored.Voting.Candidates, 2)
+	require.Len(t, stored.Voting.Ballots, 2)
+	assert.Empty(t, stored.Voting.Ballots[0].Ranking)
+	assert.Nil(t, stored.Voting.Ballots[0].SubmittedAt)
+	assert.Equal(t, []int{1, 0}, stored.Voting.Ballots[1].Ranking)
+	require.NotNil(t, stored.Voting.Ballots[1].SubmittedAt)
+	assert.Equal(t, at, stored.Voting.Ballots[1].SubmittedAt.UTC())
+
+	assert.ErrorIs(t, repo.UpdateBallot(ctx, session.ID, &models.Ballot{VoterID: 300}), ErrNotFound)
+}
+
+func TestStartReading(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, newVoting()))
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	reading := &models.Reading{
+		Book:         models.Book{Title: "The Pragmatic Programmer", Author: "David Thomas"},
+		SubscriberID: 100,
+		Deadline:     now.Add(30 * 24 * time.Hour),
+		StartedAt:    now,
+		Members: []*models.ReadingMember{
+			{SubscriberID: 100, Status: models.ReadingInProgress, StartedAt: now},
+			{SubscriberID: 200, Status: models.ReadingInProgress, StartedAt: now},
+		},
+	}
+	require.NoError(t, repo.StartReading(ctx, session.ID, reading))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusReading, stored.Status)
+	require.NotNil(t, stored.Reading)
+	assert.Equal(t, "The Pragmatic Programmer", stored.Reading.Book.Title)
+	assert.Equal(t, int64(100), stored.Reading.SubscriberID)
+	assert.Equal(t, reading.Deadline, stored.Reading.Deadline.UTC())
+	require.Len(t, stored.Reading.Members, 2)
+	assert.Equal(t, models.ReadingInProgress, stored.Reading.Members[1].Status)
+
+	// Reading is an active status: the lock is still held.
+	active, err := repo.GetActiveSession(ctx, testClubID)
+	require.NoError(t, err)
+	require.NotNil(t, active)
+	assert.Equal(t, session.ID, active.ID)
+
+	err = repo.StartReading(ctx, primitive.NewObjectID(), &models.Reading{})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStartVotingAfterCancel(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	// The round is cancelled while its poll is being sent: neither the voting
+	// nor the reading may revive it.
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusCancelled))
+
+	assert.ErrorIs(t, repo.StartVoting(ctx, session.ID, newVoting()), ErrNotFound)
+	assert.ErrorIs(t, repo.StartReading(ctx, session.ID, &models.Reading{}), ErrNotFound)
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusCancelled, stored.Status)
+	assert.Nil(t, stored.Voting)
+
+	active, err := repo.GetActiveSession(ctx, testClubID)
+	require.NoError(t, err)
+	assert.Nil(t, active, "no active lock is taken back")
+}
+
+func TestUpdateReadingMember(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartReading(ctx, session.ID, &models.Reading{
+		Book: models.Book{Title: "Dune"},
+		Members: []*models.ReadingMember{
+			{SubscriberID: 100, Status: models.ReadingInProgress, StartedAt: now},
+			{SubscriberID: 200, Status: models.ReadingInProgress, StartedAt: now},
+		},
+	}))
+
+	rating, review := 4, "Loved the worldbuilding."
+	updated := &models.ReadingMember{
+		SubscriberID: 200,
+		Status:       models.ReadingFinished,
+		Step:         models.ReviewStepDone,
+		Rating:       &rating,
+		Review:       &review,
+		StartedAt:    now,
+		FinishedAt:   &now,
+	}
+	require.NoError(t, repo.UpdateReadingMember(ctx, session.ID, updated))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Reading)
+	require.Len(t, stored.Reading.Members, 2)
+	assert.Equal(t, models.ReadingInProgress, stored.Reading.Members[0].Status, "other members are untouched")
+	m := stored.Reading.Members[1]
+	assert.Equal(t, models.ReadingFinished, m.Status)
+	assert.Equal(t, models.ReviewStepDone, m.Step)
+	require.NotNil(t, m.Rating)
+	assert.Equal(t, 4, *m.Rating)
+	require.NotNil(t, m.Review)
+	assert.Equal(t, review, *m.Review)
+	require.NotNil(t, m.FinishedAt)
+
+	// Unknown member → ErrNotFound.
+	err = repo.UpdateReadingMember(ctx, session.ID, &models.ReadingMember{SubscriberID: 999})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestAddReadingMilestone(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+
+	// reading sub-document does not exist yet → ErrNotFound, not a write error.
+	err := repo.AddReadingMilestone(ctx, session.ID, 50)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartReading(ctx, session.ID, &models.Reading{Book: models.Book{Title: "Dune"}}))
+	require.NoError(t, repo.AddReadingMilestone(ctx, session.ID, 50))
+	require.NoError(t, repo.AddReadingMilestone(ctx, session.ID, 50)) // duplicate
+	require.NoError(t, repo.AddReadingMilestone(ctx, session.ID, 75))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	require.NotNil(t, stored.Reading)
+	assert.ElementsMatch(t, []int{50, 75}, stored.Reading.MilestonesSent)
 }
 
 func TestSetGatheringAndVotingNotified(t *testing.T) {
@@ -218,6 +662,7 @@ func TestSetGatheringAndVotingNotified(t *testing.T) {
 
 	session := newGatheringSession(100)
 	require.NoError(t, repo.CreateSession(ctx, session))
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
 	require.NoError(t, repo.StartVoting(ctx, session.ID, newVoting()))
 
 	at := time.Now().UTC().Truncate(time.Millisecond)
@@ -235,6 +680,49 @@ func TestSetGatheringAndVotingNotified(t *testing.T) {
 	assert.Equal(t, at, stored.Voting.ClosedAt.UTC())
 }
 
+func TestExtendGatheringAndVoting(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+
+	at := time.Now().UTC().Truncate(time.Millisecond)
+	deadline := at.Add(48 * time.Hour)
+	notifyAt := at.Add(36 * time.Hour)
+	require.NoError(t, repo.SetGatheringNotified(ctx, session.ID, at))
+	assert.ErrorIs(t, repo.ExtendVoting(ctx, session.ID, deadline, notifyAt), ErrNotFound, "not voting yet")
+	require.NoError(t, repo.ExtendGathering(ctx, session.ID, deadline, notifyAt))
+
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, newVoting()))
+	require.NoError(t, repo.SetVotingNotified(ctx, session.ID, at))
+	assert.ErrorIs(t, repo.ExtendGathering(ctx, session.ID, deadline.Add(time.Hour), notifyAt), ErrNotFound, "gathering is over")
+	require.NoError(t, repo.ExtendVoting(ctx, session.ID, deadline, notifyAt))
+
+	stored, err := repo.GetSessionById(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, deadline, stored.Gathering.Deadline.UTC())
+	assert.Equal(t, notifyAt, stored.Gathering.NotifyAt.UTC())
+	assert.Nil(t, stored.Gathering.NotifiedAt, "the reminder is sent again")
+	assert.Equal(t, deadline, stored.Voting.Deadline.UTC())
+	assert.Equal(t, notifyAt, stored.Voting.NotifyAt.UTC())
+	assert.Nil(t, stored.Voting.NotifiedAt, "the reminder is sent again")
+
+	assert.ErrorIs(t, repo.ExtendVoting(ctx, primitive.NewObjectID(), deadline, notifyAt), ErrNotFound)
+
+	for _, status := range []string{models.StatusCompleted, models.StatusCancelled} {
+		require.NoError(t, repo.SetStatus(ctx, session.ID, status))
+		assert.ErrorIs(t, repo.ExtendVoting(ctx, session.ID, deadline, notifyAt), ErrNotFound, status)
+	}
+}
+
 func TestListPastSessions(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test")
@@ -261,18 +749,96 @@ func TestListPastSessions(t *testing.T) {
 	active := newGatheringSession(300)
 	require.NoError(t, repo.CreateSession(ctx, active))
 
-	past, err := repo.ListPastSessions(ctx, 0)
+	// So must another club's history.
+	foreign := newGatheringSession(400)
+	foreign.ChatID = testClubID - 1
+	require.NoError(t, repo.CreateSession(ctx, foreign))
+	require.NoError(t, repo.SetStatus(ctx, foreign.ID, models.StatusCompleted))
+
+	past, err := repo.ListPastSessions(ctx, testClubID, 0)
 	require.NoError(t, err)
 	require.Len(t, past, 2)
 	assert.Equal(t, "June 2026", past[0].Name, "newest first")
 	assert.Equal(t, "May 2026", past[1].Name)
 
-	limited, err := repo.ListPastSessions(ctx, 1)
+	limited, err := repo.ListPastSessions(ctx, testClubID, 1)
 	require.NoError(t, err)
 	require.Len(t, limited, 1)
 	assert.Equal(t, "June 2026", limited[0].Name)
 }
 
+func TestListPastWinners(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	session := newGatheringSession(100)
+	require.NoError(t, repo.CreateSession(ctx, session))
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusVoting))
+	require.NoError(t, repo.StartVoting(ctx, session.ID, newVoting()))
+	require.NoError(t, repo.SetWinners(ctx, session.ID, []models.Winner{{SubscriberID: 100, Title: "Dune"}}))
+	startedAt := time.Now().UTC().Truncate(time.Millisecond)
+	require.NoError(t, repo.StartReading(ctx, session.ID, &models.Reading{Book: models.Book{Title: "Dune"}, StartedAt: startedAt}))
+	require.NoError(t, repo.SetStatus(ctx, session.ID, models.StatusCompleted))
+
+	// An active session is not history.
+	require.NoError(t, repo.CreateSession(ctx, newGatheringSession(200)))
+
+	past, err := repo.ListPastWinners(ctx, testClubID)
+	require.NoError(t, err)
+	require.Len(t, past, 1)
+	assert.Equal(t, []models.Winner{{SubscriberID: 100, Title: "Dune"}}, past[0].Winners)
+	assert.False(t, past[0].CreatedAt.IsZero())
+	require.NotNil(t, past[0].Reading)
+	assert.True(t, startedAt.Equal(past[0].Reading.StartedAt))
+	assert.Empty(t, past[0].Reading.Book.Title, "only what the check needs is loaded")
+	assert.Empty(t, past[0].Gathering.Participants)
+	assert.Nil(t, past[0].Voting)
+}
+
+func TestListUncataloguedSessions(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSession(clear, mongoDB)
+	repo := newSessionRepo(t, mongoDB)
+	ctx := testCtx(t)
+
+	// A completed round with a submitted book is listed until the book is
+	// referenced from the catalog.
+	past := newGatheringSession(100)
+	past.Gathering.Participants[0].Step = models.StepDone
+	past.Gathering.Participants[0].Book = &models.Book{Title: "Dune", Author: "Herbert"}
+	require.NoError(t, repo.CreateSession(ctx, past))
+	require.NoError(t, repo.SetStatus(ctx, past.ID, models.StatusCompleted))
+
+	// A round still gathering is not.
+	gathering := newGatheringSession(200)
+	gathering.Gathering.Participants[0].Step = models.StepDone
+	gathering.Gathering.Participants[0].Book = &models.Book{Title: "Solaris"}
+	require.NoError(t, repo.CreateSession(ctx, gathering))
+
+	sessions, err := repo.ListUncataloguedSessions(ctx)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, past.ID, sessions[0].ID)
+
+	p := sessions[0].Gathering.Participants[0]
+	p.BookID = primitive.NewObjectID()
+	require.NoError(t, repo.UpdateParticipant(ctx, past.ID, p))
+
+	sessions, err = repo.ListUncataloguedSessions(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
 // --- helpers ---
 
 func newSessionRepo(t *testing.T, db *mongo.Database) *SessionRepository {
@@ -293,6 +859,7 @@ func testCtx(t *testing.T) context.Context {
 func newGatheringSession(subscriberID int64) *models.BookClubSession {
 	now := time.Now().UTC()
 	return &models.BookClubSession{
+		ChatID:    testClubID,
 		Name:      "June 2026",
 		Status:    models.StatusGathering,
 		CreatedBy: subscriberID,
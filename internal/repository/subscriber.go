@@ -54,9 +54,10 @@ func (s *SubscriberRepository) SetArchiveSubscriber(ctx context.Context, subscri
 	return nil
 }
 
-func (s *SubscriberRepository) GetAllSubscribers(ctx context.Context) ([]*models.Subscriber, error) {
+// GetAllSubscribers returns the active subscribers of one club.
+func (s *SubscriberRepository) GetAllSubscribers(ctx context.Context, chatID int64) ([]*models.Subscriber, error) {
 	collection := s.db.Collection(subs_collection)
-	cursor, err := collection.Find(ctx, bson.M{"archived": false})
+	cursor, err := collection.Find(ctx, bson.M{"archived": false, "clubIds": chatID})
 	if err != nil {
 		return nil, err
 	}
@@ -91,3 +92,71 @@ func (s *SubscriberRepository) GetSubscriberById(ctx context.Context, id int64)
 
 	return &subscriber, nil
 }
+
+// AddClub adds a club to the subscriber's memberships and reactivates them.
+// Returns ErrNotFound if the subscriber does not exist.
+func (s *SubscriberRepository) AddClub(ctx context.Context, subscriberID, chatID int64) error {
+	collection := s.db.Collection(subs_collection)
+	filter := bson.M{"_id": subscriberID}
+	update := bson.M{
+		"$addToSet": bson.M{"clubIds": chatID},
+		"$set":      bson.M{"archived": false},
+	}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RemoveClub removes a club from the subscriber's memberships. Returns
+// ErrNotFound if the subscriber does not exist.
+func (s *SubscriberRepository) RemoveClub(ctx context.Context, subscriberID, chatID int64) error {
+	collection := s.db.Collection(subs_collection)
+	filter := bson.M{"_id": subscriberID}
+	update := bson.M{"$pull": bson.M{"clubIds": chatID}}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetCurrentClub stores the club a subscriber picked with /club. Returns
+// ErrNotFound if the subscriber does not exist.
+func (s *SubscriberRepository) SetCurrentClub(ctx context.Context, subscriberID, chatID int64) error {
+	collection := s.db.Collection(subs_collection)
+	filter := bson.M{"_id": subscriberID}
+	update := bson.M{"$set": bson.M{"currentClubId": chatID}}
+
+	res, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AssignLegacyClub makes every subscriber that predates multi-club support (no
+// clubIds field) a member of the given club. It returns how many were updated.
+func (s *SubscriberRepository) AssignLegacyClub(ctx context.Context, chatID int64) (int64, error) {
+	collection := s.db.Collection(subs_collection)
+	filter := bson.M{"clubIds": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"clubIds": []int64{chatID}}}
+
+	res, err := collection.UpdateMany(ctx, filter, update)
+	if err != nil {
+		return 0, err
+	}
+	return res.ModifiedCount, nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 )
@@ -211,6 +212,7 @@ func TestGetAllSubscribers(t *testing.T) {
 				LastName:  "Caruso",
 				Nick:      "Defenator",
 				JoinedAt:  joinedTime,
+				ClubIDs:   []int64{testClubID},
 			},
 			&models.Subscriber{
 				ID:        234,
@@ -218,6 +220,7 @@ func TestGetAllSubscribers(t *testing.T) {
 				LastName:  "Jepherson",
 				Nick:      "Nicky",
 				JoinedAt:  joinedTime.Add(1 * time.Hour),
+				ClubIDs:   []int64{testClubID},
 			},
 			&models.Subscriber{
 				ID:        345,
@@ -225,6 +228,7 @@ func TestGetAllSubscribers(t *testing.T) {
 				LastName:  "Embid",
 				Nick:      "Knee",
 				JoinedAt:  joinedTime.Add(2 * time.Hour),
+				ClubIDs:   []int64{testClubID},
 			},
 		}
 
@@ -236,7 +240,7 @@ func TestGetAllSubscribers(t *testing.T) {
 
 		getAllCtx, getAllCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer getAllCancel()
-		retrievedSubs, err := repo.GetAllSubscribers(getAllCtx)
+		retrievedSubs, err := repo.GetAllSubscribers(getAllCtx, testClubID)
 		assert.NoError(t, err)
 		assert.Len(t, retrievedSubs, 3)
 
@@ -271,8 +275,8 @@ func TestGetAllSubscribers(t *testing.T) {
 
 		joinedTime := time.Now().UTC().Truncate(time.Millisecond)
 		subscribers := []any{
-			&models.Subscriber{ID: 1, FirstName: "Active", Archived: false, JoinedAt: joinedTime},
-			&models.Subscriber{ID: 2, FirstName: "Archived", Archived: true, JoinedAt: joinedTime},
+			&models.Subscriber{ID: 1, FirstName: "Active", Archived: false, JoinedAt: joinedTime, ClubIDs: []int64{testClubID}},
+			&models.Subscriber{ID: 2, FirstName: "Archived", Archived: true, JoinedAt: joinedTime, ClubIDs: []int64{testClubID}},
 		}
 
 		insertCtx, insertCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -282,11 +286,110 @@ func TestGetAllSubscribers(t *testing.T) {
 
 		getAllCtx, getAllCancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer getAllCancel()
-		retrievedSubs, err := repo.GetAllSubscribers(getAllCtx)
+		retrievedSubs, err := repo.GetAllSubscribers(getAllCtx, testClubID)
 		assert.NoError(t, err)
 		assert.Len(t, retrievedSubs, 1)
 		assert.Equal(t, int64(1), retrievedSubs[0].ID)
 	})
+
+	t.Run("returns only members of the club", func(t *testing.T) {
+		mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+		defer cleanSubscriber(clear, mongoDB)
+
+		repo, err := NewSubscriberRepository(mongoDB)
+		assert.NoError(t, err)
+
+		joinedTime := time.Now().UTC().Truncate(time.Millisecond)
+		subscribers := []any{
+			&models.Subscriber{ID: 1, FirstName: "Here", JoinedAt: joinedTime, ClubIDs: []int64{testClubID}},
+			&models.Subscriber{ID: 2, FirstName: "Both", JoinedAt: joinedTime, ClubIDs: []int64{-200, testClubID}},
+			&models.Subscriber{ID: 3, FirstName: "Elsewhere", JoinedAt: joinedTime, ClubIDs: []int64{-200}},
+		}
+
+		insertCtx, insertCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer insertCancel()
+		_, err = repo.db.Collection(subs_collection).InsertMany(insertCtx, subscribers)
+		assert.NoError(t, err)
+
+		retrievedSubs, err := repo.GetAllSubscribers(testCtx(t), testClubID)
+		assert.NoError(t, err)
+		ids := []int64{}
+		for _, sub := range retrievedSubs {
+			ids = append(ids, sub.ID)
+		}
+		assert.ElementsMatch(t, []int64{1, 2}, ids)
+	})
+}
+
+func TestSubscriberClubs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSubscriber(clear, mongoDB)
+
+	repo, err := NewSubscriberRepository(mongoDB)
+	require.NoError(t, err)
+	ctx := testCtx(t)
+
+	insertSubscriber(repo, t, &models.Subscriber{ID: 1, FirstName: "Jane", Archived: true, ClubIDs: []int64{}})
+
+	require.NoError(t, repo.AddClub(ctx, 1, testClubID))
+	require.NoError(t, repo.AddClub(ctx, 1, testClubID)) // duplicate
+	require.NoError(t, repo.AddClub(ctx, 1, -200))
+	sub, err := repo.GetSubscriberById(ctx, 1)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []int64{testClubID, -200}, sub.ClubIDs)
+	assert.False(t, sub.Archived, "joining a club reactivates the subscriber")
+
+	require.NoError(t, repo.SetCurrentClub(ctx, 1, -200))
+	require.NoError(t, repo.RemoveClub(ctx, 1, testClubID))
+	sub, err = repo.GetSubscriberById(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-200}, sub.ClubIDs)
+	assert.Equal(t, int64(-200), sub.CurrentClubID)
+
+	assert.ErrorIs(t, repo.AddClub(ctx, 999, testClubID), ErrNotFound)
+	assert.ErrorIs(t, repo.RemoveClub(ctx, 999, testClubID), ErrNotFound)
+	assert.ErrorIs(t, repo.SetCurrentClub(ctx, 999, testClubID), ErrNotFound)
+}
+
+func TestAssignLegacyClub(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test")
+	}
+
+	mongoDB, clear := mongo_helpers.CreateTestMongoDB(t)
+	defer cleanSubscriber(clear, mongoDB)
+
+	repo, err := NewSubscriberRepository(mongoDB)
+	require.NoError(t, err)
+	ctx := testCtx(t)
+
+	// Written before multi-club support: no clubIds field at all.
+	_, err = repo.db.Collection(subs_collection).InsertMany(ctx, []any{
+		bson.M{"_id": int64(1), "firstName": "Legacy", "archived": false},
+		bson.M{"_id": int64(2), "firstName": "Legacy archived", "archived": true},
+	})
+	require.NoError(t, err)
+	insertSubscriber(repo, t, &models.Subscriber{ID: 3, FirstName: "Current", ClubIDs: []int64{-200}})
+
+	n, err := repo.AssignLegacyClub(ctx, testClubID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	legacy, err := repo.GetSubscriberById(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{testClubID}, legacy.ClubIDs)
+	current, err := repo.GetSubscriberById(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{-200}, current.ClubIDs, "subscribers with clubs are left alone")
+
+	// Running it again is a no-op.
+	n, err = repo.AssignLegacyClub(ctx, testClubID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), n)
 }
 
 func TestGetSubscriberById(t *testing.T) {
@@ -341,6 +444,9 @@ func TestGetSubscriberById(t *testing.T) {
 	})
 }
 
+// testClubID is the chat ID of the club the subscriber tests put members in.
+const testClubID int64 = -100
+
 // Helper function to insert a subscriber and verify the insert
 func insertSubscriber(repo *SubscriberRepository, t *testing.T, subscriber *models.Subscriber) {
 	insertCtx, insertCancel := context.WithTimeout(context.Background(), 5*time.Second)
@@ -5,42 +5,132 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"reflect"
+	"strings"
 )
 
 const folder = "./message"
 
 type LocalizedMessages struct {
-	AlreadySubscribedWaitForVoting     string `json:"already_subscribed_wait_for_voting"`
-	WelcomeBookClubNextVoting          string `json:"welcome_book_club_next_voting"`
-	VotingAlreadyStartedWaitForEnd     string `json:"voting_already_started_wait_for_end"`
-	VotingNotStartedOrEnded            string `json:"voting_not_started_or_ended"`
-	NotParticipantCurrentVoting        string `json:"not_participant_current_voting"`
-	WhoIsAuthor                        string `json:"who_is_author"`
-	WriteBookDescription               string `json:"write_book_description"`
-	AttachCoverPhoto                   string `json:"attach_cover_photo"`
-	BookAddedToNextVoting              string `json:"book_added_to_next_voting"`
-	ImageMissingBookAdded              string `json:"image_missing_book_added"`
-	VotingAlreadyCompleted             string `json:"voting_already_completed"`
-	AlreadyDeclinedSuggestion          string `json:"already_declined_suggestion"`
-	UnableToSuggestBook                string `json:"unable_to_suggest_book"`
-	PleaseSuggestBookTitle             string `json:"please_suggest_book_title"`
-	ErrorDeterminingWinner             string `json:"error_determining_winner"`
-	WeHaveAWinner                      string `json:"we_have_a_winner"`
-	NoClearWinnerManualVoting          string `json:"no_clear_winner_manual_voting"`
-	ChooseUpToTwoBooks                 string `json:"choose_up_to_two_books"`
-	NotEnoughBooksVotingCancelled      string `json:"not_enough_books_voting_cancelled"`
-	BookLabel                          string `json:"book_label"`
-	AuthorLabel                        string `json:"author_label"`
-	BookSubmissionDeadline             string `json:"book_submission_deadline"`
-	VotingEndsInHours                  string `json:"voting_ends_in_hours"`
-	CannotStartGatheringGroupIdMissing string `json:"cannot_start_gathering_groupId_missing"`
-	BookAlreadyProposed                string `json:"book_already_proposed"`
-	HelpInfo                           string `json:"help_info"`
-	SomethingWrong                     string `json:"something_wrong"`
-	NotSubscriber                      string `json:"not_subscriber"`
-	WelcomeBack                        string `json:"welcome_back"`
-	Unsubsribed                        string `json:"unsubsribed"`
-	GreetingMessage                    string `json:"greeting_message"`
+	AlreadySubscribedWaitForVoting string `json:"already_subscribed_wait_for_voting"`
+	WelcomeBookClubNextVoting      string `json:"welcome_book_club_next_voting"`
+	VotingAlreadyStartedWaitForEnd string `json:"voting_already_started_wait_for_end"`
+	VotingNotStartedOrEnded        string `json:"voting_not_started_or_ended"`
+	NotParticipantCurrentVoting    string `json:"not_participant_current_voting"`
+	WhoIsAuthor                    string `json:"who_is_author"`
+	WriteBookDescription           string `json:"write_book_description"`
+	AttachCoverPhoto               string `json:"attach_cover_photo"`
+	BookAddedToNextVoting          string `json:"book_added_to_next_voting"`
+	ImageMissingBookAdded          string `json:"image_missing_book_added"`
+	VotingAlreadyCompleted         string `json:"voting_already_completed"`
+	AlreadyDeclinedSuggestion      string `json:"already_declined_suggestion"`
+	UnableToSuggestBook            string `json:"unable_to_suggest_book"`
+	PleaseSuggestBookTitle         string `json:"please_suggest_book_title"`
+	ErrorDeterminingWinner         string `json:"error_determining_winner"`
+	WeHaveAWinner                  string `json:"we_have_a_winner"`
+	NoClearWinnerManualVoting      string `json:"no_clear_winner_manual_voting"`
+	RunoffStarted                  string `json:"runoff_started"`
+	RunoffQuestion                 string `json:"runoff_question"`
+	TieBrokenByDraw                string `json:"tie_broken_by_draw"`
+	TieBrokenByWaiting             string `json:"tie_broken_by_waiting"`
+	RankedVotingStarted            string `json:"ranked_voting_started"`
+	RankedBallot                   string `json:"ranked_ballot"`
+	RankedBallotSubmitted          string `json:"ranked_ballot_submitted"`
+	RankedBallotEmpty              string `json:"ranked_ballot_empty"`
+	RankedBallotClosed             string `json:"ranked_ballot_closed"`
+	RankedDone                     string `json:"ranked_done"`
+	RankedReset                    string `json:"ranked_reset"`
+	ReadingStarted                 string `json:"reading_started"`
+	NothingToReadNow               string `json:"nothing_to_read_now"`
+	NotReadingMember               string `json:"not_reading_member"`
+	AlreadyFinishedReading         string `json:"already_finished_reading"`
+	FinishReadingFirst             string `json:"finish_reading_first"`
+	RateTheBook                    string `json:"rate_the_book"`
+	InvalidRating                  string `json:"invalid_rating"`
+	WriteBookReview                string `json:"write_book_review"`
+	ReviewSaved                    string `json:"review_saved"`
+	ReadingInProgressHint          string `json:"reading_in_progress_hint"`
+	AbandonedReading               string `json:"abandoned_reading"`
+	AlreadyAbandonedReading        string `json:"already_abandoned_reading"`
+	ReadingDigestTitle             string `json:"reading_digest_title"`
+	ReadingDigestAverage           string `json:"reading_digest_average"`
+	ReadingDigestNoRatings         string `json:"reading_digest_no_ratings"`
+	ReadingDigestReviews           string `json:"reading_digest_reviews"`
+	ProgressUsage                  string `json:"progress_usage"`
+	ProgressOnlyWhileReading       string `json:"progress_only_while_reading"`
+	ProgressSaved                  string `json:"progress_saved"`
+	ReadingNudgePercent            string `json:"reading_nudge_percent"`
+	ReadingNudgePage               string `json:"reading_nudge_page"`
+	ReadingNudgeNoProgress         string `json:"reading_nudge_no_progress"`
+	HeatsStarted                   string `json:"heats_started"`
+	HeatQuestion                   string `json:"heat_question"`
+	HeatsFinished                  string `json:"heats_finished"`
+	ChooseUpToBooks                string `json:"choose_up_to_books"`
+	TooManyChoices                 string `json:"too_many_choices"`
+	NotEnoughBooksVotingCancelled  string `json:"not_enough_books_voting_cancelled"`
+	BookLabel                      string `json:"book_label"`
+	AuthorLabel                    string `json:"author_label"`
+	BookSubmissionDeadline         string `json:"book_submission_deadline"`
+	VotingEndsInHours              string `json:"voting_ends_in_hours"`
+	NoClubsYet                     string `json:"no_clubs_yet"`
+	NotInAnyClub                   string `json:"not_in_any_club"`
+	ChooseClubToSubscribe          string `json:"choose_club_to_subscribe"`
+	ChooseClub                     string `json:"choose_club"`
+	YourClubs                      string `json:"your_clubs"`
+	ClubSelected                   string `json:"club_selected"`
+	NotClubMember                  string `json:"not_club_member"`
+	AdminOnlyCommand               string `json:"admin_only_command"`
+	NothingToCancel                string `json:"nothing_to_cancel"`
+	RoundCancelled                 string `json:"round_cancelled"`
+	RoundCancelledConfirm          string `json:"round_cancelled_confirm"`
+	RoundCancelledGroup            string `json:"round_cancelled_group"`
+	ExtendUsage                    string `json:"extend_usage"`
+	StartVoteUsage                 string `json:"start_vote_usage"`
+	RoundTheme                     string `json:"round_theme"`
+	NothingToExtend                string `json:"nothing_to_extend"`
+	GatheringExtended              string `json:"gathering_extended"`
+	VotingExtended                 string `json:"voting_extended"`
+	BookFound                      string `json:"book_found"`
+	BookFoundYes                   string `json:"book_found_yes"`
+	BookFoundNo                    string `json:"book_found_no"`
+	BookNotFoundByISBN             string `json:"book_not_found_by_isbn"`
+	BookLinkUnreadable             string `json:"book_link_unreadable"`
+	BookRejectedAskTitle           string `json:"book_rejected_ask_title"`
+	BookProposalClosed             string `json:"book_proposal_closed"`
+	BookLookupPending              string `json:"book_lookup_pending"`
+	DuplicateFound                 string `json:"duplicate_found"`
+	DuplicateKeep                  string `json:"duplicate_keep"`
+	DuplicateOther                 string `json:"duplicate_other"`
+	PastWinnerRejected             string `json:"past_winner_rejected"`
+	PastWinnerTooSoon              string `json:"past_winner_too_soon"`
+	PastWinnerWarning              string `json:"past_winner_warning"`
+	AskBookTitle                   string `json:"ask_book_title"`
+	NothingToGoBack                string `json:"nothing_to_go_back"`
+	BookSubmittedUseEdit           string `json:"book_submitted_use_edit"`
+	EditUsage                      string `json:"edit_usage"`
+	EditFinishFirst                string `json:"edit_finish_first"`
+	EditCancelled                  string `json:"edit_cancelled"`
+	BookUpdated                    string `json:"book_updated"`
+	MyBook                         string `json:"my_book"`
+	MyBookDraft                    string `json:"my_book_draft"`
+	NoBookYet                      string `json:"no_book_yet"`
+	BookWithdrawn                  string `json:"book_withdrawn"`
+	QuestionOptional               string `json:"question_optional"`
+	AnswerRequired                 string `json:"answer_required"`
+	AnswerInvalid                  string `json:"answer_invalid"`
+	AnswerNotNumber                string `json:"answer_not_number"`
+	AnswerNotURL                   string `json:"answer_not_url"`
+	AnswerNotChoice                string `json:"answer_not_choice"`
+	AnswerNotPhoto                 string `json:"answer_not_photo"`
+	HelpInfo                       string `json:"help_info"`
+	SomethingWrong                 string `json:"something_wrong"`
+	NotSubscriber                  string `json:"not_subscriber"`
+	WelcomeBack                    string `json:"welcome_back"`
+	Unsubsribed                    string `json:"unsubsribed"`
+	GreetingMessage                string `json:"greeting_message"`
+	// Questions holds the texts of questionnaire questions that have no field
+	// above, by message key.
+	Questions map[string]string `json:"questions"`
 }
 
 func LoadMessaged() (*LocalizedMessages, error) {
@@ -79,3 +169,17 @@ func parseMessaged(r io.Reader) (*LocalizedMessages, error) {
 	}
 	return &res, nil
 }
+
+// Get returns the text with the given key: a field of LocalizedMessages by its
+// JSON name, or an entry of Questions. It is empty when there is none.
+func (m *LocalizedMessages) Get(key string) string {
+	v := reflect.ValueOf(m).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == key && t.Field(i).Type.Kind() == reflect.String {
+			return v.Field(i).String()
+		}
+	}
+	return m.Questions[key]
+}